@@ -0,0 +1,101 @@
+// Command apikey manages the API keys of the bookstore from the command
+// line, which is how the first admin key is created.
+//
+//	apikey create NAME SCOPES   create a key, e.g. apikey create ops admin
+//	apikey list                 list keys and when they were last used
+//	apikey revoke ID            revoke a key
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"go-bookstore-mysql-crud/pkg/auth"
+	"go-bookstore-mysql-crud/pkg/config"
+	"go-bookstore-mysql-crud/pkg/models"
+	"go-bookstore-mysql-crud/pkg/validation"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	keys := models.NewGormAPIKeyRepository(config.GetDatabase())
+	ctx := context.Background()
+
+	var err error
+	switch os.Args[1] {
+	case "create":
+		if len(os.Args) != 4 {
+			usage()
+		}
+		err = create(ctx, keys, os.Args[2], os.Args[3])
+	case "list":
+		err = list(ctx, keys)
+	case "revoke":
+		if len(os.Args) != 3 {
+			usage()
+		}
+		id, convErr := strconv.ParseUint(os.Args[2], 10, 0)
+		if convErr != nil || id == 0 {
+			log.Fatalf("invalid key ID %q", os.Args[2])
+		}
+		_, err = keys.Revoke(ctx, uint(id))
+	default:
+		usage()
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func create(ctx context.Context, keys models.APIKeyRepository, name, scopes string) error {
+	req := models.APIKeyRequest{Name: name, Scopes: strings.Split(scopes, ",")}
+	if err := validation.Struct(&req); err != nil {
+		return err
+	}
+	key := req.ToAPIKey()
+	secret, prefix, hash, err := auth.GenerateAPIKey()
+	if err != nil {
+		return err
+	}
+	key.Prefix, key.Hash = prefix, hash
+	if err := keys.Create(ctx, key); err != nil {
+		return err
+	}
+	fmt.Printf("Created key %d (%s) with scopes %s\n", key.ID, key.Name, strings.Join(key.Scopes, ","))
+	fmt.Println("Store it now, it cannot be shown again:")
+	fmt.Println(secret)
+	return nil
+}
+
+func list(ctx context.Context, keys models.APIKeyRepository) error {
+	all, err := keys.List(ctx)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tPREFIX\tSCOPES\tLAST USED\tREVOKED")
+	for _, k := range all {
+		used, revoked := "never", ""
+		if k.LastUsedAt != nil {
+			used = k.LastUsedAt.Format("2006-01-02 15:04")
+		}
+		if k.RevokedAt != nil {
+			revoked = k.RevokedAt.Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", k.ID, k.Name, k.Prefix, strings.Join(k.Scopes, ","), used, revoked)
+	}
+	return w.Flush()
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: apikey create NAME SCOPES | list | revoke ID")
+	os.Exit(2)
+}
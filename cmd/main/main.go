@@ -20,19 +20,27 @@
 package main
 
 import (
-	"log"
-	"net/http"
+	"context"
+	"log/slog"
+	"os"
+	"time"
 
 	"github.com/gorilla/mux"
-	"github.com/lpernett/godotenv"
 	httpSwagger "github.com/swaggo/http-swagger"
 
 	// Import the generated Swagger docs
 	_ "go-bookstore-mysql-crud/docs"
 
 	// "gorm.io/driver/mysql"
-	"fmt"
+	"go-bookstore-mysql-crud/pkg/auth"
+	"go-bookstore-mysql-crud/pkg/config"
+	"go-bookstore-mysql-crud/pkg/controllers"
+	"go-bookstore-mysql-crud/pkg/logging"
+	"go-bookstore-mysql-crud/pkg/metrics"
+	"go-bookstore-mysql-crud/pkg/migrations"
+	"go-bookstore-mysql-crud/pkg/models"
 	routespckg "go-bookstore-mysql-crud/pkg/routes"
+	"go-bookstore-mysql-crud/pkg/tracing"
 )
 
 // @title Go Bookstore API
@@ -54,30 +62,111 @@ import (
 // @in header
 // @name Authorization
 func main() {
-	// Load environment variables
-	err := godotenv.Load()
+	// Load environment variables and log as they configure
+	cfg := config.LoadConfig()
+	logging.Setup(cfg.Log.Level, cfg.Log.Format)
+
+	// Connect to the database, retrying while it starts up, and refuse to
+	// serve an outdated schema
+	db := config.GetDatabase()
+	sqlDB, err := db.DB()
+	if err != nil {
+		fatal("Failed to access database", err)
+	}
+	migrator, err := migrations.New(sqlDB)
+	if err != nil {
+		fatal("Failed to load migrations", err)
+	}
+	if err := migrator.RequireCurrent(context.Background()); err != nil {
+		fatal("Database schema is not current", err)
+	}
+	repos := models.NewGormRepositories(db)
+	tokens, err := auth.NewTokens(cfg.Auth)
 	if err != nil {
-		log.Println("No .env file found, using system environment variables")
+		fatal("Failed to load signing keys", err)
 	}
 
 	// Initialize the router
 	router := mux.NewRouter()
 
 	// Register API routes
-	routespckg.RegisterBookstoreRoutes(router)
+	handlers := routespckg.NewControllers(repos, tokens)
+	handlers.Health = controllers.NewHealthController(sqlDB, migrator)
+	handlers.Books.RequireIfMatch = cfg.Server.RequireIfMatch
+	handlers.Books.MaxBatchOperations = cfg.Server.MaxBatchOperations
+	handlers.Books.ReadTimeout = cfg.Server.ReadTimeout
+	handlers.Books.WriteTimeout = cfg.Server.WriteTimeout
+	routespckg.RegisterBookstoreRoutes(router, handlers)
+
+	// Trace requests and the queries they run
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		fatal("Failed to set up tracing", err)
+	}
+	if err := tracing.InstrumentGORM(db); err != nil {
+		fatal("Failed to trace database", err)
+	}
+	router.Use(tracing.Middleware)
+
+	// Prometheus metrics for requests, queries and the connection pool
+	m := metrics.New()
+	if err := m.InstrumentGORM(db); err != nil {
+		fatal("Failed to instrument database", err)
+	}
+	if err := m.RegisterDBStats(sqlDB, "bookstore"); err != nil {
+		fatal("Failed to register database metrics", err)
+	}
+	router.Use(m.Middleware)
+	router.NotFoundHandler = m.Unmatched(router.NotFoundHandler)
+	router.MethodNotAllowedHandler = m.Unmatched(router.MethodNotAllowedHandler)
+	router.Handle("/metrics", m.Handler()).Methods("GET")
 
 	// Swagger documentation route
 	router.PathPrefix("/swagger/").Handler(httpSwagger.Handler(
-		httpSwagger.URL("http://localhost:8080/swagger/doc.json"), // The URL pointing to API definition
+		httpSwagger.URL("/swagger/doc.json"), // The URL pointing to API definition
 		httpSwagger.DeepLinking(true),
 		httpSwagger.DocExpansion("none"),
 		httpSwagger.DomID("swagger-ui"),
 	))
 
-	http.Handle("/", router)
-	fmt.Println("Starting server on :8080...")
-	fmt.Println("Swagger documentation available at: http://localhost:8080/swagger/")
-	if err := http.ListenAndServe(":8080", nil); err != nil {
-		log.Fatal("Failed to start server: ", err)
+	// Books past their retention are purged in the background until the
+	// server has shut down
+	trashCtx, stopTrash := context.WithCancel(context.Background())
+	trashDone := make(chan struct{})
+	go func() {
+		defer close(trashDone)
+		if cfg.Trash.Retention > 0 {
+			purgeTrash(trashCtx, repos.Books, cfg.Trash)
+		}
+	}()
+
+	// Request IDs wrap the whole router so that unmatched routes get one too
+	srv := newServer(cfg, logging.Middleware(router))
+	slog.Info("Starting server", "addr", srv.Addr,
+		"swagger", "http://localhost"+srv.Addr+"/swagger/")
+	if err := serve(srv, cfg, handlers.Health.SetDraining); err != nil {
+		fatal("Server failed", err)
+	}
+
+	stopTrash()
+	<-trashDone
+
+	// Flush spans of the drained requests before exiting
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Error("Failed to flush traces", "error", err)
+	}
+
+	// Requests have drained, so no query can still be using the pool
+	if err := config.CloseDatabase(); err != nil {
+		slog.Error("Failed to close database", "error", err)
 	}
+	slog.Info("Server stopped")
+}
+
+// fatal logs msg with err and exits the process
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
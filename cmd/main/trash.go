@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"go-bookstore-mysql-crud/pkg/config"
+	"go-bookstore-mysql-crud/pkg/models"
+)
+
+// purgeTrash purges the books that have been in the trash for longer than
+// cfg.Retention, then again every cfg.PurgeInterval until ctx is done
+func purgeTrash(ctx context.Context, books models.BookRepository, cfg config.TrashConfig) {
+	ticker := time.NewTicker(cfg.PurgeInterval)
+	defer ticker.Stop()
+	for {
+		purged, err := books.PurgeDeletedBefore(ctx, time.Now().Add(-cfg.Retention))
+		switch {
+		case err != nil && ctx.Err() == nil:
+			slog.Error("Failed to purge expired books from the trash", "error", err)
+		case purged > 0:
+			slog.Info("Purged expired books from the trash", "count", purged, "retention", cfg.Retention)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
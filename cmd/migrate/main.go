@@ -0,0 +1,89 @@
+// Command migrate applies, reverts and reports the versioned database
+// migrations of the bookstore.
+//
+//	migrate up        apply every pending migration
+//	migrate down      revert the most recent migration
+//	migrate status    list migrations and whether they are applied
+//	migrate to N      migrate up or down to version N (0 reverts everything)
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"go-bookstore-mysql-crud/pkg/config"
+	"go-bookstore-mysql-crud/pkg/migrations"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	sqlDB, err := config.GetDatabase().DB()
+	if err != nil {
+		log.Fatal("Failed to access database: ", err)
+	}
+	migrator, err := migrations.New(sqlDB)
+	if err != nil {
+		log.Fatal("Failed to load migrations: ", err)
+	}
+	ctx := context.Background()
+
+	switch os.Args[1] {
+	case "up":
+		err = migrator.Up(ctx)
+	case "down":
+		err = migrator.Down(ctx)
+	case "to":
+		if len(os.Args) != 3 {
+			usage()
+		}
+		target, convErr := strconv.Atoi(os.Args[2])
+		if convErr != nil || target < 0 {
+			log.Fatalf("invalid version %q", os.Args[2])
+		}
+		err = migrator.To(ctx, target)
+	case "status":
+		err = printStatus(ctx, migrator)
+	default:
+		usage()
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if os.Args[1] != "status" {
+		current, err := migrator.Current(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Database is at version %d (latest %d)\n", current, migrator.Latest())
+	}
+}
+
+func printStatus(ctx context.Context, migrator *migrations.Migrator) error {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+	for _, s := range statuses {
+		state, at := "pending", ""
+		if s.Applied {
+			state, at = "applied", s.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", s.Version, s.Name, state, at)
+	}
+	return w.Flush()
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: migrate up|down|status|to N")
+	os.Exit(2)
+}
@@ -0,0 +1,89 @@
+// Command onix imports the products of an ONIX 3.0 message into the
+// catalog, like POST /books/import/onix but without a size limit, and
+// prints the products that were skipped or rejected.
+//
+//	onix [-dry-run] [-currency CODE] FILE   import FILE, or standard input when FILE is -
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"go-bookstore-mysql-crud/pkg/config"
+	"go-bookstore-mysql-crud/pkg/models"
+	"go-bookstore-mysql-crud/pkg/onix"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "report what would change without storing anything")
+	currency := flag.String("currency", "", "ISO-4217 currency of the prices to import")
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() != 1 {
+		usage()
+	}
+
+	var input io.Reader = os.Stdin
+	if name := flag.Arg(0); name != "-" {
+		file, err := os.Open(name)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer file.Close()
+		input = file
+	}
+
+	repos := models.NewGormRepositories(config.GetDatabase())
+	importer := &onix.Importer{
+		Books:    repos.Books,
+		Authors:  repos.Authors,
+		Currency: strings.ToUpper(*currency),
+		DryRun:   *dryRun,
+	}
+	report, err := importer.Import(context.Background(), input)
+	if report != nil {
+		if printErr := printReport(report); printErr != nil {
+			log.Fatal(printErr)
+		}
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	if report.Error != nil {
+		log.Fatal(report.Error)
+	}
+}
+
+func printReport(report *onix.Report) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "LINE\tRECORD\tISBN\tSTATUS\tREASON")
+	for _, res := range report.Results {
+		reason := res.Reason
+		if res.Err != nil {
+			reason = res.Err.Error()
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", res.Line, res.RecordReference, res.ISBN, res.Status, reason)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	verb := "Imported"
+	if report.DryRun {
+		verb = "Checked"
+	}
+	fmt.Printf("%s %d products: %d created, %d updated, %d skipped, %d failed\n",
+		verb, report.Products, report.Created, report.Updated, report.Skipped, report.Failed)
+	return nil
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: onix [-dry-run] [-currency CODE] FILE")
+	os.Exit(2)
+}
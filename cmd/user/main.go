@@ -0,0 +1,65 @@
+// Command user creates staff users who log in through /auth/login.
+//
+//	user create EMAIL ROLE   create a viewer, editor or admin
+//
+// The password is read from the USER_PASSWORD environment variable or,
+// when that is empty, from the first line of standard input.
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"go-bookstore-mysql-crud/pkg/auth"
+	"go-bookstore-mysql-crud/pkg/config"
+	"go-bookstore-mysql-crud/pkg/models"
+)
+
+// minPasswordLength is the shortest password accepted for new users
+const minPasswordLength = 12
+
+func main() {
+	if len(os.Args) != 4 || os.Args[1] != "create" {
+		usage()
+	}
+	email, role := models.NormalizeEmail(os.Args[2]), os.Args[3]
+	if !strings.Contains(email, "@") {
+		log.Fatalf("invalid email %q", os.Args[2])
+	}
+	if models.RoleScopes(role) == nil {
+		log.Fatalf("invalid role %q, expected viewer, editor or admin", role)
+	}
+
+	password := os.Getenv("USER_PASSWORD")
+	if password == "" {
+		fmt.Fprint(os.Stderr, "Password: ")
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			log.Fatal("failed to read password: ", err)
+		}
+		password = strings.TrimRight(line, "\r\n")
+	}
+	if len(password) < minPasswordLength {
+		log.Fatalf("password must be at least %d characters", minPasswordLength)
+	}
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	user := &models.User{Email: email, PasswordHash: hash, Role: role}
+	users := models.NewGormUserRepository(config.GetDatabase())
+	if err := users.Create(context.Background(), user); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Created %s %s (id %d)\n", user.Role, user.Email, user.ID)
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: user create EMAIL viewer|editor|admin")
+	os.Exit(2)
+}
@@ -24,9 +24,14 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/books": {
+        "/api-keys": {
             "get": {
-                "description": "Retrieve all books from the database",
+                "security": [
+                    {
+                        "api_key": []
+                    }
+                ],
+                "description": "Retrieve every API key, newest first, including revoked keys. Secrets are never returned.",
                 "consumes": [
                     "application/json"
                 ],
@@ -34,30 +39,43 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "books"
+                    "api-keys"
                 ],
-                "summary": "Get all books",
+                "summary": "List API keys",
                 "responses": {
                     "200": {
-                        "description": "List of books",
+                        "description": "API keys",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.BookResponse"
-                            }
+                            "$ref": "#/definitions/models.APIKeyListResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized - Missing or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden - Requires the admin scope",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
                         }
                     },
                     "500": {
-                        "description": "Internal server error",
+                        "description": "internal_error - Internal server error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/apperrors.Problem"
                         }
                     }
                 }
             },
             "post": {
-                "description": "Add a new book to the database",
+                "security": [
+                    {
+                        "api_key": []
+                    }
+                ],
+                "description": "Create an API key with the given scopes. The key is only returned in this response; store it securely.",
                 "consumes": [
                     "application/json"
                 ],
@@ -65,47 +83,68 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "books"
+                    "api-keys"
                 ],
-                "summary": "Create a new book",
+                "summary": "Create an API key",
                 "parameters": [
                     {
-                        "description": "Book object",
-                        "name": "book",
+                        "description": "API key object",
+                        "name": "key",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.BookRequest"
+                            "$ref": "#/definitions/models.APIKeyRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Created book",
+                        "description": "Created API key including its secret",
                         "schema": {
-                            "$ref": "#/definitions/models.BookResponse"
+                            "$ref": "#/definitions/models.APIKeyCreatedResponse"
                         }
                     },
                     "400": {
-                        "description": "Bad request - Invalid book data",
+                        "description": "bad_request - Malformed JSON or unknown fields",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized - Missing or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden - Requires the admin scope",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation_failed - Validation failed",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/apperrors.Problem"
                         }
                     },
                     "500": {
-                        "description": "Internal server error",
+                        "description": "internal_error - Internal server error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/apperrors.Problem"
                         }
                     }
                 }
             }
         },
-        "/books/{id}": {
-            "get": {
-                "description": "Retrieve a specific book by its ID",
+        "/api-keys/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "api_key": []
+                    }
+                ],
+                "description": "Revoke an API key; requests using it are rejected from then on. The key stays listed with its revocation time.",
                 "consumes": [
                     "application/json"
                 ],
@@ -113,13 +152,13 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "books"
+                    "api-keys"
                 ],
-                "summary": "Get a book by ID",
+                "summary": "Revoke an API key",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Book ID",
+                        "description": "API key ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -127,29 +166,47 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Book details",
+                        "description": "Revoked API key",
                         "schema": {
-                            "$ref": "#/definitions/models.BookResponse"
+                            "$ref": "#/definitions/models.APIKey"
                         }
                     },
                     "400": {
-                        "description": "Bad request - Invalid ID",
+                        "description": "bad_request - Invalid ID",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized - Missing or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden - Requires the admin scope",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not_found - API key not found",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
                         }
                     },
                     "500": {
-                        "description": "Internal server error",
+                        "description": "internal_error - Internal server error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/apperrors.Problem"
                         }
                     }
                 }
-            },
-            "put": {
-                "description": "Update an existing book's information",
+            }
+        },
+        "/auth/login": {
+            "post": {
+                "description": "Exchange a staff email and password for a short-lived access token and a longer-lived refresh token. Send the access token as \"Authorization: Bearer \u003ctoken\u003e\".",
                 "consumes": [
                     "application/json"
                 ],
@@ -157,52 +214,190 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "books"
+                    "auth"
                 ],
-                "summary": "Update a book",
+                "summary": "Log in",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Book ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Credentials",
+                        "name": "credentials",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.LoginRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Issued tokens",
+                        "schema": {
+                            "$ref": "#/definitions/auth.TokenPair"
+                        }
+                    },
+                    "400": {
+                        "description": "bad_request - Malformed JSON or unknown fields",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
                     },
+                    "401": {
+                        "description": "unauthorized - Invalid email or password",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation_failed - Validation failed",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal_error - Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/refresh": {
+            "post": {
+                "description": "Exchange a refresh token for a new access and refresh token. The new access token reflects the user's current role.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Refresh tokens",
+                "parameters": [
                     {
-                        "description": "Updated book object",
-                        "name": "book",
+                        "description": "Refresh token",
+                        "name": "token",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.BookRequest"
+                            "$ref": "#/definitions/models.RefreshRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Updated book",
+                        "description": "Issued tokens",
                         "schema": {
-                            "$ref": "#/definitions/models.BookResponse"
+                            "$ref": "#/definitions/auth.TokenPair"
+                        }
+                    },
+                    "400": {
+                        "description": "bad_request - Malformed JSON or unknown fields",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized - Invalid or expired refresh token",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation_failed - Validation failed",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal_error - Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/authors": {
+            "get": {
+                "security": [
+                    {
+                        "api_key": []
+                    }
+                ],
+                "description": "Retrieve a page of authors ordered by name",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authors"
+                ],
+                "summary": "List authors",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of authors to return (default 20, max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of authors to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Case-insensitive substring of the name",
+                        "name": "name",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Page of authors",
+                        "schema": {
+                            "$ref": "#/definitions/models.AuthorListResponse"
                         }
                     },
                     "400": {
-                        "description": "Bad request - Invalid ID or book data",
+                        "description": "invalid_query - Invalid query parameters",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized - Missing or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden - Requires the read scope",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
                         }
                     },
                     "500": {
-                        "description": "Internal server error",
+                        "description": "internal_error - Internal server error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/apperrors.Problem"
                         }
                     }
                 }
             },
-            "delete": {
-                "description": "Remove a book from the database by ID",
+            "post": {
+                "security": [
+                    {
+                        "api_key": []
+                    }
+                ],
+                "description": "Add a new author. Names differing only in case, punctuation or spacing are considered the same author.",
                 "consumes": [
                     "application/json"
                 ],
@@ -210,13 +405,88 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "books"
+                    "authors"
                 ],
-                "summary": "Delete a book",
+                "summary": "Create a new author",
+                "parameters": [
+                    {
+                        "description": "Author object",
+                        "name": "author",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.AuthorRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Created author",
+                        "schema": {
+                            "$ref": "#/definitions/models.Author"
+                        }
+                    },
+                    "400": {
+                        "description": "bad_request - Malformed JSON or unknown fields",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized - Missing or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden - Requires the write scope",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "conflict - Author already exists",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation_failed - Validation failed",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal_error - Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/authors/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "api_key": []
+                    }
+                ],
+                "description": "Retrieve a specific author by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authors"
+                ],
+                "summary": "Get an author by ID",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Book ID",
+                        "description": "Author ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -224,79 +494,3114 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Deleted book",
+                        "description": "Author details",
                         "schema": {
-                            "$ref": "#/definitions/models.BookResponse"
+                            "$ref": "#/definitions/models.Author"
                         }
                     },
                     "400": {
-                        "description": "Bad request - Invalid ID",
+                        "description": "bad_request - Invalid ID",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized - Missing or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden - Requires the read scope",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not_found - Author not found",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/apperrors.Problem"
                         }
                     },
                     "500": {
-                        "description": "Internal server error",
+                        "description": "internal_error - Internal server error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/apperrors.Problem"
                         }
                     }
                 }
-            }
-        }
-    },
-    "definitions": {
-        "models.BookRequest": {
-            "description": "Book request model for API documentation",
-            "type": "object",
-            "required": [
-                "author",
-                "price",
-                "title"
-            ],
-            "properties": {
-                "author": {
-                    "description": "@Description Author of the book\n@Example \"F. Scott Fitzgerald\"",
-                    "type": "string",
-                    "example": "F. Scott Fitzgerald"
-                },
-                "price": {
-                    "description": "@Description Price of the book\n@Example \"$15.99\"",
-                    "type": "string",
-                    "example": "$15.99"
-                },
-                "title": {
-                    "description": "@Description Title of the book\n@Example \"The Great Gatsby\"",
-                    "type": "string",
-                    "example": "The Great Gatsby"
-                }
-            }
-        },
-        "models.BookResponse": {
-            "description": "Book response model for API documentation",
-            "type": "object",
-            "properties": {
+            },
+            "put": {
+                "security": [
+                    {
+                        "api_key": []
+                    }
+                ],
+                "description": "Rename an existing author",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authors"
+                ],
+                "summary": "Update an author",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Author ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Updated author object",
+                        "name": "author",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.AuthorRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated author",
+                        "schema": {
+                            "$ref": "#/definitions/models.Author"
+                        }
+                    },
+                    "400": {
+                        "description": "bad_request - Invalid ID, malformed JSON or unknown fields",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized - Missing or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden - Requires the write scope",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not_found - Author not found",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "conflict - Another author has an equivalent name",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation_failed - Validation failed",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal_error - Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "api_key": []
+                    }
+                ],
+                "description": "Remove an author that is not credited on any book",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authors"
+                ],
+                "summary": "Delete an author",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Author ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Deleted author",
+                        "schema": {
+                            "$ref": "#/definitions/models.Author"
+                        }
+                    },
+                    "400": {
+                        "description": "bad_request - Invalid ID",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized - Missing or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden - Requires the admin scope",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not_found - Author not found",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "conflict - Author is credited on books",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal_error - Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/authors/{id}/books": {
+            "get": {
+                "security": [
+                    {
+                        "api_key": []
+                    }
+                ],
+                "description": "Retrieve a page of the books crediting an author in any role. Accepts the same parameters as GET /books.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authors"
+                ],
+                "summary": "List an author's books",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Author ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of books to return (default 20, max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of books to skip; cannot be combined with cursor",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Opaque cursor from next_cursor or prev_cursor of a previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated sort fields (id, title, author, price, created_at); prefix with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Page of books",
+                        "schema": {
+                            "$ref": "#/definitions/models.BookListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "bad_request - Invalid ID or query parameters",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized - Missing or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden - Requires the read scope",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not_found - Author not found",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal_error - Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/books": {
+            "get": {
+                "security": [
+                    {
+                        "api_key": []
+                    }
+                ],
+                "description": "Retrieve a page of books, optionally filtered and sorted. Pages are addressed either by offset or by the opaque cursors returned in the previous response. With facets, the response also counts the books matching the filters by author, category, price range and publication year; the counts cover every page and respect every filter, including the one a facet is about.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "books"
+                ],
+                "summary": "List books",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of books to return (default 20, max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of books to skip; cannot be combined with cursor",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Opaque cursor from next_cursor or prev_cursor of a previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated sort fields (id, title, author, price, created_at); prefix with - for descending, e.g. title,-price",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Case-insensitive substring of the title",
+                        "name": "title",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Case-insensitive substring of the author",
+                        "name": "author",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of an author the books credit",
+                        "name": "author_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Category, ignoring case",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Publication year",
+                        "name": "year",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Minimum price in major units, inclusive, e.g. 9.99",
+                        "name": "min_price",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Maximum price in major units, inclusive, e.g. 19.99",
+                        "name": "max_price",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ISO-4217 currency of min_price, max_price and the price facet (default USD)",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated facets to count: author, category, price, year",
+                        "name": "facets",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Page of books",
+                        "schema": {
+                            "$ref": "#/definitions/models.BookListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid_query - Invalid query parameters",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized - Missing or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden - Requires the read scope",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal_error - Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "api_key": []
+                    }
+                ],
+                "description": "Add a new book to the database",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "books"
+                ],
+                "summary": "Create a new book",
+                "parameters": [
+                    {
+                        "description": "Book object",
+                        "name": "book",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.BookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Created book",
+                        "schema": {
+                            "$ref": "#/definitions/models.BookResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the new book"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "bad_request - Malformed JSON or unknown fields",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized - Missing or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden - Requires the write scope",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "conflict - Book already exists or ISBN is taken",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "payload_too_large - Request body too large",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation_failed - Validation failed or unknown author",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal_error - Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/books/export": {
+            "get": {
+                "security": [
+                    {
+                        "api_key": []
+                    }
+                ],
+                "description": "Stream every book matching the filters, in the order GET /books would list them. format=csv (the default) has the columns id, title, author, price (in major units), currency, isbn_13, isbn_10, publisher, description, category, published_year, version, created_at and updated_at, and can be imported again; cells starting with =, +, - or @ are prefixed with ' so that spreadsheets do not run them as formulas. format=marc renders binary MARC 21 bibliographic records and format=marcxml a MARCXML collection, with the ID (001), ISBNs and price (020, 365), main author (100), title (245), publisher and year (264), description (520), category (653) and other contributors (700) of each book.",
+                "produces": [
+                    "text/csv",
+                    "application/marc",
+                    "application/marcxml+xml"
+                ],
+                "tags": [
+                    "books"
+                ],
+                "summary": "Export books",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "csv (default), marc or marcxml",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated sort fields (id, title, author, price, created_at); prefix with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Case-insensitive substring of the title",
+                        "name": "title",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Case-insensitive substring of the author",
+                        "name": "author",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of an author the books credit",
+                        "name": "author_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Category, ignoring case",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Publication year",
+                        "name": "year",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Minimum price in major units, inclusive, e.g. 9.99",
+                        "name": "min_price",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Maximum price in major units, inclusive, e.g. 19.99",
+                        "name": "max_price",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ISO-4217 currency of min_price and max_price (default USD)",
+                        "name": "currency",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The books in the requested format",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid_query - Invalid format or query parameters",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized - Missing or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden - Requires the read scope",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal_error - Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/books/import": {
+            "post": {
+                "security": [
+                    {
+                        "api_key": []
+                    }
+                ],
+                "description": "Create and update books from a CSV file with a header row. Columns named title, author, price, currency, isbn (or isbn_13, isbn_10, ean), publisher, description, category and published_year (or year) are read; map others with map=Header:field, or ignore a column with map=Header:-. Rows whose ISBN belongs to a book update it, changing only the non-empty mapped cells; other rows create a book. Prices are read in major units in the row's currency, or as strings such as \"$15.99\" when there is no currency column. Rows are validated and stored on their own, so a bad row does not stop the import; the report lists every rejected row by line. With dry_run=true nothing is stored. A GET /books/export file can be imported as is.",
+                "consumes": [
+                    "text/csv"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "books"
+                ],
+                "summary": "Import books from CSV",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Check the file and report what would change without storing anything",
+                        "name": "dry_run",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Column mapping as Header:field, where field is title, author, price, currency, isbn, publisher, description, category, published_year or - to ignore the column",
+                        "name": "map",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Field delimiter (default ,)",
+                        "name": "delimiter",
+                        "in": "query"
+                    },
+                    {
+                        "description": "CSV file with a header row",
+                        "name": "file",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Import report",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ImportReport"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid_query - Invalid dry_run, map or delimiter; bad_request - The file could not be read",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized - Missing or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden - Requires the write scope",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "payload_too_large - File larger than 32 MiB",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation_failed - Missing header row or no column maps to a book field",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal_error - Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/books/import/onix": {
+            "post": {
+                "security": [
+                    {
+                        "api_key": []
+                    }
+                ],
+                "description": "Create and update books from the products of an ONIX 3.0 message, in reference or short tags. The distinctive title, the authors, editors and translators, the publisher, the description, the main subject as the category, the year of publication, the recommended retail price and the ISBN of each product are read. Products whose ISBN belongs to a book update it, keeping the fields the product does not provide; other products create a book. Deletion notices, products without an ISBN and books already up to date are skipped. The message is read and stored a product at a time, and a bad product does not stop the import; the report counts the products created and updated and lists those skipped or rejected. With dry_run=true nothing is stored. The import runs past APP_READ_TIMEOUT and APP_WRITE_TIMEOUT as long as the message keeps arriving. Messages over 512 MiB are imported with the onix command.",
+                "consumes": [
+                    "text/xml"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "books"
+                ],
+                "summary": "Import books from an ONIX 3.0 feed",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Check the message and report what would change without storing anything",
+                        "name": "dry_run",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ISO-4217 currency of the prices to import; by default the recommended retail price in any supported currency",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "description": "ONIX 3.0 message",
+                        "name": "message",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Import report",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ONIXReport"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid_query - Invalid dry_run or currency; bad_request - Not an ONIX 3.0 message",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized - Missing or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden - Requires the write scope",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "payload_too_large - Message larger than 512 MiB",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal_error - Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/books/isbn/{isbn}": {
+            "get": {
+                "security": [
+                    {
+                        "api_key": []
+                    }
+                ],
+                "description": "Resolve an ISBN-10 or ISBN-13, e.g. from a scanned barcode, to a catalog entry. Hyphens and spaces are ignored.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "books"
+                ],
+                "summary": "Get a book by ISBN",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ISBN-10 or ISBN-13",
+                        "name": "isbn",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of a cached copy; answered with 304 if it is still current",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Book details",
+                        "schema": {
+                            "$ref": "#/definitions/models.BookResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Current version of the book"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Not modified - the cached copy is current"
+                    },
+                    "400": {
+                        "description": "bad_request - Invalid ISBN",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized - Missing or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden - Requires the read scope",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not_found - No book has this ISBN",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal_error - Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/books/search": {
+            "get": {
+                "security": [
+                    {
+                        "api_key": []
+                    }
+                ],
+                "description": "Full-text search of the title, author and description of the books, most relevant first; title matches weigh the most. A book matches when it contains every word of q. Quote words to search for a \"phrase\" and end a word with * to search for a prefix, e.g. gats*. Words shorter than 3 characters and common words such as \"the\" are ignored outside phrases. Each result carries its highlighted matches.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "books"
+                ],
+                "summary": "Search books",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Search query, e.g. \\",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of books to return (default 20, max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of books to skip",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Page of results",
+                        "schema": {
+                            "$ref": "#/definitions/models.BookSearchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid_query - Missing q, q without searchable words or invalid pagination",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized - Missing or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden - Requires the read scope",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal_error - Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/books/trash": {
+            "get": {
+                "security": [
+                    {
+                        "api_key": []
+                    }
+                ],
+                "description": "Retrieve a page of the books in the trash, most recently deleted first. Deleted books can be restored until they are purged, by hand or once the retention period has passed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "books"
+                ],
+                "summary": "List deleted books",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of books to return (default 20, max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of books to skip",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Page of deleted books",
+                        "schema": {
+                            "$ref": "#/definitions/models.BookListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid_query - Invalid query parameters",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized - Missing or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden - Requires the read scope",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal_error - Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/books/trash/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "api_key": []
+                    }
+                ],
+                "description": "Permanently remove a book from the trash, together with its author credits and stock ledger. This cannot be undone.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "books"
+                ],
+                "summary": "Purge a deleted book",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Book ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Purged book",
+                        "schema": {
+                            "$ref": "#/definitions/models.BookResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "bad_request - Invalid ID",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized - Missing or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden - Requires the admin scope",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not_found - Book not found",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "conflict - Book is not in the trash",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal_error - Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/books/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "api_key": []
+                    }
+                ],
+                "description": "Retrieve a specific book by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "books"
+                ],
+                "summary": "Get a book by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Book ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of a cached copy; answered with 304 if it is still current",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Book details",
+                        "schema": {
+                            "$ref": "#/definitions/models.BookResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Current version of the book"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Not modified - the cached copy is current"
+                    },
+                    "400": {
+                        "description": "bad_request - Invalid ID",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized - Missing or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden - Requires the read scope",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not_found - Book not found",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal_error - Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "api_key": []
+                    }
+                ],
+                "description": "Replace all fields of an existing book; optional fields left out, such as isbn, are cleared. Use PATCH to change only some fields.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "books"
+                ],
+                "summary": "Replace a book",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Book ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version being replaced; required unless APP_REQUIRE_IF_MATCH is false",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Updated book object",
+                        "name": "book",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.BookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated book",
+                        "schema": {
+                            "$ref": "#/definitions/models.BookResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "New version of the book"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "bad_request - Invalid ID, malformed JSON or unknown fields",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized - Missing or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden - Requires the write scope",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not_found - Book not found",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "conflict - ISBN is used by another book",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "precondition_failed - The book has changed since the ETag in If-Match",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "payload_too_large - Request body too large",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation_failed - Validation failed or unknown author",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "precondition_required - If-Match is missing",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal_error - Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "api_key": []
+                    }
+                ],
+                "description": "Move a book to the trash by ID. It can be restored until it is purged, by hand or once the retention period has passed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "books"
+                ],
+                "summary": "Delete a book",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Book ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version being deleted; required unless APP_REQUIRE_IF_MATCH is false",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Deleted book",
+                        "schema": {
+                            "$ref": "#/definitions/models.BookResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "bad_request - Invalid ID",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized - Missing or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden - Requires the admin scope",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not_found - Book not found",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "precondition_failed - The book has changed since the ETag in If-Match",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "precondition_required - If-Match is missing",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal_error - Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "api_key": []
+                    }
+                ],
+                "description": "Change some fields of a book. The patch applies to the book in the shape of a PUT body (title, author, authors, price, publisher, description, category, published_year and isbn) and the result is validated like one before anything is stored. Send a JSON Merge Patch (RFC 7396) as application/merge-patch+json, e.g. {\"isbn\": null, \"category\": \"Classics\"} to clear the ISBN and recategorize the book, or a JSON Patch (RFC 6902) as application/json-patch+json, e.g. [{\"op\": \"test\", \"path\": \"/title\", \"value\": \"Old\"}, {\"op\": \"replace\", \"path\": \"/title\", \"value\": \"New\"}]. Changing author but not authors credits the book from the new free text.",
+                "consumes": [
+                    "application/merge-patch+json",
+                    "application/json-patch+json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "books"
+                ],
+                "summary": "Patch a book",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Book ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version being patched; required unless APP_REQUIRE_IF_MATCH is false",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "JSON Patch operations, or a JSON Merge Patch document with the fields of models.BookRequest",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/patch.Operation"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Patched book",
+                        "schema": {
+                            "$ref": "#/definitions/models.BookResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "New version of the book"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "bad_request - Invalid ID, malformed patch or unknown fields",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized - Missing or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden - Requires the write scope",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not_found - Book not found",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "conflict - A test operation failed, a path does not exist or the ISBN is used by another book",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "precondition_failed - The book has changed since the ETag in If-Match",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "payload_too_large - Request body too large",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "415": {
+                        "description": "unsupported_media_type - Content-Type is not a supported patch format",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation_failed - The patched book is invalid or credits an unknown author",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "precondition_required - If-Match is missing",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal_error - Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/books/{id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "api_key": []
+                    }
+                ],
+                "description": "Take a book out of the trash, with its authors and stock as they were when it was deleted",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "books"
+                ],
+                "summary": "Restore a deleted book",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Book ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Restored book",
+                        "schema": {
+                            "$ref": "#/definitions/models.BookResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "bad_request - Invalid ID",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized - Missing or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden - Requires the write scope",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not_found - Book not found",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "conflict - Book is not in the trash",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal_error - Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/books/{id}/stock": {
+            "get": {
+                "security": [
+                    {
+                        "api_key": []
+                    }
+                ],
+                "description": "Retrieve the on-hand, reserved and available quantity of a book and whether it needs reordering",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stock"
+                ],
+                "summary": "Get a book's stock level",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Book ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Stock level",
+                        "schema": {
+                            "$ref": "#/definitions/models.StockLevel"
+                        }
+                    },
+                    "400": {
+                        "description": "bad_request - Invalid ID",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized - Missing or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden - Requires the read scope",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not_found - Book not found",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal_error - Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "api_key": []
+                    }
+                ],
+                "description": "Change the reorder threshold of a book. Quantities can only be changed by posting movements.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stock"
+                ],
+                "summary": "Update a book's stock settings",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Book ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Stock settings",
+                        "name": "settings",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.StockSettingsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated stock level",
+                        "schema": {
+                            "$ref": "#/definitions/models.StockLevel"
+                        }
+                    },
+                    "400": {
+                        "description": "bad_request - Invalid ID, malformed JSON or unknown fields",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized - Missing or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden - Requires the write scope",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not_found - Book not found",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation_failed - Validation failed",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal_error - Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/books/{id}/stock/movements": {
+            "get": {
+                "security": [
+                    {
+                        "api_key": []
+                    }
+                ],
+                "description": "Retrieve a page of the book's stock ledger, newest first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stock"
+                ],
+                "summary": "List a book's stock movements",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Book ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of movements to return (default 20, max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of movements to skip",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Page of movements",
+                        "schema": {
+                            "$ref": "#/definitions/models.StockMovementListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "bad_request - Invalid ID or query parameters",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized - Missing or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden - Requires the read scope",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not_found - Book not found",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal_error - Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "api_key": []
+                    }
+                ],
+                "description": "Append a movement to the book's stock ledger and apply it to the stock level atomically. Sales and reservations are rejected when fewer copies are available, so concurrent sales can never drive stock negative.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stock"
+                ],
+                "summary": "Record a stock movement",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Book ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Stock movement",
+                        "name": "movement",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.StockMovementRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Recorded movement and resulting stock level",
+                        "schema": {
+                            "$ref": "#/definitions/models.StockMovementResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "bad_request - Invalid ID, malformed JSON or unknown fields",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized - Missing or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden - Requires the write scope",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not_found - Book not found",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "insufficient_stock - Not enough stock for the movement",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation_failed - Validation failed",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal_error - Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/books:batch": {
+            "post": {
+                "security": [
+                    {
+                        "api_key": []
+                    }
+                ],
+                "description": "Apply up to APP_BATCH_MAX_OPERATIONS (default 500) book operations in order. Creates take a book like POST /books, updates replace a book like PUT /books/{id} and deletes move one to the trash; updates and deletes carry the book's version in place of If-Match. Consecutive creates are inserted together. In atomic mode, the default, the operations share one transaction: if any fails nothing is stored, and the other operations report batch_aborted. In best_effort mode each operation is stored unless it fails. Every result carries the status the operation would have been answered with on its own. Deletes require the admin scope.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "books"
+                ],
+                "summary": "Create, update and delete books in bulk",
+                "parameters": [
+                    {
+                        "description": "Operations to apply",
+                        "name": "batch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.BatchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Every operation was stored",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.BatchResponse"
+                        }
+                    },
+                    "207": {
+                        "description": "Some operations failed; see the status and error of each result",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.BatchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "bad_request - Malformed JSON or unknown fields",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized - Missing or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden - Requires the write scope, or the admin scope for deletes",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "payload_too_large - Request body too large",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation_failed - Unknown mode, or no or too many operations",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal_error - Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/healthz": {
+            "get": {
+                "description": "Report that the process is running. Does not check dependencies.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Liveness probe",
+                "responses": {
+                    "200": {
+                        "description": "Process is alive",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.HealthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Report whether the service can serve traffic: the database answers a ping and its schema is at the version the binary expects.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Readiness probe",
+                "responses": {
+                    "200": {
+                        "description": "Ready",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.HealthResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Not ready",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.HealthResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "apperrors.Code": {
+            "type": "string",
+            "enum": [
+                "bad_request",
+                "invalid_query",
+                "validation_failed",
+                "payload_too_large",
+                "unsupported_media_type",
+                "unauthorized",
+                "forbidden",
+                "not_found",
+                "route_not_found",
+                "method_not_allowed",
+                "conflict",
+                "insufficient_stock",
+                "batch_aborted",
+                "precondition_failed",
+                "precondition_required",
+                "internal_error"
+            ],
+            "x-enum-varnames": [
+                "CodeBadRequest",
+                "CodeInvalidQuery",
+                "CodeValidation",
+                "CodePayloadTooLarge",
+                "CodeUnsupportedMediaType",
+                "CodeUnauthorized",
+                "CodeForbidden",
+                "CodeNotFound",
+                "CodeRouteNotFound",
+                "CodeMethodNotAllowed",
+                "CodeConflict",
+                "CodeInsufficientStock",
+                "CodeBatchAborted",
+                "CodePreconditionFailed",
+                "CodePreconditionRequired",
+                "CodeInternal"
+            ]
+        },
+        "apperrors.Problem": {
+            "description": "RFC 7807 problem details; branch on code, not on title or detail",
+            "type": "object",
+            "properties": {
+                "code": {
+                    "description": "@Description Stable machine readable error code\n@Example \"not_found\"",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/apperrors.Code"
+                        }
+                    ],
+                    "example": "not_found"
+                },
+                "detail": {
+                    "description": "@Description Explanation specific to this occurrence\n@Example \"book not found\"",
+                    "type": "string",
+                    "example": "book not found"
+                },
+                "errors": {
+                    "description": "@Description Field failures for validation_failed problems",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/validation.FieldError"
+                    }
+                },
+                "instance": {
+                    "description": "@Description Request path that produced the problem\n@Example \"/books/42\"",
+                    "type": "string",
+                    "example": "/books/42"
+                },
+                "request_id": {
+                    "description": "@Description ID of the request, also sent in the X-Request-ID header; quote it when reporting a problem\n@Example \"4bf92f3577b34da6a3ce929d0e0e4736\"",
+                    "type": "string",
+                    "example": "4bf92f3577b34da6a3ce929d0e0e4736"
+                },
+                "status": {
+                    "description": "@Description HTTP status code\n@Example 404",
+                    "type": "integer",
+                    "example": 404
+                },
+                "title": {
+                    "description": "@Description Short summary of the problem type\n@Example \"Resource not found\"",
+                    "type": "string",
+                    "example": "Resource not found"
+                },
+                "type": {
+                    "description": "@Description URI reference identifying the problem type\n@Example \"urn:bookstore:problem:not_found\"",
+                    "type": "string",
+                    "example": "urn:bookstore:problem:not_found"
+                }
+            }
+        },
+        "auth.TokenPair": {
+            "description": "Access and refresh tokens issued at login",
+            "type": "object",
+            "properties": {
+                "access_token": {
+                    "description": "@Description JWT to send as \"Authorization: Bearer \u003ctoken\u003e\"",
+                    "type": "string"
+                },
+                "expires_in": {
+                    "description": "@Description Lifetime of the access token in seconds\n@Example 900",
+                    "type": "integer",
+                    "example": 900
+                },
+                "refresh_token": {
+                    "description": "@Description JWT to exchange for a new pair at /auth/refresh",
+                    "type": "string"
+                },
+                "token_type": {
+                    "description": "@Description Always \"Bearer\"\n@Example \"Bearer\"",
+                    "type": "string",
+                    "example": "Bearer"
+                }
+            }
+        },
+        "controllers.BatchOperation": {
+            "description": "Creation, replacement or deletion of one book",
+            "type": "object",
+            "properties": {
+                "book": {
+                    "description": "@Description Book to create, or to replace the book with on update, with the fields of models.BookRequest",
+                    "type": "object"
+                },
+                "id": {
+                    "description": "@Description ID of the book to update or delete\n@Example 42",
+                    "type": "integer",
+                    "example": 42
+                },
+                "op": {
+                    "description": "@Description create, update or delete\n@Example \"update\"",
+                    "type": "string",
+                    "example": "update"
+                },
+                "version": {
+                    "description": "@Description Version of the book to update or delete, as sent in If-Match; required unless APP_REQUIRE_IF_MATCH is false\n@Example 3",
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "controllers.BatchRequest": {
+            "description": "Book operations applied together",
+            "type": "object",
+            "properties": {
+                "mode": {
+                    "description": "@Description atomic (default) stores every operation or none; best_effort stores each operation that succeeds\n@Example \"atomic\"",
+                    "type": "string",
+                    "example": "atomic"
+                },
+                "operations": {
+                    "description": "@Description Operations to apply, in order",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/controllers.BatchOperation"
+                    }
+                }
+            }
+        },
+        "controllers.BatchResponse": {
+            "description": "Outcome of every operation of a book batch",
+            "type": "object",
+            "properties": {
+                "failed": {
+                    "description": "@Description Number of operations that failed or were aborted\n@Example 0",
+                    "type": "integer",
+                    "example": 0
+                },
+                "mode": {
+                    "description": "@Description Mode the batch was applied in\n@Example \"atomic\"",
+                    "type": "string",
+                    "example": "atomic"
+                },
+                "results": {
+                    "description": "@Description Outcome of each operation, in request order",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/controllers.BatchResult"
+                    }
+                },
+                "succeeded": {
+                    "description": "@Description Number of operations stored\n@Example 2",
+                    "type": "integer",
+                    "example": 2
+                }
+            }
+        },
+        "controllers.BatchResult": {
+            "description": "Outcome of one operation of a book batch",
+            "type": "object",
+            "properties": {
+                "book": {
+                    "description": "@Description The book as stored, when the operation succeeded",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Book"
+                        }
+                    ]
+                },
+                "error": {
+                    "description": "@Description Why the operation failed; batch_aborted when it was rolled back because another operation of an atomic batch failed",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    ]
+                },
+                "id": {
+                    "description": "@Description ID of the created, updated or deleted book\n@Example 42",
+                    "type": "integer",
+                    "example": 42
+                },
+                "index": {
+                    "description": "@Description Position of the operation in the request, starting at 0\n@Example 0",
+                    "type": "integer",
+                    "example": 0
+                },
+                "op": {
+                    "description": "@Description Operation as sent\n@Example \"create\"",
+                    "type": "string",
+                    "example": "create"
+                },
+                "status": {
+                    "description": "@Description HTTP status the operation would have been answered with on its own\n@Example 200",
+                    "type": "integer",
+                    "example": 200
+                }
+            }
+        },
+        "controllers.ComponentHealth": {
+            "description": "Status of one dependency",
+            "type": "object",
+            "properties": {
+                "error": {
+                    "description": "@Description Why the component is unavailable\n@Example \"dial tcp 10.0.0.5:3306: connect: connection refused\"",
+                    "type": "string",
+                    "example": "dial tcp 10.0.0.5:3306: connect: connection refused"
+                },
+                "latency_ms": {
+                    "description": "@Description How long the check took in milliseconds\n@Example 3",
+                    "type": "integer",
+                    "example": 3
+                },
+                "latest": {
+                    "description": "@Description Schema version the binary expects, for the migrations component\n@Example 13",
+                    "type": "integer",
+                    "example": 13
+                },
+                "status": {
+                    "description": "@Description ok or unavailable\n@Example \"ok\"",
+                    "type": "string",
+                    "example": "ok"
+                },
+                "version": {
+                    "description": "@Description Applied schema version, for the migrations component\n@Example 13",
+                    "type": "integer",
+                    "example": 13
+                }
+            }
+        },
+        "controllers.HealthResponse": {
+            "description": "Overall and per-component health",
+            "type": "object",
+            "properties": {
+                "components": {
+                    "description": "@Description Status of each dependency, by name",
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/controllers.ComponentHealth"
+                    }
+                },
+                "status": {
+                    "description": "@Description ok when every component is ok, otherwise unavailable\n@Example \"ok\"",
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
+        "controllers.ImportReport": {
+            "description": "Outcome of a CSV import",
+            "type": "object",
+            "properties": {
+                "columns": {
+                    "description": "@Description Book field read from each mapped column, by header",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "created": {
+                    "description": "@Description Number of books created, or that would be in a dry run\n@Example 100",
+                    "type": "integer",
+                    "example": 100
+                },
+                "dry_run": {
+                    "description": "@Description Whether the import only checked the file\n@Example false",
+                    "type": "boolean",
+                    "example": false
+                },
+                "errors": {
+                    "description": "@Description Why each rejected row was rejected, in file order",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/controllers.ImportRowError"
+                    }
+                },
+                "failed": {
+                    "description": "@Description Number of rows rejected\n@Example 3",
+                    "type": "integer",
+                    "example": 3
+                },
+                "ignored_columns": {
+                    "description": "@Description Headers of the columns that were not read",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "rows": {
+                    "description": "@Description Number of data rows read\n@Example 120",
+                    "type": "integer",
+                    "example": 120
+                },
+                "updated": {
+                    "description": "@Description Number of books updated, or that would be in a dry run\n@Example 17",
+                    "type": "integer",
+                    "example": 17
+                }
+            }
+        },
+        "controllers.ImportRowError": {
+            "description": "Failure of one row of a CSV import",
+            "type": "object",
+            "properties": {
+                "error": {
+                    "description": "@Description Why the row was rejected",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    ]
+                },
+                "isbn": {
+                    "description": "@Description ISBN of the row, as written in the file\n@Example \"978-0-7432-7356-5\"",
+                    "type": "string",
+                    "example": "978-0-7432-7356-5"
+                },
+                "line": {
+                    "description": "@Description Line of the row in the file; the header is line 1\n@Example 7",
+                    "type": "integer",
+                    "example": 7
+                }
+            }
+        },
+        "controllers.ONIXReport": {
+            "description": "Outcome of an ONIX import",
+            "type": "object",
+            "properties": {
+                "created": {
+                    "description": "@Description Number of books created, or that would be in a dry run\n@Example 180",
+                    "type": "integer",
+                    "example": 180
+                },
+                "dry_run": {
+                    "description": "@Description Whether the import only checked the message\n@Example false",
+                    "type": "boolean",
+                    "example": false
+                },
+                "error": {
+                    "description": "@Description Why the message could not be read to its end; the products before it were imported",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    ]
+                },
+                "failed": {
+                    "description": "@Description Number of products rejected\n@Example 5",
+                    "type": "integer",
+                    "example": 5
+                },
+                "products": {
+                    "description": "@Description Number of products read\n@Example 250",
+                    "type": "integer",
+                    "example": 250
+                },
+                "results": {
+                    "description": "@Description Outcome of each skipped or rejected product, in message order; created and updated products are only counted",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/controllers.ONIXResult"
+                    }
+                },
+                "skipped": {
+                    "description": "@Description Number of products skipped: deletion notices, products without an ISBN and books already up to date\n@Example 15",
+                    "type": "integer",
+                    "example": 15
+                },
+                "updated": {
+                    "description": "@Description Number of books updated, or that would be in a dry run\n@Example 50",
+                    "type": "integer",
+                    "example": 50
+                }
+            }
+        },
+        "controllers.ONIXResult": {
+            "description": "Outcome of a skipped or rejected product of an ONIX import",
+            "type": "object",
+            "properties": {
+                "book_id": {
+                    "description": "@Description ID of the book the product was matched to by ISBN\n@Example 42",
+                    "type": "integer",
+                    "example": 42
+                },
+                "error": {
+                    "description": "@Description Why the product was rejected",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    ]
+                },
+                "isbn": {
+                    "description": "@Description ISBN of the product, as written in the message\n@Example \"9780743273565\"",
+                    "type": "string",
+                    "example": "9780743273565"
+                },
+                "line": {
+                    "description": "@Description Line of the message the product starts on\n@Example 42",
+                    "type": "integer",
+                    "example": 42
+                },
+                "reason": {
+                    "description": "@Description Why the product was skipped\n@Example \"the book is up to date\"",
+                    "type": "string",
+                    "example": "the book is up to date"
+                },
+                "record_reference": {
+                    "description": "@Description RecordReference of the product\n@Example \"com.example.9780743273565\"",
+                    "type": "string",
+                    "example": "com.example.9780743273565"
+                },
+                "status": {
+                    "description": "@Description skipped or failed\n@Example \"skipped\"",
+                    "type": "string",
+                    "example": "skipped"
+                }
+            }
+        },
+        "models.APIKey": {
+            "description": "API key metadata; the secret is never returned after creation",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "description": "@Description When the key was created\n@Example \"2023-01-01T00:00:00Z\"",
+                    "type": "string",
+                    "example": "2023-01-01T00:00:00Z"
+                },
+                "id": {
+                    "description": "@Description Unique identifier for the key\n@Example 1",
+                    "type": "integer",
+                    "example": 1
+                },
+                "last_used_at": {
+                    "description": "@Description When the key last authenticated a request, to the minute\n@Example \"2023-01-01T00:00:00Z\"",
+                    "type": "string",
+                    "example": "2023-01-01T00:00:00Z"
+                },
+                "name": {
+                    "description": "@Description What the key is used for\n@Example \"warehouse sync\"",
+                    "type": "string",
+                    "example": "warehouse sync"
+                },
+                "prefix": {
+                    "description": "@Description Public part of the key, used to identify it in logs\n@Example \"bks_1a2b3c4d\"",
+                    "type": "string",
+                    "example": "bks_1a2b3c4d"
+                },
+                "revoked_at": {
+                    "description": "@Description When the key was revoked\n@Example \"2023-01-01T00:00:00Z\"",
+                    "type": "string",
+                    "example": "2023-01-01T00:00:00Z"
+                },
+                "scopes": {
+                    "description": "@Description Scopes granted to the key",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "read",
+                        "write"
+                    ]
+                }
+            }
+        },
+        "models.APIKeyCreatedResponse": {
+            "description": "Newly created API key including its secret",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "description": "@Description When the key was created\n@Example \"2023-01-01T00:00:00Z\"",
+                    "type": "string",
+                    "example": "2023-01-01T00:00:00Z"
+                },
+                "id": {
+                    "description": "@Description Unique identifier for the key\n@Example 1",
+                    "type": "integer",
+                    "example": 1
+                },
+                "key": {
+                    "description": "@Description The full key; store it now, it cannot be retrieved again\n@Example \"bks_1a2b3c4d_Jz0f9...\"",
+                    "type": "string",
+                    "example": "bks_1a2b3c4d_Jz0f9..."
+                },
+                "last_used_at": {
+                    "description": "@Description When the key last authenticated a request, to the minute\n@Example \"2023-01-01T00:00:00Z\"",
+                    "type": "string",
+                    "example": "2023-01-01T00:00:00Z"
+                },
+                "name": {
+                    "description": "@Description What the key is used for\n@Example \"warehouse sync\"",
+                    "type": "string",
+                    "example": "warehouse sync"
+                },
+                "prefix": {
+                    "description": "@Description Public part of the key, used to identify it in logs\n@Example \"bks_1a2b3c4d\"",
+                    "type": "string",
+                    "example": "bks_1a2b3c4d"
+                },
+                "revoked_at": {
+                    "description": "@Description When the key was revoked\n@Example \"2023-01-01T00:00:00Z\"",
+                    "type": "string",
+                    "example": "2023-01-01T00:00:00Z"
+                },
+                "scopes": {
+                    "description": "@Description Scopes granted to the key",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "read",
+                        "write"
+                    ]
+                }
+            }
+        },
+        "models.APIKeyListResponse": {
+            "description": "API key list model for API documentation",
+            "type": "object",
+            "properties": {
+                "data": {
+                    "description": "@Description Keys, newest first, including revoked keys",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.APIKey"
+                    }
+                }
+            }
+        },
+        "models.APIKeyRequest": {
+            "description": "API key request model for API documentation",
+            "type": "object",
+            "required": [
+                "name",
+                "scopes"
+            ],
+            "properties": {
+                "name": {
+                    "description": "@Description What the key is used for\n@Example \"warehouse sync\"",
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "warehouse sync"
+                },
+                "scopes": {
+                    "description": "@Description Scopes to grant: read, write and/or admin",
+                    "type": "array",
+                    "maxItems": 3,
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "read",
+                        "write"
+                    ]
+                }
+            }
+        },
+        "models.Author": {
+            "description": "Author model for the bookstore API",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "description": "@Description When the author was created\n@Example \"2023-01-01T00:00:00Z\"",
+                    "type": "string",
+                    "example": "2023-01-01T00:00:00Z"
+                },
+                "id": {
+                    "description": "@Description Unique identifier for the author\n@Example 1",
+                    "type": "integer",
+                    "example": 1
+                },
+                "name": {
+                    "description": "@Description Display name of the author\n@Example \"F. Scott Fitzgerald\"",
+                    "type": "string",
+                    "example": "F. Scott Fitzgerald"
+                },
+                "updated_at": {
+                    "description": "@Description When the author was last updated\n@Example \"2023-01-01T00:00:00Z\"",
+                    "type": "string",
+                    "example": "2023-01-01T00:00:00Z"
+                }
+            }
+        },
+        "models.AuthorFacet": {
+            "description": "Number of matching books crediting an author",
+            "type": "object",
+            "properties": {
+                "author_id": {
+                    "description": "@Description Identifier of the author, for the author_id filter\n@Example 1",
+                    "type": "integer",
+                    "example": 1
+                },
+                "count": {
+                    "description": "@Description Number of matching books\n@Example 4",
+                    "type": "integer",
+                    "example": 4
+                },
+                "name": {
+                    "description": "@Description Display name of the author\n@Example \"F. Scott Fitzgerald\"",
+                    "type": "string",
+                    "example": "F. Scott Fitzgerald"
+                }
+            }
+        },
+        "models.AuthorListResponse": {
+            "description": "Paginated author list model for API documentation",
+            "type": "object",
+            "properties": {
+                "data": {
+                    "description": "@Description Authors in the page",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Author"
+                    }
+                },
+                "pagination": {
+                    "description": "@Description Pagination metadata",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Pagination"
+                        }
+                    ]
+                }
+            }
+        },
+        "models.AuthorRequest": {
+            "description": "Author request model for API documentation",
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "description": "@Description Display name of the author\n@Example \"F. Scott Fitzgerald\"",
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "F. Scott Fitzgerald"
+                }
+            }
+        },
+        "models.Book": {
+            "description": "Book model for the bookstore API",
+            "type": "object",
+            "properties": {
+                "author": {
+                    "description": "@Description Author of the book\n@Example \"F. Scott Fitzgerald\"",
+                    "type": "string",
+                    "example": "F. Scott Fitzgerald"
+                },
+                "authors": {
+                    "description": "@Description Contributors credited on the book, in credit order",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.BookAuthor"
+                    }
+                },
+                "category": {
+                    "description": "@Description Category the book is shelved under\n@Example \"Fiction\"",
+                    "type": "string",
+                    "example": "Fiction"
+                },
+                "created_at": {
+                    "description": "@Description When the book was created",
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "description": "@Description When the book was moved to the trash; unset for live books",
+                    "type": "string"
+                },
+                "description": {
+                    "description": "@Description Blurb of the book as plain text\n@Example \"The story of the mysteriously wealthy Jay Gatsby and his love for Daisy Buchanan.\"",
+                    "type": "string",
+                    "example": "The story of the mysteriously wealthy Jay Gatsby and his love for Daisy Buchanan."
+                },
+                "id": {
+                    "description": "@Description Unique identifier for the book\n@Example 1",
+                    "type": "integer",
+                    "example": 1
+                },
+                "isbn_10": {
+                    "description": "@Description ISBN-10 of the book without hyphens, for 978-prefixed ISBNs\n@Example \"0743273567\"",
+                    "type": "string",
+                    "example": "0743273567"
+                },
+                "isbn_13": {
+                    "description": "@Description ISBN-13 of the book without hyphens\n@Example \"9780743273565\"",
+                    "type": "string",
+                    "example": "9780743273565"
+                },
+                "price": {
+                    "description": "@Description Price of the book",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Money"
+                        }
+                    ]
+                },
+                "published_year": {
+                    "description": "@Description Year the book was published\n@Example 1925",
+                    "type": "integer",
+                    "example": 1925
+                },
+                "publisher": {
+                    "description": "@Description Publisher of the book\n@Example \"Charles Scribner's Sons\"",
+                    "type": "string",
+                    "example": "Charles Scribner's Sons"
+                },
+                "title": {
+                    "description": "@Description Title of the book\n@Example \"The Great Gatsby\"",
+                    "type": "string",
+                    "example": "The Great Gatsby"
+                },
+                "updated_at": {
+                    "description": "@Description When the book was last updated",
+                    "type": "string"
+                },
+                "version": {
+                    "description": "@Description Revision of the book, incremented by every change and served as its ETag\n@Example 3",
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "models.BookAuthor": {
+            "description": "Contributor credited on a book",
+            "type": "object",
+            "properties": {
+                "author_id": {
+                    "description": "@Description Identifier of the author\n@Example 1",
+                    "type": "integer",
+                    "example": 1
+                },
+                "name": {
+                    "description": "@Description Name of the author\n@Example \"F. Scott Fitzgerald\"",
+                    "type": "string",
+                    "example": "F. Scott Fitzgerald"
+                },
+                "position": {
+                    "description": "@Description Position of the contributor in the book's credits, starting at 0\n@Example 0",
+                    "type": "integer",
+                    "example": 0
+                },
+                "role": {
+                    "description": "@Description Contribution of the author: author, editor or translator\n@Example \"author\"",
+                    "type": "string",
+                    "example": "author"
+                }
+            }
+        },
+        "models.BookAuthorRequest": {
+            "description": "Contributor of a book in requests",
+            "type": "object",
+            "required": [
+                "author_id"
+            ],
+            "properties": {
+                "author_id": {
+                    "description": "@Description Identifier of an existing author\n@Example 1",
+                    "type": "integer",
+                    "example": 1
+                },
+                "role": {
+                    "description": "@Description author (default), editor or translator\n@Example \"author\"",
+                    "type": "string",
+                    "enum": [
+                        "author",
+                        "editor",
+                        "translator"
+                    ],
+                    "example": "author"
+                }
+            }
+        },
+        "models.BookFacets": {
+            "description": "Counts of the books matching the filters, by the requested facets",
+            "type": "object",
+            "properties": {
+                "author": {
+                    "description": "@Description Authors credited on the most books, most books first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AuthorFacet"
+                    }
+                },
+                "category": {
+                    "description": "@Description Categories with the most books, most books first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CategoryFacet"
+                    }
+                },
+                "price": {
+                    "description": "@Description Books priced in the facet currency, by price range from the cheapest",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PriceFacet"
+                    }
+                },
+                "year": {
+                    "description": "@Description Books by publication year, most recent first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.YearFacet"
+                    }
+                }
+            }
+        },
+        "models.BookListResponse": {
+            "description": "Paginated book list model for API documentation",
+            "type": "object",
+            "properties": {
+                "data": {
+                    "description": "@Description Books in the page",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.BookResponse"
+                    }
+                },
+                "facets": {
+                    "description": "@Description Counts of the books matching the filters, when facets are requested",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.BookFacets"
+                        }
+                    ]
+                },
+                "pagination": {
+                    "description": "@Description Pagination metadata",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Pagination"
+                        }
+                    ]
+                }
+            }
+        },
+        "models.BookRequest": {
+            "description": "Book request model for API documentation",
+            "type": "object",
+            "required": [
+                "price",
+                "title"
+            ],
+            "properties": {
+                "author": {
+                    "description": "@Description Author of the book as free text; names separated by \"\u0026\", \"and\" or \";\" are credited individually. Required unless authors is given.\n@Example \"F. Scott Fitzgerald\"",
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "F. Scott Fitzgerald"
+                },
+                "authors": {
+                    "description": "@Description Existing authors to credit, in credit order; takes precedence over author",
+                    "type": "array",
+                    "maxItems": 20,
+                    "items": {
+                        "$ref": "#/definitions/models.BookAuthorRequest"
+                    }
+                },
+                "category": {
+                    "description": "@Description Category the book is shelved under\n@Example \"Fiction\"",
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "Fiction"
+                },
+                "description": {
+                    "description": "@Description Blurb of the book as plain text\n@Example \"The story of the mysteriously wealthy Jay Gatsby and his love for Daisy Buchanan.\"",
+                    "type": "string",
+                    "maxLength": 10000,
+                    "example": "The story of the mysteriously wealthy Jay Gatsby and his love for Daisy Buchanan."
+                },
+                "isbn": {
+                    "description": "@Description ISBN-10 or ISBN-13, hyphens optional; stored as ISBN-13\n@Example \"978-0-7432-7356-5\"",
+                    "type": "string",
+                    "example": "978-0-7432-7356-5"
+                },
+                "price": {
+                    "description": "@Description Price of the book; a legacy string such as \"$15.99\" is also accepted",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Money"
+                        }
+                    ]
+                },
+                "published_year": {
+                    "description": "@Description Year the book was published\n@Example 1925",
+                    "type": "integer",
+                    "maximum": 9999,
+                    "minimum": 1000,
+                    "example": 1925
+                },
+                "publisher": {
+                    "description": "@Description Publisher of the book\n@Example \"Charles Scribner's Sons\"",
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "Charles Scribner's Sons"
+                },
+                "title": {
+                    "description": "@Description Title of the book\n@Example \"The Great Gatsby\"",
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "The Great Gatsby"
+                }
+            }
+        },
+        "models.BookResponse": {
+            "description": "Book response model for API documentation",
+            "type": "object",
+            "properties": {
+                "author": {
+                    "description": "@Description Author of the book\n@Example \"F. Scott Fitzgerald\"",
+                    "type": "string",
+                    "example": "F. Scott Fitzgerald"
+                },
+                "authors": {
+                    "description": "@Description Contributors credited on the book, in credit order",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.BookAuthor"
+                    }
+                },
+                "category": {
+                    "description": "@Description Category the book is shelved under\n@Example \"Fiction\"",
+                    "type": "string",
+                    "example": "Fiction"
+                },
+                "created_at": {
+                    "description": "@Description When the book was created\n@Example \"2023-01-01T00:00:00Z\"",
+                    "type": "string",
+                    "example": "2023-01-01T00:00:00Z"
+                },
+                "deleted_at": {
+                    "description": "@Description When the book was moved to the trash; only set in trash listings\n@Example \"2023-02-01T00:00:00Z\"",
+                    "type": "string",
+                    "example": "2023-02-01T00:00:00Z"
+                },
+                "description": {
+                    "description": "@Description Blurb of the book as plain text\n@Example \"The story of the mysteriously wealthy Jay Gatsby and his love for Daisy Buchanan.\"",
+                    "type": "string",
+                    "example": "The story of the mysteriously wealthy Jay Gatsby and his love for Daisy Buchanan."
+                },
+                "id": {
+                    "description": "@Description Unique identifier for the book\n@Example 1",
+                    "type": "integer",
+                    "example": 1
+                },
+                "isbn_10": {
+                    "description": "@Description ISBN-10 of the book without hyphens, for 978-prefixed ISBNs\n@Example \"0743273567\"",
+                    "type": "string",
+                    "example": "0743273567"
+                },
+                "isbn_13": {
+                    "description": "@Description ISBN-13 of the book without hyphens\n@Example \"9780743273565\"",
+                    "type": "string",
+                    "example": "9780743273565"
+                },
+                "price": {
+                    "description": "@Description Price of the book",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Money"
+                        }
+                    ]
+                },
+                "published_year": {
+                    "description": "@Description Year the book was published\n@Example 1925",
+                    "type": "integer",
+                    "example": 1925
+                },
+                "publisher": {
+                    "description": "@Description Publisher of the book\n@Example \"Charles Scribner's Sons\"",
+                    "type": "string",
+                    "example": "Charles Scribner's Sons"
+                },
+                "title": {
+                    "description": "@Description Title of the book\n@Example \"The Great Gatsby\"",
+                    "type": "string",
+                    "example": "The Great Gatsby"
+                },
+                "updated_at": {
+                    "description": "@Description When the book was last updated\n@Example \"2023-01-01T00:00:00Z\"",
+                    "type": "string",
+                    "example": "2023-01-01T00:00:00Z"
+                },
+                "version": {
+                    "description": "@Description Revision of the book, incremented by every change and served as its ETag\n@Example 3",
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "models.BookSearchHit": {
+            "description": "Book found by a search, with its relevance and highlighted matches",
+            "type": "object",
+            "properties": {
                 "author": {
                     "description": "@Description Author of the book\n@Example \"F. Scott Fitzgerald\"",
                     "type": "string",
                     "example": "F. Scott Fitzgerald"
                 },
+                "authors": {
+                    "description": "@Description Contributors credited on the book, in credit order",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.BookAuthor"
+                    }
+                },
+                "category": {
+                    "description": "@Description Category the book is shelved under\n@Example \"Fiction\"",
+                    "type": "string",
+                    "example": "Fiction"
+                },
                 "created_at": {
                     "description": "@Description When the book was created\n@Example \"2023-01-01T00:00:00Z\"",
                     "type": "string",
                     "example": "2023-01-01T00:00:00Z"
                 },
+                "deleted_at": {
+                    "description": "@Description When the book was moved to the trash; only set in trash listings\n@Example \"2023-02-01T00:00:00Z\"",
+                    "type": "string",
+                    "example": "2023-02-01T00:00:00Z"
+                },
+                "description": {
+                    "description": "@Description Blurb of the book as plain text\n@Example \"The story of the mysteriously wealthy Jay Gatsby and his love for Daisy Buchanan.\"",
+                    "type": "string",
+                    "example": "The story of the mysteriously wealthy Jay Gatsby and his love for Daisy Buchanan."
+                },
+                "highlights": {
+                    "description": "@Description The title, author and an excerpt of the description with matching words wrapped in \u003cmark\u003e tags, HTML-escaped, for the fields that match",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
                 "id": {
                     "description": "@Description Unique identifier for the book\n@Example 1",
                     "type": "integer",
                     "example": 1
                 },
+                "isbn_10": {
+                    "description": "@Description ISBN-10 of the book without hyphens, for 978-prefixed ISBNs\n@Example \"0743273567\"",
+                    "type": "string",
+                    "example": "0743273567"
+                },
+                "isbn_13": {
+                    "description": "@Description ISBN-13 of the book without hyphens\n@Example \"9780743273565\"",
+                    "type": "string",
+                    "example": "9780743273565"
+                },
                 "price": {
-                    "description": "@Description Price of the book\n@Example \"$15.99\"",
+                    "description": "@Description Price of the book",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Money"
+                        }
+                    ]
+                },
+                "published_year": {
+                    "description": "@Description Year the book was published\n@Example 1925",
+                    "type": "integer",
+                    "example": 1925
+                },
+                "publisher": {
+                    "description": "@Description Publisher of the book\n@Example \"Charles Scribner's Sons\"",
                     "type": "string",
-                    "example": "$15.99"
+                    "example": "Charles Scribner's Sons"
+                },
+                "score": {
+                    "description": "@Description Relevance of the book; higher is better, and scores are only comparable within one response\n@Example 7.25",
+                    "type": "number",
+                    "example": 7.25
                 },
                 "title": {
                     "description": "@Description Title of the book\n@Example \"The Great Gatsby\"",
@@ -307,6 +3612,402 @@ const docTemplate = `{
                     "description": "@Description When the book was last updated\n@Example \"2023-01-01T00:00:00Z\"",
                     "type": "string",
                     "example": "2023-01-01T00:00:00Z"
+                },
+                "version": {
+                    "description": "@Description Revision of the book, incremented by every change and served as its ETag\n@Example 3",
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "models.BookSearchResponse": {
+            "description": "Paginated search results model for API documentation",
+            "type": "object",
+            "properties": {
+                "data": {
+                    "description": "@Description Books in the page, most relevant first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.BookSearchHit"
+                    }
+                },
+                "pagination": {
+                    "description": "@Description Pagination metadata",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Pagination"
+                        }
+                    ]
+                }
+            }
+        },
+        "models.CategoryFacet": {
+            "description": "Number of matching books in a category",
+            "type": "object",
+            "properties": {
+                "category": {
+                    "description": "@Description Category, for the category filter\n@Example \"Fiction\"",
+                    "type": "string",
+                    "example": "Fiction"
+                },
+                "count": {
+                    "description": "@Description Number of matching books\n@Example 120",
+                    "type": "integer",
+                    "example": 120
+                }
+            }
+        },
+        "models.LoginRequest": {
+            "description": "Login request model for API documentation",
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "description": "@Description Login email\n@Example \"jo@example.com\"",
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "jo@example.com"
+                },
+                "password": {
+                    "description": "@Description Password\n@Example \"correct horse battery staple\"",
+                    "type": "string",
+                    "maxLength": 1024,
+                    "example": "correct horse battery staple"
+                }
+            }
+        },
+        "models.Money": {
+            "description": "Monetary amount in minor units with an ISO-4217 currency code",
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "description": "@Description Amount in minor units of the currency, e.g. cents\n@Example 1599",
+                    "type": "integer",
+                    "example": 1599
+                },
+                "currency": {
+                    "description": "@Description ISO-4217 currency code\n@Example \"USD\"",
+                    "type": "string",
+                    "example": "USD"
+                }
+            }
+        },
+        "models.Pagination": {
+            "description": "Pagination metadata for list responses",
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "description": "@Description Maximum number of books in the page\n@Example 20",
+                    "type": "integer",
+                    "example": 20
+                },
+                "next": {
+                    "description": "@Description Link to the next page\n@Example \"/books?limit=20\u0026offset=60\"",
+                    "type": "string",
+                    "example": "/books?limit=20\u0026offset=60"
+                },
+                "next_cursor": {
+                    "description": "@Description Opaque cursor for the next page",
+                    "type": "string"
+                },
+                "offset": {
+                    "description": "@Description Number of books skipped, when paginating by offset\n@Example 40",
+                    "type": "integer",
+                    "example": 40
+                },
+                "prev": {
+                    "description": "@Description Link to the previous page\n@Example \"/books?limit=20\u0026offset=20\"",
+                    "type": "string",
+                    "example": "/books?limit=20\u0026offset=20"
+                },
+                "prev_cursor": {
+                    "description": "@Description Opaque cursor for the previous page",
+                    "type": "string"
+                },
+                "total": {
+                    "description": "@Description Number of books matching the filters\n@Example 250",
+                    "type": "integer",
+                    "example": 250
+                }
+            }
+        },
+        "models.PriceFacet": {
+            "description": "Number of matching books priced within a range",
+            "type": "object",
+            "properties": {
+                "count": {
+                    "description": "@Description Number of matching books\n@Example 35",
+                    "type": "integer",
+                    "example": 35
+                },
+                "max": {
+                    "description": "@Description Highest price of the range, inclusive, for the max_price filter; absent for the last range",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Money"
+                        }
+                    ]
+                },
+                "min": {
+                    "description": "@Description Lowest price of the range, inclusive, for the min_price filter",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Money"
+                        }
+                    ]
+                }
+            }
+        },
+        "models.RefreshRequest": {
+            "description": "Token refresh request model for API documentation",
+            "type": "object",
+            "required": [
+                "refresh_token"
+            ],
+            "properties": {
+                "refresh_token": {
+                    "description": "@Description Refresh token from a previous login or refresh",
+                    "type": "string"
+                }
+            }
+        },
+        "models.StockLevel": {
+            "description": "Current inventory of a book",
+            "type": "object",
+            "properties": {
+                "available": {
+                    "description": "@Description Copies that can still be sold (on_hand - reserved)\n@Example 10",
+                    "type": "integer",
+                    "example": 10
+                },
+                "book_id": {
+                    "description": "@Description Identifier of the book\n@Example 1",
+                    "type": "integer",
+                    "example": 1
+                },
+                "needs_reorder": {
+                    "description": "@Description Whether available stock is at or below the reorder threshold\n@Example false",
+                    "type": "boolean",
+                    "example": false
+                },
+                "on_hand": {
+                    "description": "@Description Copies physically in stock\n@Example 12",
+                    "type": "integer",
+                    "example": 12
+                },
+                "reorder_threshold": {
+                    "description": "@Description Available stock at or below which the book needs reordering\n@Example 5",
+                    "type": "integer",
+                    "example": 5
+                },
+                "reserved": {
+                    "description": "@Description Copies set aside for pending orders\n@Example 2",
+                    "type": "integer",
+                    "example": 2
+                },
+                "updated_at": {
+                    "description": "@Description When the level last changed\n@Example \"2023-01-01T00:00:00Z\"",
+                    "type": "string",
+                    "example": "2023-01-01T00:00:00Z"
+                }
+            }
+        },
+        "models.StockMovement": {
+            "description": "Entry in the append-only stock ledger of a book",
+            "type": "object",
+            "properties": {
+                "book_id": {
+                    "description": "@Description Identifier of the book\n@Example 1",
+                    "type": "integer",
+                    "example": 1
+                },
+                "created_at": {
+                    "description": "@Description When the movement was recorded\n@Example \"2023-01-01T00:00:00Z\"",
+                    "type": "string",
+                    "example": "2023-01-01T00:00:00Z"
+                },
+                "id": {
+                    "description": "@Description Unique identifier for the movement\n@Example 1",
+                    "type": "integer",
+                    "example": 1
+                },
+                "on_hand_after": {
+                    "description": "@Description On-hand stock after the movement\n@Example 11",
+                    "type": "integer",
+                    "example": 11
+                },
+                "quantity": {
+                    "description": "@Description Number of copies; signed only for adjust\n@Example 1",
+                    "type": "integer",
+                    "example": 1
+                },
+                "reason": {
+                    "description": "@Description Why the movement happened\n@Example \"order #1042\"",
+                    "type": "string",
+                    "example": "order #1042"
+                },
+                "reserved_after": {
+                    "description": "@Description Reserved stock after the movement\n@Example 2",
+                    "type": "integer",
+                    "example": 2
+                },
+                "type": {
+                    "description": "@Description receive, sell, return, adjust, reserve or release\n@Example \"sell\"",
+                    "type": "string",
+                    "example": "sell"
+                }
+            }
+        },
+        "models.StockMovementListResponse": {
+            "description": "Paginated stock movement list model for API documentation",
+            "type": "object",
+            "properties": {
+                "data": {
+                    "description": "@Description Movements in the page, newest first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.StockMovement"
+                    }
+                },
+                "pagination": {
+                    "description": "@Description Pagination metadata",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Pagination"
+                        }
+                    ]
+                }
+            }
+        },
+        "models.StockMovementRequest": {
+            "description": "Stock movement request model for API documentation",
+            "type": "object",
+            "required": [
+                "quantity",
+                "type"
+            ],
+            "properties": {
+                "quantity": {
+                    "description": "@Description Number of copies; must be positive except for adjust, where it is a signed correction\n@Example 1",
+                    "type": "integer",
+                    "example": 1
+                },
+                "reason": {
+                    "description": "@Description Why the movement happened\n@Example \"order #1042\"",
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "order #1042"
+                },
+                "type": {
+                    "description": "@Description receive, sell, return, adjust, reserve or release\n@Example \"sell\"",
+                    "type": "string",
+                    "enum": [
+                        "receive",
+                        "sell",
+                        "return",
+                        "adjust",
+                        "reserve",
+                        "release"
+                    ],
+                    "example": "sell"
+                }
+            }
+        },
+        "models.StockMovementResponse": {
+            "description": "Recorded stock movement and the resulting stock level",
+            "type": "object",
+            "properties": {
+                "level": {
+                    "description": "@Description Stock level after the movement",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.StockLevel"
+                        }
+                    ]
+                },
+                "movement": {
+                    "description": "@Description The recorded movement",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.StockMovement"
+                        }
+                    ]
+                }
+            }
+        },
+        "models.StockSettingsRequest": {
+            "description": "Stock settings request model for API documentation",
+            "type": "object",
+            "properties": {
+                "reorder_threshold": {
+                    "description": "@Description Available stock at or below which the book needs reordering\n@Example 5",
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 5
+                }
+            }
+        },
+        "models.YearFacet": {
+            "description": "Number of matching books published in a year",
+            "type": "object",
+            "properties": {
+                "count": {
+                    "description": "@Description Number of matching books\n@Example 3",
+                    "type": "integer",
+                    "example": 3
+                },
+                "year": {
+                    "description": "@Description Publication year, for the year filter\n@Example 1925",
+                    "type": "integer",
+                    "example": 1925
+                }
+            }
+        },
+        "patch.Operation": {
+            "description": "JSON Patch (RFC 6902) operation",
+            "type": "object",
+            "properties": {
+                "from": {
+                    "description": "@Description JSON Pointer to the source location of move and copy",
+                    "type": "string"
+                },
+                "op": {
+                    "description": "@Description add, remove, replace, move, copy or test\n@Example \"replace\"",
+                    "type": "string",
+                    "example": "replace"
+                },
+                "path": {
+                    "description": "@Description JSON Pointer (RFC 6901) to the target location\n@Example \"/title\"",
+                    "type": "string",
+                    "example": "/title"
+                },
+                "value": {
+                    "description": "@Description Value to add, replace with or test against",
+                    "type": "object"
+                }
+            }
+        },
+        "validation.FieldError": {
+            "description": "Validation failure of a single request field",
+            "type": "object",
+            "properties": {
+                "code": {
+                    "description": "@Description Machine readable rule that failed\n@Example \"required\"",
+                    "type": "string",
+                    "example": "required"
+                },
+                "field": {
+                    "description": "@Description JSON name of the offending field\n@Example \"title\"",
+                    "type": "string",
+                    "example": "title"
+                },
+                "message": {
+                    "description": "@Description Human readable explanation\n@Example \"title is required\"",
+                    "type": "string",
+                    "example": "title is required"
                 }
             }
         }
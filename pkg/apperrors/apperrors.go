@@ -0,0 +1,255 @@
+// Package apperrors defines the typed errors returned by the HTTP API and
+// renders them as RFC 7807 application/problem+json documents. Every problem
+// carries a stable Code that clients can branch on; the human readable
+// Title and Detail may change between releases.
+package apperrors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"go-bookstore-mysql-crud/pkg/logging"
+	"go-bookstore-mysql-crud/pkg/models"
+	"go-bookstore-mysql-crud/pkg/onix"
+	"go-bookstore-mysql-crud/pkg/patch"
+	"go-bookstore-mysql-crud/pkg/utils"
+	"go-bookstore-mysql-crud/pkg/validation"
+)
+
+// ContentType is the media type of problem responses
+const ContentType = "application/problem+json"
+
+// Code is a stable, machine readable error identifier
+type Code string
+
+const (
+	CodeBadRequest           Code = "bad_request"
+	CodeInvalidQuery         Code = "invalid_query"
+	CodeValidation           Code = "validation_failed"
+	CodePayloadTooLarge      Code = "payload_too_large"
+	CodeUnsupportedMediaType Code = "unsupported_media_type"
+	CodeUnauthorized         Code = "unauthorized"
+	CodeForbidden            Code = "forbidden"
+	CodeNotFound             Code = "not_found"
+	CodeRouteNotFound        Code = "route_not_found"
+	CodeMethodNotAllowed     Code = "method_not_allowed"
+	CodeConflict             Code = "conflict"
+	CodeInsufficientStock    Code = "insufficient_stock"
+	CodeBatchAborted         Code = "batch_aborted"
+	CodePreconditionFailed   Code = "precondition_failed"
+	CodePreconditionRequired Code = "precondition_required"
+	CodeInternal             Code = "internal_error"
+)
+
+// codeStatus maps each code to its HTTP status and default title
+var codeStatus = map[Code]struct {
+	status int
+	title  string
+}{
+	CodeBadRequest:           {http.StatusBadRequest, "Bad request"},
+	CodeInvalidQuery:         {http.StatusBadRequest, "Invalid query parameters"},
+	CodeValidation:           {http.StatusUnprocessableEntity, "Validation failed"},
+	CodePayloadTooLarge:      {http.StatusRequestEntityTooLarge, "Request body too large"},
+	CodeUnsupportedMediaType: {http.StatusUnsupportedMediaType, "Unsupported media type"},
+	CodeUnauthorized:         {http.StatusUnauthorized, "Authentication required"},
+	CodeForbidden:            {http.StatusForbidden, "Forbidden"},
+	CodeNotFound:             {http.StatusNotFound, "Resource not found"},
+	CodeRouteNotFound:        {http.StatusNotFound, "Route not found"},
+	CodeMethodNotAllowed:     {http.StatusMethodNotAllowed, "Method not allowed"},
+	CodeConflict:             {http.StatusConflict, "Conflict"},
+	CodeInsufficientStock:    {http.StatusConflict, "Insufficient stock"},
+	CodeBatchAborted:         {http.StatusFailedDependency, "Batch aborted"},
+	CodePreconditionFailed:   {http.StatusPreconditionFailed, "Precondition failed"},
+	CodePreconditionRequired: {http.StatusPreconditionRequired, "Precondition required"},
+	CodeInternal:             {http.StatusInternalServerError, "Internal server error"},
+}
+
+// Error is an API error of a known kind
+type Error struct {
+	Code   Code
+	Detail string
+	Fields validation.Errors
+	// Err is the underlying cause; it is logged but never sent to clients
+	// for internal errors
+	Err error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %s: %v", e.Code, e.Detail, e.Err)
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Detail)
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// Status returns the HTTP status code for the error
+func (e *Error) Status() int {
+	return codeStatus[e.Code].status
+}
+
+// New returns an Error with the given code and client facing detail
+func New(code Code, detail string) *Error {
+	return &Error{Code: code, Detail: detail}
+}
+
+// NotFound reports a missing resource
+func NotFound(detail string) *Error { return New(CodeNotFound, detail) }
+
+// Conflict reports a request that clashes with the current state of a resource
+func Conflict(detail string) *Error { return New(CodeConflict, detail) }
+
+// Unauthorized reports a request without valid credentials
+func Unauthorized(detail string) *Error { return New(CodeUnauthorized, detail) }
+
+// Forbidden reports credentials that lack the required scope
+func Forbidden(detail string) *Error { return New(CodeForbidden, detail) }
+
+// PreconditionFailed reports a conditional request whose condition does not hold
+func PreconditionFailed(detail string) *Error { return New(CodePreconditionFailed, detail) }
+
+// PreconditionRequired reports a change request sent without If-Match
+func PreconditionRequired(detail string) *Error { return New(CodePreconditionRequired, detail) }
+
+// BadRequest reports a request that could not be understood
+func BadRequest(detail string) *Error { return New(CodeBadRequest, detail) }
+
+// Validation reports field level validation failures
+func Validation(fields validation.Errors) *Error {
+	return &Error{Code: CodeValidation, Detail: "one or more fields are invalid", Fields: fields}
+}
+
+// Internal wraps an unexpected error; its message is hidden from clients
+func Internal(err error) *Error {
+	return &Error{Code: CodeInternal, Detail: "an unexpected error occurred", Err: err}
+}
+
+// FromError classifies err, mapping repository, decoding and validation
+// errors onto their API error kind. Unrecognised errors become internal.
+func FromError(err error) *Error {
+	var (
+		appErr    *Error
+		fieldErrs validation.Errors
+	)
+	switch {
+	case errors.As(err, &appErr):
+		return appErr
+	case errors.As(err, &fieldErrs):
+		return Validation(fieldErrs)
+	case errors.Is(err, models.ErrBookNotFound), errors.Is(err, models.ErrAuthorNotFound), errors.Is(err, models.ErrAPIKeyNotFound):
+		return &Error{Code: CodeNotFound, Detail: err.Error(), Err: err}
+	case errors.Is(err, models.ErrBookExists), errors.Is(err, models.ErrBookNotDeleted),
+		errors.Is(err, models.ErrAuthorExists), errors.Is(err, models.ErrAuthorInUse):
+		return &Error{Code: CodeConflict, Detail: err.Error(), Err: err}
+	case errors.Is(err, models.ErrInsufficientStock):
+		return &Error{Code: CodeInsufficientStock, Detail: err.Error(), Err: err}
+	case errors.Is(err, models.ErrBatchAborted):
+		return &Error{Code: CodeBatchAborted, Detail: err.Error(), Err: err}
+	case errors.Is(err, models.ErrBookVersionMismatch):
+		return &Error{Code: CodePreconditionFailed, Detail: err.Error(), Err: err}
+	case errors.Is(err, models.ErrInvalidMovement):
+		return &Error{Code: CodeBadRequest, Detail: err.Error(), Err: err}
+	case errors.Is(err, models.ErrInvalidQuery):
+		return &Error{Code: CodeInvalidQuery, Detail: err.Error(), Err: err}
+	case errors.Is(err, onix.ErrInvalidMessage):
+		return &Error{Code: CodeBadRequest, Detail: err.Error(), Err: err}
+	case errors.Is(err, patch.ErrInvalidPatch):
+		return &Error{Code: CodeBadRequest, Detail: err.Error(), Err: err}
+	case errors.Is(err, patch.ErrConflict):
+		return &Error{Code: CodeConflict, Detail: err.Error(), Err: err}
+	case errors.Is(err, utils.ErrBodyTooLarge):
+		return &Error{Code: CodePayloadTooLarge, Detail: err.Error(), Err: err}
+	case errors.Is(err, utils.ErrInvalidBody):
+		return &Error{Code: CodeBadRequest, Detail: err.Error(), Err: err}
+	}
+	return Internal(err)
+}
+
+// Problem is an RFC 7807 problem details document
+// @Description RFC 7807 problem details; branch on code, not on title or detail
+type Problem struct {
+	// @Description URI reference identifying the problem type
+	// @Example "urn:bookstore:problem:not_found"
+	Type string `json:"type" example:"urn:bookstore:problem:not_found"`
+
+	// @Description Short summary of the problem type
+	// @Example "Resource not found"
+	Title string `json:"title" example:"Resource not found"`
+
+	// @Description HTTP status code
+	// @Example 404
+	Status int `json:"status" example:"404"`
+
+	// @Description Explanation specific to this occurrence
+	// @Example "book not found"
+	Detail string `json:"detail,omitempty" example:"book not found"`
+
+	// @Description Request path that produced the problem
+	// @Example "/books/42"
+	Instance string `json:"instance,omitempty" example:"/books/42"`
+
+	// @Description Stable machine readable error code
+	// @Example "not_found"
+	Code Code `json:"code" example:"not_found"`
+
+	// @Description Field failures for validation_failed problems
+	Errors validation.Errors `json:"errors,omitempty"`
+
+	// @Description ID of the request, also sent in the X-Request-ID header; quote it when reporting a problem
+	// @Example "4bf92f3577b34da6a3ce929d0e0e4736"
+	RequestID string `json:"request_id,omitempty" example:"4bf92f3577b34da6a3ce929d0e0e4736"`
+}
+
+// Problem renders the error as a problem document for the request r
+func (e *Error) Problem(r *http.Request) Problem {
+	p := Problem{
+		Type:   "urn:bookstore:problem:" + string(e.Code),
+		Title:  codeStatus[e.Code].title,
+		Status: e.Status(),
+		Detail: e.Detail,
+		Code:   e.Code,
+		Errors: e.Fields,
+	}
+	if r != nil {
+		p.Instance = r.URL.Path
+		p.RequestID = logging.RequestIDFrom(r.Context())
+	}
+	return p
+}
+
+// Write classifies err and writes it as an application/problem+json response
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	problem := ProblemFor(r, err)
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(problem.Status)
+	json.NewEncoder(w).Encode(problem)
+}
+
+// ProblemFor classifies err like Write does and returns its problem
+// document, for errors reported inside a larger response such as the
+// per-item results of a batch
+func ProblemFor(r *http.Request, err error) Problem {
+	appErr := FromError(err)
+	if appErr.Code == CodeInternal {
+		slog.ErrorContext(r.Context(), "request failed", "method", r.Method, "path", r.URL.Path, "error", appErr.Err)
+	}
+	return appErr.Problem(r)
+}
+
+// NotFoundHandler answers requests that match no route
+func NotFoundHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Write(w, r, New(CodeRouteNotFound, "no route matches "+r.URL.Path))
+	})
+}
+
+// MethodNotAllowedHandler answers requests whose route exists for other methods
+func MethodNotAllowedHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Write(w, r, New(CodeMethodNotAllowed, r.Method+" is not supported on "+r.URL.Path))
+	})
+}
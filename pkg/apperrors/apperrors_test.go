@@ -0,0 +1,117 @@
+package apperrors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-bookstore-mysql-crud/pkg/models"
+	"go-bookstore-mysql-crud/pkg/utils"
+	"go-bookstore-mysql-crud/pkg/validation"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFromError(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		code   Code
+		status int
+	}{
+		{"API error", Conflict("taken"), CodeConflict, http.StatusConflict},
+		{"wrapped API error", fmt.Errorf("saving: %w", NotFound("no such book")), CodeNotFound, http.StatusNotFound},
+		{"field errors", validation.Errors{{Field: "title", Code: "required"}}, CodeValidation, http.StatusUnprocessableEntity},
+		{"missing book", fmt.Errorf("%w: 42", models.ErrBookNotFound), CodeNotFound, http.StatusNotFound},
+		{"existing book", models.ErrBookExists, CodeConflict, http.StatusConflict},
+		{"stale version", models.ErrBookVersionMismatch, CodePreconditionFailed, http.StatusPreconditionFailed},
+		{"book not in the trash", models.ErrBookNotDeleted, CodeConflict, http.StatusConflict},
+		{"aborted batch", models.ErrBatchAborted, CodeBatchAborted, http.StatusFailedDependency},
+		{"missing author", models.ErrAuthorNotFound, CodeNotFound, http.StatusNotFound},
+		{"existing author", models.ErrAuthorExists, CodeConflict, http.StatusConflict},
+		{"author in use", models.ErrAuthorInUse, CodeConflict, http.StatusConflict},
+		{"insufficient stock", fmt.Errorf("%w: 2 on hand", models.ErrInsufficientStock), CodeInsufficientStock, http.StatusConflict},
+		{"invalid movement", models.ErrInvalidMovement, CodeBadRequest, http.StatusBadRequest},
+		{"invalid query", fmt.Errorf("%w: bad cursor", models.ErrInvalidQuery), CodeInvalidQuery, http.StatusBadRequest},
+		{"body too large", utils.ErrBodyTooLarge, CodePayloadTooLarge, http.StatusRequestEntityTooLarge},
+		{"invalid body", fmt.Errorf("%w: unknown field", utils.ErrInvalidBody), CodeBadRequest, http.StatusBadRequest},
+		{"anything else", errors.New("dial tcp: connection refused"), CodeInternal, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		got := FromError(tt.err)
+		if got.Code != tt.code || got.Status() != tt.status {
+			t.Errorf("%s: FromError = %s with status %d, want %s with %d", tt.name, got.Code, got.Status(), tt.code, tt.status)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		want   Problem
+	}{
+		{
+			name:   "not found",
+			err:    models.ErrBookNotFound,
+			status: http.StatusNotFound,
+			want: Problem{Type: "urn:bookstore:problem:not_found", Title: "Resource not found", Status: http.StatusNotFound,
+				Detail: "book not found", Instance: "/books/42", Code: CodeNotFound},
+		},
+		{
+			name:   "validation",
+			err:    validation.Errors{{Field: "title", Code: "required", Message: "title is required"}},
+			status: http.StatusUnprocessableEntity,
+			want: Problem{Type: "urn:bookstore:problem:validation_failed", Title: "Validation failed", Status: http.StatusUnprocessableEntity,
+				Detail: "one or more fields are invalid", Instance: "/books/42", Code: CodeValidation,
+				Errors: validation.Errors{{Field: "title", Code: "required", Message: "title is required"}}},
+		},
+		{
+			// The cause of an internal error is never sent to the client
+			name:   "internal",
+			err:    errors.New("Error 1045: Access denied for user 'root'"),
+			status: http.StatusInternalServerError,
+			want: Problem{Type: "urn:bookstore:problem:internal_error", Title: "Internal server error", Status: http.StatusInternalServerError,
+				Detail: "an unexpected error occurred", Instance: "/books/42", Code: CodeInternal},
+		},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		Write(w, httptest.NewRequest("GET", "/books/42", nil), tt.err)
+		if w.Code != tt.status || w.Header().Get("Content-Type") != ContentType {
+			t.Errorf("%s: status %d with Content-Type %q", tt.name, w.Code, w.Header().Get("Content-Type"))
+		}
+		if strings.Contains(w.Body.String(), "Access denied") {
+			t.Errorf("%s: response leaks the cause: %s", tt.name, w.Body)
+		}
+		var got Problem
+		if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: problem %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRouteHandlers(t *testing.T) {
+	tests := []struct {
+		handler http.Handler
+		status  int
+		code    Code
+	}{
+		{NotFoundHandler(), http.StatusNotFound, CodeRouteNotFound},
+		{MethodNotAllowedHandler(), http.StatusMethodNotAllowed, CodeMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		tt.handler.ServeHTTP(w, httptest.NewRequest("PATCH", "/nowhere", nil))
+		var got Problem
+		if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil || w.Code != tt.status || got.Code != tt.code {
+			t.Errorf("%s: status %d, problem %s", tt.code, w.Code, w.Body)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package auth
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestGenerateAPIKey(t *testing.T) {
+	key, prefix, hash, err := GenerateAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !regexp.MustCompile(`^bks_[0-9a-f]{8}_[A-Za-z0-9_-]{43}$`).MatchString(key) {
+		t.Errorf("key %q is not of the form bks_<8 hex>_<43 base64url>", key)
+	}
+	if got, ok := parseAPIKey(key); !ok || got != prefix {
+		t.Errorf("parseAPIKey = %q, %v; want %q", got, ok, prefix)
+	}
+	if hash != HashAPIKey(key) || !matchAPIKey(key, hash) {
+		t.Errorf("hash %q does not match the key", hash)
+	}
+	if other, _, _, _ := GenerateAPIKey(); other == key || matchAPIKey(other, hash) {
+		t.Errorf("a second key %q matches the first", other)
+	}
+}
+
+func TestParseAPIKey(t *testing.T) {
+	tests := []struct {
+		key    string
+		prefix string
+		ok     bool
+	}{
+		{"bks_1a2b3c4d_secret", "bks_1a2b3c4d", true},
+		{"bks_1a2b3c4d_", "", false},
+		{"bks_1a2b3c4d", "", false},
+		{"bks_1a2b3c_secret", "", false},
+		{"key_1a2b3c4d_secret", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		if prefix, ok := parseAPIKey(tt.key); prefix != tt.prefix || ok != tt.ok {
+			t.Errorf("parseAPIKey(%q) = %q, %v; want %q, %v", tt.key, prefix, ok, tt.prefix, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+// Package auth authenticates API requests and enforces per-route scopes.
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"go-bookstore-mysql-crud/pkg/apperrors"
+	"go-bookstore-mysql-crud/pkg/models"
+)
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	// Kind is "api_key" or "user"
+	Kind string
+	// ID identifies the principal within its kind
+	ID uint
+	// Name is a human readable label for logs
+	Name   string
+	Scopes models.Scopes
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying p
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFrom returns the principal authenticated for the request, if any
+func PrincipalFrom(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok
+}
+
+// Authenticator checks the credentials of incoming requests
+type Authenticator struct {
+	Keys models.APIKeyRepository
+	// Tokens verifies user access tokens; when nil only API keys are accepted
+	Tokens *Tokens
+	// Now returns the current time; it defaults to time.Now
+	Now func() time.Time
+}
+
+// NewAuthenticator returns an Authenticator that looks keys up in keys and
+// verifies user access tokens with tokens
+func NewAuthenticator(keys models.APIKeyRepository, tokens *Tokens) *Authenticator {
+	return &Authenticator{Keys: keys, Tokens: tokens, Now: time.Now}
+}
+
+// Require returns middleware that rejects requests whose credentials do not
+// grant scope, with 401 when credentials are missing or invalid and 403 when
+// they lack the scope
+func (a *Authenticator) Require(scope string) func(http.HandlerFunc) http.Handler {
+	return func(next http.HandlerFunc) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p, err := a.Authenticate(r)
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="bookstore"`)
+				apperrors.Write(w, r, err)
+				return
+			}
+			if !p.Scopes.Allows(scope) {
+				apperrors.Write(w, r, apperrors.Forbidden("this operation requires the "+scope+" scope"))
+				return
+			}
+			next(w, r.WithContext(WithPrincipal(r.Context(), p)))
+		})
+	}
+}
+
+// Authenticate returns the principal for the request's Authorization header.
+// The header holds an API key, optionally after "Bearer ", or a user access
+// token after "Bearer ".
+func (a *Authenticator) Authenticate(r *http.Request) (*Principal, error) {
+	credential := credentialFrom(r)
+	if credential == "" {
+		return nil, apperrors.Unauthorized("missing Authorization header")
+	}
+	if strings.HasPrefix(credential, APIKeyPrefix) || a.Tokens == nil {
+		return a.authenticateAPIKey(r.Context(), credential)
+	}
+	return a.authenticateToken(credential)
+}
+
+func (a *Authenticator) authenticateToken(credential string) (*Principal, error) {
+	claims, err := a.Tokens.Verify(credential, TokenAccess)
+	if err != nil {
+		return nil, apperrors.Unauthorized(err.Error())
+	}
+	id, err := claims.UserID()
+	if err != nil {
+		return nil, apperrors.Unauthorized(err.Error())
+	}
+	return &Principal{Kind: "user", ID: id, Name: "user " + claims.Subject, Scopes: models.RoleScopes(claims.Role)}, nil
+}
+
+func (a *Authenticator) authenticateAPIKey(ctx context.Context, credential string) (*Principal, error) {
+	invalid := apperrors.Unauthorized("invalid or revoked API key")
+	prefix, ok := parseAPIKey(credential)
+	if !ok {
+		return nil, invalid
+	}
+	key, err := a.Keys.GetByPrefix(ctx, prefix)
+	if errors.Is(err, models.ErrAPIKeyNotFound) {
+		return nil, invalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	if key.Revoked() || !matchAPIKey(credential, key.Hash) {
+		return nil, invalid
+	}
+	if err := a.Keys.Touch(ctx, key.ID, a.Now()); err != nil {
+		return nil, err
+	}
+	return &Principal{Kind: "api_key", ID: key.ID, Name: key.Name, Scopes: key.Scopes}, nil
+}
+
+// credentialFrom extracts the credential from the Authorization header
+func credentialFrom(r *http.Request) string {
+	header := strings.TrimSpace(r.Header.Get("Authorization"))
+	if scheme, rest, ok := strings.Cut(header, " "); ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(rest)
+	}
+	return header
+}
@@ -0,0 +1,182 @@
+package auth
+
+import (
+	"context"
+	"go-bookstore-mysql-crud/pkg/apperrors"
+	"go-bookstore-mysql-crud/pkg/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// storeKey stores a key with the given scopes and returns it with its record
+func storeKey(t *testing.T, keys models.APIKeyRepository, scopes ...string) (string, *models.APIKey) {
+	t.Helper()
+	key, prefix, hash, err := GenerateAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	record := &models.APIKey{Name: "test", Prefix: prefix, Hash: hash, Scopes: scopes}
+	if err := keys.Create(context.Background(), record); err != nil {
+		t.Fatal(err)
+	}
+	return key, record
+}
+
+func request(authorization string) *http.Request {
+	r := httptest.NewRequest("GET", "/books", nil)
+	if authorization != "" {
+		r.Header.Set("Authorization", authorization)
+	}
+	return r
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	keys := models.NewMemoryAPIKeyRepository()
+	a := NewAuthenticator(keys, nil)
+	key, record := storeKey(t, keys, models.ScopeRead)
+	revoked, revokedRecord := storeKey(t, keys, models.ScopeAdmin)
+	if _, err := keys.Revoke(context.Background(), revokedRecord.ID); err != nil {
+		t.Fatal(err)
+	}
+	// The stored prefix with somebody else's secret
+	prefix, _ := parseAPIKey(key)
+	forged := prefix + "_" + strings.Repeat("A", 43)
+
+	tests := []struct {
+		name          string
+		authorization string
+		ok            bool
+	}{
+		{"bearer key", "Bearer " + key, true},
+		{"bare key", key, true},
+		{"lower case scheme", "bearer  " + key, true},
+		{"missing header", "", false},
+		{"malformed key", "Bearer not-a-key", false},
+		{"unknown prefix", "Bearer bks_00000000_" + strings.Repeat("A", 43), false},
+		{"wrong secret", "Bearer " + forged, false},
+		{"revoked key", "Bearer " + revoked, false},
+	}
+	for _, tt := range tests {
+		p, err := a.Authenticate(request(tt.authorization))
+		if !tt.ok {
+			if appErr := apperrors.FromError(err); appErr.Code != apperrors.CodeUnauthorized {
+				t.Errorf("%s: Authenticate = %v, want unauthorized", tt.name, err)
+			}
+			continue
+		}
+		if err != nil || p.Kind != "api_key" || p.ID != record.ID || !p.Scopes.Allows(models.ScopeRead) {
+			t.Errorf("%s: Authenticate = %+v, %v", tt.name, p, err)
+		}
+	}
+}
+
+func TestAuthenticateRecordsLastUse(t *testing.T) {
+	keys := models.NewMemoryAPIKeyRepository()
+	a := NewAuthenticator(keys, nil)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	a.Now = func() time.Time { return now }
+	key, record := storeKey(t, keys, models.ScopeRead)
+	lastUsed := func() *time.Time {
+		t.Helper()
+		stored, err := keys.GetByPrefix(context.Background(), record.Prefix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return stored.LastUsedAt
+	}
+
+	if lastUsed() != nil {
+		t.Fatalf("a new key is already used")
+	}
+	// Failed attempts are not recorded
+	if _, err := a.Authenticate(request("Bearer " + record.Prefix + "_wrong")); err == nil {
+		t.Fatal("Authenticate accepted a wrong secret")
+	}
+	if lastUsed() != nil {
+		t.Errorf("a failed attempt recorded a use")
+	}
+
+	steps := []struct {
+		after time.Duration
+		want  time.Time
+	}{
+		{0, now},
+		// Uses within a minute of the recorded one are not written again
+		{30 * time.Second, now},
+		{2 * time.Minute, now.Add(2 * time.Minute)},
+	}
+	start := now
+	for _, step := range steps {
+		now = start.Add(step.after)
+		if _, err := a.Authenticate(request("Bearer " + key)); err != nil {
+			t.Fatal(err)
+		}
+		if got := lastUsed(); got == nil || !got.Equal(step.want) {
+			t.Errorf("after %v: last used at %v, want %v", step.after, got, step.want)
+		}
+	}
+}
+
+func TestRequire(t *testing.T) {
+	keys := models.NewMemoryAPIKeyRepository()
+	a := NewAuthenticator(keys, nil)
+	reader, _ := storeKey(t, keys, models.ScopeRead)
+	admin, adminRecord := storeKey(t, keys, models.ScopeAdmin)
+
+	var principal *Principal
+	handler := a.Require(models.ScopeWrite)(func(w http.ResponseWriter, r *http.Request) {
+		principal, _ = PrincipalFrom(r.Context())
+	})
+	tests := []struct {
+		name          string
+		authorization string
+		status        int
+	}{
+		{"anonymous", "", http.StatusUnauthorized},
+		{"missing scope", "Bearer " + reader, http.StatusForbidden},
+		// Admin grants everything write does
+		{"higher scope", "Bearer " + admin, http.StatusOK},
+	}
+	for _, tt := range tests {
+		principal = nil
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, request(tt.authorization))
+		if w.Code != tt.status {
+			t.Errorf("%s: status %d, want %d: %s", tt.name, w.Code, tt.status, w.Body)
+		}
+		if challenge := w.Header().Get("WWW-Authenticate"); (tt.status == http.StatusUnauthorized) != (challenge != "") {
+			t.Errorf("%s: WWW-Authenticate %q", tt.name, challenge)
+		}
+		if (tt.status == http.StatusOK) != (principal != nil) {
+			t.Errorf("%s: handler saw principal %+v", tt.name, principal)
+		}
+	}
+	if principal == nil || principal.ID != adminRecord.ID {
+		t.Errorf("handler saw principal %+v, want key %d", principal, adminRecord.ID)
+	}
+}
+
+func TestAuthenticateUserToken(t *testing.T) {
+	tokens := newTokens(t, time.Now(), currentKey)
+	a := NewAuthenticator(models.NewMemoryAPIKeyRepository(), tokens)
+	pair, err := tokens.Issue(&models.User{ID: 7, Role: models.UserRoleEditor})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := a.Authenticate(request("Bearer " + pair.AccessToken))
+	if err != nil || p.Kind != "user" || p.ID != 7 || !p.Scopes.Allows(models.ScopeWrite) || p.Scopes.Allows(models.ScopeAdmin) {
+		t.Errorf("Authenticate with an access token = %+v, %v", p, err)
+	}
+	if _, err := a.Authenticate(request("Bearer " + pair.RefreshToken)); apperrors.FromError(err).Code != apperrors.CodeUnauthorized {
+		t.Errorf("Authenticate with a refresh token = %v, want unauthorized", err)
+	}
+	// Without Tokens every credential is taken for an API key
+	a.Tokens = nil
+	if _, err := a.Authenticate(request("Bearer " + pair.AccessToken)); apperrors.FromError(err).Code != apperrors.CodeUnauthorized {
+		t.Errorf("Authenticate with tokens disabled = %v, want unauthorized", err)
+	}
+}
@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"crypto/pbkdf2"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestHashPassword(t *testing.T) {
+	hash, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(hash, "pbkdf2-sha256$600000$") {
+		t.Errorf("hash %q does not record the scheme and iterations", hash)
+	}
+	if ok, err := CheckPassword(hash, "correct horse"); !ok || err != nil {
+		t.Errorf("CheckPassword with the password = %v, %v", ok, err)
+	}
+	if ok, err := CheckPassword(hash, "correct horse "); ok || err != nil {
+		t.Errorf("CheckPassword with another password = %v, %v", ok, err)
+	}
+	// Hashes are salted
+	if again, _ := HashPassword("correct horse"); again == hash {
+		t.Error("two hashes of the same password are equal")
+	}
+}
+
+func TestCheckPasswordUsesStoredParameters(t *testing.T) {
+	salt := []byte("0123456789abcdef")
+	key, err := pbkdf2.Key(sha256.New, "secret", salt, 1000, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := "pbkdf2-sha256$1000$" + base64.RawStdEncoding.EncodeToString(salt) + "$" + base64.RawStdEncoding.EncodeToString(key)
+	if ok, err := CheckPassword(hash, "secret"); !ok || err != nil {
+		t.Errorf("CheckPassword of an older hash = %v, %v", ok, err)
+	}
+}
+
+func TestCheckPasswordRejectsMalformedHashes(t *testing.T) {
+	salt := base64.RawStdEncoding.EncodeToString([]byte("0123456789abcdef"))
+	tests := []struct {
+		name string
+		hash string
+	}{
+		{"empty", ""},
+		{"plain text", "secret"},
+		{"other scheme", "bcrypt$1000$" + salt + "$" + salt},
+		{"missing key", "pbkdf2-sha256$1000$" + salt},
+		{"extra field", "pbkdf2-sha256$1000$" + salt + "$" + salt + "$x"},
+		{"iterations not a number", "pbkdf2-sha256$many$" + salt + "$" + salt},
+		{"zero iterations", "pbkdf2-sha256$0$" + salt + "$" + salt},
+		{"negative iterations", "pbkdf2-sha256$-5$" + salt + "$" + salt},
+		{"salt not base64", "pbkdf2-sha256$1000$!!!$" + salt},
+		{"key not base64", "pbkdf2-sha256$1000$" + salt + "$!!!"},
+		{"empty key", "pbkdf2-sha256$1000$" + salt + "$"},
+	}
+	for _, tt := range tests {
+		if ok, err := CheckPassword(tt.hash, "secret"); ok || !errors.Is(err, ErrMalformedHash) {
+			t.Errorf("%s: CheckPassword = %v, %v; want ErrMalformedHash", tt.name, ok, err)
+		}
+	}
+}
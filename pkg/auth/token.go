@@ -0,0 +1,203 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-bookstore-mysql-crud/pkg/config"
+	"go-bookstore-mysql-crud/pkg/models"
+)
+
+// ErrInvalidToken is returned for tokens that are malformed, expired, of the
+// wrong type or signed with an unknown key
+var ErrInvalidToken = errors.New("invalid or expired token")
+
+// Token types, carried in the typ claim so that a refresh token cannot be
+// used as an access token or the other way round
+const (
+	TokenAccess  = "access"
+	TokenRefresh = "refresh"
+)
+
+// Claims is the payload of the JWTs issued by Tokens
+type Claims struct {
+	Issuer    string `json:"iss"`
+	Subject   string `json:"sub"`
+	Role      string `json:"role"`
+	Type      string `json:"typ"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+	ID        string `json:"jti"`
+}
+
+// UserID returns the ID of the user the token was issued to
+func (c *Claims) UserID() (uint, error) {
+	id, err := strconv.ParseUint(c.Subject, 10, 0)
+	if err != nil || id == 0 {
+		return 0, ErrInvalidToken
+	}
+	return uint(id), nil
+}
+
+// TokenPair is the result of a successful login or refresh
+// @Description Access and refresh tokens issued at login
+type TokenPair struct {
+	// @Description JWT to send as "Authorization: Bearer <token>"
+	AccessToken string `json:"access_token"`
+
+	// @Description JWT to exchange for a new pair at /auth/refresh
+	RefreshToken string `json:"refresh_token"`
+
+	// @Description Always "Bearer"
+	// @Example "Bearer"
+	TokenType string `json:"token_type" example:"Bearer"`
+
+	// @Description Lifetime of the access token in seconds
+	// @Example 900
+	ExpiresIn int64 `json:"expires_in" example:"900"`
+}
+
+type tokenHeader struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+	Kid string `json:"kid"`
+}
+
+// Tokens issues and verifies HS256 JWTs. New tokens are signed with the
+// first configured key; tokens signed with any configured key verify, which
+// allows signing keys to be rotated without logging everyone out.
+type Tokens struct {
+	keys       []config.SigningKey
+	issuer     string
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+	// Now returns the current time; it defaults to time.Now
+	Now func() time.Time
+}
+
+// NewTokens returns Tokens configured from cfg
+func NewTokens(cfg config.AuthConfig) (*Tokens, error) {
+	if len(cfg.SigningKeys) == 0 {
+		return nil, errors.New("auth: no signing keys configured")
+	}
+	return &Tokens{
+		keys:       cfg.SigningKeys,
+		issuer:     cfg.Issuer,
+		accessTTL:  cfg.AccessTokenTTL,
+		refreshTTL: cfg.RefreshTokenTTL,
+		Now:        time.Now,
+	}, nil
+}
+
+// Issue returns a new access and refresh token for user
+func (t *Tokens) Issue(user *models.User) (*TokenPair, error) {
+	access, err := t.sign(user, TokenAccess, t.accessTTL)
+	if err != nil {
+		return nil, err
+	}
+	refresh, err := t.sign(user, TokenRefresh, t.refreshTTL)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenPair{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(t.accessTTL / time.Second),
+	}, nil
+}
+
+// Verify checks the signature, issuer, expiry and type of token and returns its claims
+func (t *Tokens) Verify(token, tokenType string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+	var header tokenHeader
+	if err := decodeSegment(parts[0], &header); err != nil || header.Alg != "HS256" {
+		return nil, ErrInvalidToken
+	}
+	key, ok := t.key(header.Kid)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(signature, sign(key.Secret, parts[0]+"."+parts[1])) {
+		return nil, ErrInvalidToken
+	}
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if claims.Issuer != t.issuer || claims.Type != tokenType || t.Now().Unix() >= claims.ExpiresAt {
+		return nil, ErrInvalidToken
+	}
+	return &claims, nil
+}
+
+func (t *Tokens) sign(user *models.User, tokenType string, ttl time.Duration) (string, error) {
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return "", err
+	}
+	now := t.Now()
+	key := t.keys[0]
+	header, err := encodeSegment(tokenHeader{Alg: "HS256", Typ: "JWT", Kid: key.ID})
+	if err != nil {
+		return "", err
+	}
+	payload, err := encodeSegment(Claims{
+		Issuer:    t.issuer,
+		Subject:   strconv.FormatUint(uint64(user.ID), 10),
+		Role:      user.Role,
+		Type:      tokenType,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(ttl).Unix(),
+		ID:        hex.EncodeToString(jti),
+	})
+	if err != nil {
+		return "", err
+	}
+	signingInput := header + "." + payload
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sign(key.Secret, signingInput)), nil
+}
+
+// key returns the configured signing key with the given ID
+func (t *Tokens) key(id string) (config.SigningKey, bool) {
+	for _, key := range t.keys {
+		if key.ID == id {
+			return key, true
+		}
+	}
+	return config.SigningKey{}, false
+}
+
+func sign(secret []byte, signingInput string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signingInput))
+	return mac.Sum(nil)
+}
+
+func encodeSegment(v interface{}) (string, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	raw, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
@@ -0,0 +1,166 @@
+package auth
+
+import (
+	"encoding/base64"
+	"errors"
+	"go-bookstore-mysql-crud/pkg/config"
+	"go-bookstore-mysql-crud/pkg/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	currentKey  = config.SigningKey{ID: "2024-05", Secret: []byte(strings.Repeat("c", 32))}
+	previousKey = config.SigningKey{ID: "2024-01", Secret: []byte(strings.Repeat("p", 32))}
+)
+
+// newTokens returns Tokens with the given keys and a clock fixed at now
+func newTokens(t *testing.T, now time.Time, keys ...config.SigningKey) *Tokens {
+	t.Helper()
+	tokens, err := NewTokens(config.AuthConfig{
+		SigningKeys:     keys,
+		Issuer:          "go-bookstore",
+		AccessTokenTTL:  15 * time.Minute,
+		RefreshTokenTTL: 24 * time.Hour,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokens.Now = func() time.Time { return now }
+	return tokens
+}
+
+// forge signs header and claims with key, however invalid they are
+func forge(t *testing.T, header tokenHeader, claims Claims, key config.SigningKey) string {
+	t.Helper()
+	h, err := encodeSegment(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := encodeSegment(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h + "." + c + "." + base64.RawURLEncoding.EncodeToString(sign(key.Secret, h+"."+c))
+}
+
+func TestTokensVerify(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	user := &models.User{ID: 7, Role: models.UserRoleEditor}
+	tokens := newTokens(t, now, currentKey, previousKey)
+	pair, err := tokens.Issue(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Issued before the rotation, with the key that is now listed second
+	rotated, err := newTokens(t, now, previousKey).Issue(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	retired, err := newTokens(t, now, config.SigningKey{ID: "2023-09", Secret: []byte(strings.Repeat("r", 32))}).Issue(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	valid := Claims{Issuer: "go-bookstore", Subject: "7", Role: models.UserRoleEditor, Type: TokenAccess, IssuedAt: now.Unix(), ExpiresAt: now.Add(time.Minute).Unix()}
+	header := tokenHeader{Alg: "HS256", Typ: "JWT", Kid: currentKey.ID}
+	with := func(change func(h *tokenHeader, c *Claims)) string {
+		h, c := header, valid
+		change(&h, &c)
+		return forge(t, h, c, currentKey)
+	}
+	parts := strings.Split(pair.AccessToken, ".")
+	admin, err := encodeSegment(Claims{Issuer: "go-bookstore", Subject: "7", Role: models.UserRoleAdmin, Type: TokenAccess, ExpiresAt: now.Add(time.Hour).Unix()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		token     string
+		tokenType string
+		ok        bool
+	}{
+		{"access token", pair.AccessToken, TokenAccess, true},
+		{"refresh token", pair.RefreshToken, TokenRefresh, true},
+		{"rotated key", rotated.AccessToken, TokenAccess, true},
+		{"forged with the current key", with(func(*tokenHeader, *Claims) {}), TokenAccess, true},
+		{"refresh token used as access token", pair.RefreshToken, TokenAccess, false},
+		{"access token used as refresh token", pair.AccessToken, TokenRefresh, false},
+		{"unknown kid", retired.AccessToken, TokenAccess, false},
+		{"missing kid", with(func(h *tokenHeader, _ *Claims) { h.Kid = "" }), TokenAccess, false},
+		{"alg none", with(func(h *tokenHeader, _ *Claims) { h.Alg = "none" }), TokenAccess, false},
+		{"alg HS512", with(func(h *tokenHeader, _ *Claims) { h.Alg = "HS512" }), TokenAccess, false},
+		{"unsigned", parts[0] + "." + parts[1] + ".", TokenAccess, false},
+		{"changed claims", parts[0] + "." + admin + "." + parts[2], TokenAccess, false},
+		{"signature of another key", forge(t, header, valid, previousKey), TokenAccess, false},
+		{"signature not base64", parts[0] + "." + parts[1] + ".!!!", TokenAccess, false},
+		{"other issuer", with(func(_ *tokenHeader, c *Claims) { c.Issuer = "elsewhere" }), TokenAccess, false},
+		{"expired", with(func(_ *tokenHeader, c *Claims) { c.ExpiresAt = now.Add(-time.Second).Unix() }), TokenAccess, false},
+		{"expires now", with(func(_ *tokenHeader, c *Claims) { c.ExpiresAt = now.Unix() }), TokenAccess, false},
+		{"no expiry", with(func(_ *tokenHeader, c *Claims) { c.ExpiresAt = 0 }), TokenAccess, false},
+		{"two segments", parts[0] + "." + parts[1], TokenAccess, false},
+		{"header not JSON", "bm9wZQ." + parts[1] + "." + parts[2], TokenAccess, false},
+		{"empty", "", TokenAccess, false},
+	}
+	for _, tt := range tests {
+		claims, err := tokens.Verify(tt.token, tt.tokenType)
+		if !tt.ok {
+			if !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("%s: Verify = %+v, %v; want ErrInvalidToken", tt.name, claims, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: Verify: %v", tt.name, err)
+			continue
+		}
+		if id, err := claims.UserID(); err != nil || id != 7 || claims.Role != models.UserRoleEditor || claims.Type != tt.tokenType {
+			t.Errorf("%s: claims %+v", tt.name, claims)
+		}
+	}
+}
+
+func TestTokensExpire(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tokens := newTokens(t, now, currentKey)
+	pair, err := tokens.Issue(&models.User{ID: 7, Role: models.UserRoleViewer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pair.ExpiresIn != 900 || pair.TokenType != "Bearer" {
+		t.Errorf("pair expires in %d with type %q", pair.ExpiresIn, pair.TokenType)
+	}
+
+	tokens.Now = func() time.Time { return now.Add(15*time.Minute - time.Second) }
+	if _, err := tokens.Verify(pair.AccessToken, TokenAccess); err != nil {
+		t.Errorf("access token a second before expiry: %v", err)
+	}
+	tokens.Now = func() time.Time { return now.Add(15 * time.Minute) }
+	if _, err := tokens.Verify(pair.AccessToken, TokenAccess); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expired access token: %v, want ErrInvalidToken", err)
+	}
+	// The refresh token outlives the access token
+	if _, err := tokens.Verify(pair.RefreshToken, TokenRefresh); err != nil {
+		t.Errorf("refresh token after the access token expired: %v", err)
+	}
+	tokens.Now = func() time.Time { return now.Add(24 * time.Hour) }
+	if _, err := tokens.Verify(pair.RefreshToken, TokenRefresh); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expired refresh token: %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestClaimsUserID(t *testing.T) {
+	for _, subject := range []string{"", "0", "-1", "abc", "1.5"} {
+		if _, err := (&Claims{Subject: subject}).UserID(); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("UserID of subject %q = %v, want ErrInvalidToken", subject, err)
+		}
+	}
+}
+
+func TestNewTokensRequiresAKey(t *testing.T) {
+	if _, err := NewTokens(config.AuthConfig{}); err == nil {
+		t.Error("NewTokens without signing keys succeeded")
+	}
+}
@@ -1,7 +1,13 @@
 package config
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+
+	"go-bookstore-mysql-crud/pkg/logging"
 
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
@@ -9,20 +15,68 @@ import (
 
 var DB *gorm.DB
 
-func ConnectDatabase() {
+// Backoff between connection attempts: it starts at connectBackoffMin and
+// doubles up to connectBackoffMax, with up to 20% jitter so that replicas
+// restarted together do not retry in lockstep
+const (
+	connectBackoffMin = 500 * time.Millisecond
+	connectBackoffMax = 15 * time.Second
+)
+
+// ConnectDatabase opens the database, retrying with exponential backoff until
+// it answers, ctx is done or DB_CONNECT_TIMEOUT has elapsed
+func ConnectDatabase(ctx context.Context) error {
 	config := LoadConfig()
+	ctx, cancel := context.WithTimeout(ctx, config.Database.ConnectTimeout)
+	defer cancel()
 
-	var err error
-	DB, err = gorm.Open(mysql.Open(config.GetDSN()), &gorm.Config{})
-	if err != nil {
-		fmt.Printf("error while connecting to database: %v", err.Error())
-		panic("failed to connect to database")
+	backoff := connectBackoffMin
+	for attempt := 1; ; attempt++ {
+		db, err := gorm.Open(mysql.Open(config.GetDSN()), &gorm.Config{
+			// Report driver specific errors such as duplicate keys as gorm.Err* values
+			TranslateError: true,
+			// Log statements through slog with the ID of the request that
+			// ran them; bound values may be personal data, so production
+			// logs only the statement
+			Logger: logging.NewGormLogger(config.Database.SlowQueryThreshold, config.IsProduction()),
+		})
+		if err == nil {
+			DB = db
+			return nil
+		}
+
+		wait := backoff + rand.N(backoff/5)
+		slog.WarnContext(ctx, "database connection attempt failed",
+			"attempt", attempt, "error", err, "retry_in", wait.Round(time.Millisecond))
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("giving up connecting to database after %d attempts: %w", attempt, err)
+		case <-time.After(wait):
+		}
+		backoff = min(backoff*2, connectBackoffMax)
 	}
 }
 
+// GetDatabase returns the database, connecting on first use. It exits the
+// process if the database cannot be reached within DB_CONNECT_TIMEOUT.
 func GetDatabase() *gorm.DB {
 	if DB == nil {
-		ConnectDatabase()
+		if err := ConnectDatabase(context.Background()); err != nil {
+			fatal("Failed to connect to database", err)
+		}
 	}
 	return DB
 }
+
+// CloseDatabase closes the connection pool opened by ConnectDatabase, if any
+func CloseDatabase() error {
+	if DB == nil {
+		return nil
+	}
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	DB = nil
+	return sqlDB.Close()
+}
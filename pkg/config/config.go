@@ -1,11 +1,16 @@
 package config
 
 import (
+	"crypto/rand"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"strconv"
+	"strings"
 	"sync"
+	"time"
+
+	"go-bookstore-mysql-crud/pkg/logging"
 
 	"github.com/lpernett/godotenv"
 )
@@ -19,6 +24,10 @@ var (
 type Config struct {
 	Database DatabaseConfig
 	Server   ServerConfig
+	Auth     AuthConfig
+	Tracing  TracingConfig
+	Log      LogConfig
+	Trash    TrashConfig
 }
 
 // DatabaseConfig holds database configuration
@@ -28,40 +37,172 @@ type DatabaseConfig struct {
 	User     string
 	Password string
 	Name     string
+	// ConnectTimeout bounds how long startup keeps retrying the connection
+	ConnectTimeout time.Duration
+	// SlowQueryThreshold is the duration above which queries are logged as
+	// warnings
+	SlowQueryThreshold time.Duration
 }
 
 // ServerConfig holds server configuration
 type ServerConfig struct {
 	Port string
 	Env  string
+	// ReadTimeout bounds reading a whole request, body included
+	ReadTimeout time.Duration
+	// ReadHeaderTimeout bounds reading the request headers
+	ReadHeaderTimeout time.Duration
+	// WriteTimeout bounds writing the response, measured from the end of the headers
+	WriteTimeout time.Duration
+	// IdleTimeout bounds how long keep-alive connections wait for the next request
+	IdleTimeout time.Duration
+	// MaxHeaderBytes bounds the size of the request headers
+	MaxHeaderBytes int
+	// ShutdownTimeout bounds how long in-flight requests may take to drain
+	// after SIGTERM or SIGINT
+	ShutdownTimeout time.Duration
+	// RequireIfMatch makes changes to books without an If-Match header fail
+	// with 428 Precondition Required, so that no client can overwrite a
+	// change it has not seen
+	RequireIfMatch bool
+	// MaxBatchOperations caps the number of operations of a book batch
+	MaxBatchOperations int
+}
+
+// AuthConfig holds the settings for user login tokens
+type AuthConfig struct {
+	// SigningKeys verify tokens; the first one also signs new tokens. Keeping
+	// the previous key listed after a new one lets tokens it signed expire
+	// naturally during a rotation.
+	SigningKeys     []SigningKey
+	Issuer          string
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+}
+
+// TracingConfig holds OpenTelemetry tracing configuration. The variable
+// names follow the OpenTelemetry SDK conventions.
+type TracingConfig struct {
+	// Exporter is none, stdout or otlp
+	Exporter    string
+	ServiceName string
+	// SampleRatio is the fraction of new traces recorded; requests that
+	// arrive with a sampled traceparent are always recorded
+	SampleRatio float64
+}
+
+// LogConfig holds logging configuration
+type LogConfig struct {
+	// Level is the minimum level logged; debug also logs every SQL statement
+	Level slog.Level
+	// Format is json or text
+	Format string
 }
 
+// TrashConfig holds the retention policy for deleted books
+type TrashConfig struct {
+	// Retention is how long deleted books stay restorable before they are
+	// purged; zero keeps them until they are purged by hand
+	Retention time.Duration
+	// PurgeInterval is how often books past their retention are purged
+	PurgeInterval time.Duration
+}
+
+// SigningKey is an HMAC secret identified by the kid header of the tokens it signs
+type SigningKey struct {
+	ID     string
+	Secret []byte
+}
+
+// minSigningKeyBytes is the shortest secret accepted for HS256
+const minSigningKeyBytes = 32
+
 // LoadConfig loads configuration from environment variables (singleton pattern)
 func LoadConfig() *Config {
 	once.Do(func() {
 		// Load .env file only once
 		err := godotenv.Load()
 		if err != nil {
-			log.Println("No .env file found, using system environment variables")
+			slog.Info("No .env file found, using system environment variables")
 		}
 
 		config = &Config{
 			Database: DatabaseConfig{
-				Host:     getEnv("DB_HOST", ""),
-				Port:     getEnv("DB_PORT", "3306"),
-				User:     getEnv("DB_USER", ""),
-				Password: getEnv("DB_PASSWORD", ""), // Empty default
-				Name:     getEnv("DB_NAME", "BOOK_STORE"),
+				Host:               getEnv("DB_HOST", ""),
+				Port:               getEnv("DB_PORT", "3306"),
+				User:               getEnv("DB_USER", ""),
+				Password:           getEnv("DB_PASSWORD", ""), // Empty default
+				Name:               getEnv("DB_NAME", "BOOK_STORE"),
+				ConnectTimeout:     getEnvDuration("DB_CONNECT_TIMEOUT", time.Minute),
+				SlowQueryThreshold: getEnvDuration("DB_SLOW_QUERY_THRESHOLD", 200*time.Millisecond),
 			},
 			Server: ServerConfig{
-				Port: getEnv("APP_PORT", "8080"),
-				Env:  getEnv("APP_ENV", "development"),
+				Port:               getEnv("APP_PORT", "8080"),
+				Env:                getEnv("APP_ENV", "development"),
+				ReadTimeout:        getEnvDuration("APP_READ_TIMEOUT", 15*time.Second),
+				ReadHeaderTimeout:  getEnvDuration("APP_READ_HEADER_TIMEOUT", 5*time.Second),
+				WriteTimeout:       getEnvDuration("APP_WRITE_TIMEOUT", 30*time.Second),
+				IdleTimeout:        getEnvDuration("APP_IDLE_TIMEOUT", 120*time.Second),
+				MaxHeaderBytes:     getEnvPositiveInt("APP_MAX_HEADER_BYTES", 1<<20),
+				ShutdownTimeout:    getEnvDuration("APP_SHUTDOWN_TIMEOUT", 25*time.Second),
+				RequireIfMatch:     getEnvBool("APP_REQUIRE_IF_MATCH", true),
+				MaxBatchOperations: getEnvPositiveInt("APP_BATCH_MAX_OPERATIONS", 500),
+			},
+			Auth: AuthConfig{
+				Issuer:          getEnv("JWT_ISSUER", "go-bookstore"),
+				AccessTokenTTL:  getEnvDuration("JWT_ACCESS_TTL", 15*time.Minute),
+				RefreshTokenTTL: getEnvDuration("JWT_REFRESH_TTL", 30*24*time.Hour),
+			},
+			Tracing: TracingConfig{
+				Exporter:    getEnv("OTEL_TRACES_EXPORTER", "none"),
+				ServiceName: getEnv("OTEL_SERVICE_NAME", "go-bookstore"),
+				SampleRatio: getEnvFloat("OTEL_TRACES_SAMPLER_ARG", 1),
+			},
+			Trash: TrashConfig{
+				Retention:     time.Duration(getEnvInt("TRASH_RETENTION_DAYS", 30)) * 24 * time.Hour,
+				PurgeInterval: getEnvDuration("TRASH_PURGE_INTERVAL", time.Hour),
 			},
 		}
 
+		if err := config.Log.Level.UnmarshalText([]byte(getEnv("LOG_LEVEL", "info"))); err != nil {
+			fatal("Invalid LOG_LEVEL", err)
+		}
+		// Log aggregators in production parse JSON; people read text
+		defaultFormat := logging.FormatText
+		if config.IsProduction() {
+			defaultFormat = logging.FormatJSON
+		}
+		config.Log.Format = getEnv("LOG_FORMAT", defaultFormat)
+		if config.Log.Format != logging.FormatJSON && config.Log.Format != logging.FormatText {
+			fatal("LOG_FORMAT must be json or text", nil)
+		}
+
+		// The ratio is a probability; NaN fails the check too
+		if ratio := config.Tracing.SampleRatio; !(ratio >= 0 && ratio <= 1) {
+			fatal(fmt.Sprintf("OTEL_TRACES_SAMPLER_ARG must be between 0 and 1, got %v", ratio), nil)
+		}
+
+		keys, err := parseSigningKeys(getEnv("JWT_SIGNING_KEYS", ""))
+		if err != nil {
+			fatal("Invalid JWT_SIGNING_KEYS", err)
+		}
+		if len(keys) == 0 {
+			if config.IsProduction() {
+				fatal("JWT_SIGNING_KEYS environment variable is required in production", nil)
+			}
+			// Tokens signed with a random key do not survive a restart
+			slog.Warn("JWT_SIGNING_KEYS not set, signing tokens with a temporary key")
+			secret := make([]byte, minSigningKeyBytes)
+			if _, err := rand.Read(secret); err != nil {
+				fatal("Failed to generate a temporary signing key", err)
+			}
+			keys = []SigningKey{{ID: "temporary", Secret: secret}}
+		}
+		config.Auth.SigningKeys = keys
+
 		// Validate required configuration
 		if config.Database.Password == "" {
-			log.Fatal("DB_PASSWORD environment variable is required")
+			fatal("DB_PASSWORD environment variable is required", nil)
 		}
 	})
 
@@ -74,11 +215,26 @@ func (c *Config) GetDSN() string {
 		c.Database.User, c.Database.Password, c.Database.Host, c.Database.Port, c.Database.Name)
 }
 
+// Addr returns the address the HTTP server listens on
+func (c *Config) Addr() string {
+	return ":" + c.Server.Port
+}
+
 // IsProduction returns true if the application is running in production
 func (c *Config) IsProduction() bool {
 	return c.Server.Env == "production"
 }
 
+// fatal logs msg with the error, if any, and exits the process
+func fatal(msg string, err error) {
+	if err != nil {
+		slog.Error(msg, "error", err)
+	} else {
+		slog.Error(msg)
+	}
+	os.Exit(1)
+}
+
 // getEnv gets an environment variable or returns a default value
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
@@ -87,12 +243,97 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
-// getEnvInt gets an environment variable as int or returns a default value
-func getEnvInt(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
-		if intValue, err := strconv.Atoi(value); err == nil {
-			return intValue
+// getEnvDuration gets an environment variable as a positive time.Duration
+// or returns a default value. Invalid values stop the process rather than
+// running with a limit the operator did not ask for.
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		fatal("Invalid "+key, err)
+	}
+	if d <= 0 {
+		fatal(fmt.Sprintf("%s must be a positive duration such as 30s, got %q", key, value), nil)
+	}
+	return d
+}
+
+// getEnvBool gets an environment variable as a bool or returns a default
+// value; invalid values stop the process
+func getEnvBool(key string, defaultValue bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		fatal("Invalid "+key, err)
+	}
+	return b
+}
+
+// getEnvFloat gets an environment variable as a float64 or returns a
+// default value; invalid values stop the process
+func getEnvFloat(key string, defaultValue float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		fatal("Invalid "+key, err)
+	}
+	return f
+}
+
+// parseSigningKeys parses a comma separated list of kid:secret pairs
+func parseSigningKeys(raw string) ([]SigningKey, error) {
+	var keys []SigningKey
+	seen := make(map[string]bool)
+	for _, pair := range strings.Split(raw, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		id, secret, ok := strings.Cut(pair, ":")
+		if !ok || id == "" {
+			return nil, fmt.Errorf("expected kid:secret, got %q", pair)
+		}
+		if len(secret) < minSigningKeyBytes {
+			return nil, fmt.Errorf("secret of key %q must be at least %d bytes", id, minSigningKeyBytes)
 		}
+		if seen[id] {
+			return nil, fmt.Errorf("key %q is listed twice", id)
+		}
+		seen[id] = true
+		keys = append(keys, SigningKey{ID: id, Secret: []byte(secret)})
 	}
-	return defaultValue
+	return keys, nil
+}
+
+// getEnvInt gets an environment variable as int or returns a default value;
+// invalid values stop the process
+func getEnvInt(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	intValue, err := strconv.Atoi(value)
+	if err != nil {
+		fatal("Invalid "+key, err)
+	}
+	return intValue
+}
+
+// getEnvPositiveInt gets an environment variable as a positive int or
+// returns a default value; invalid values stop the process
+func getEnvPositiveInt(key string, defaultValue int) int {
+	n := getEnvInt(key, defaultValue)
+	if n <= 0 {
+		fatal(fmt.Sprintf("%s must be a positive number, got %d", key, n), nil)
+	}
+	return n
 }
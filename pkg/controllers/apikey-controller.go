@@ -0,0 +1,117 @@
+package controllers
+
+import (
+	"encoding/json"
+	"go-bookstore-mysql-crud/pkg/apperrors"
+	"go-bookstore-mysql-crud/pkg/auth"
+	"go-bookstore-mysql-crud/pkg/models"
+	"go-bookstore-mysql-crud/pkg/utils"
+	"go-bookstore-mysql-crud/pkg/validation"
+	"net/http"
+)
+
+// APIKeyController serves the API key administration endpoints
+type APIKeyController struct {
+	Keys models.APIKeyRepository
+}
+
+// NewAPIKeyController returns an APIKeyController backed by the given repository
+func NewAPIKeyController(keys models.APIKeyRepository) *APIKeyController {
+	return &APIKeyController{Keys: keys}
+}
+
+// GetAPIKeys godoc
+// @Summary List API keys
+// @Description Retrieve every API key, newest first, including revoked keys. Secrets are never returned.
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Security api_key
+// @Success 200 {object} models.APIKeyListResponse "API keys"
+// @Failure 401 {object} apperrors.Problem "unauthorized - Missing or invalid credentials"
+// @Failure 403 {object} apperrors.Problem "forbidden - Requires the admin scope"
+// @Failure 500 {object} apperrors.Problem "internal_error - Internal server error"
+// @Router /api-keys [get]
+func (c *APIKeyController) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := c.Keys.List(r.Context())
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.APIKeyListResponse{Data: keys})
+}
+
+// CreateAPIKey godoc
+// @Summary Create an API key
+// @Description Create an API key with the given scopes. The key is only returned in this response; store it securely.
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Security api_key
+// @Param key body models.APIKeyRequest true "API key object"
+// @Success 200 {object} models.APIKeyCreatedResponse "Created API key including its secret"
+// @Failure 400 {object} apperrors.Problem "bad_request - Malformed JSON or unknown fields"
+// @Failure 401 {object} apperrors.Problem "unauthorized - Missing or invalid credentials"
+// @Failure 403 {object} apperrors.Problem "forbidden - Requires the admin scope"
+// @Failure 422 {object} apperrors.Problem "validation_failed - Validation failed"
+// @Failure 500 {object} apperrors.Problem "internal_error - Internal server error"
+// @Router /api-keys [post]
+func (c *APIKeyController) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req models.APIKeyRequest
+	if err := utils.ParseBody(r, &req); err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	if err := validation.Struct(&req); err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	key := req.ToAPIKey()
+	secret, prefix, hash, err := auth.GenerateAPIKey()
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	key.Prefix, key.Hash = prefix, hash
+	if err := c.Keys.Create(r.Context(), key); err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.APIKeyCreatedResponse{APIKey: *key, Key: secret})
+}
+
+// RevokeAPIKey godoc
+// @Summary Revoke an API key
+// @Description Revoke an API key; requests using it are rejected from then on. The key stays listed with its revocation time.
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Security api_key
+// @Param id path int true "API key ID"
+// @Success 200 {object} models.APIKey "Revoked API key"
+// @Failure 400 {object} apperrors.Problem "bad_request - Invalid ID"
+// @Failure 401 {object} apperrors.Problem "unauthorized - Missing or invalid credentials"
+// @Failure 403 {object} apperrors.Problem "forbidden - Requires the admin scope"
+// @Failure 404 {object} apperrors.Problem "not_found - API key not found"
+// @Failure 500 {object} apperrors.Problem "internal_error - Internal server error"
+// @Router /api-keys/{id} [delete]
+func (c *APIKeyController) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	ID, err := parseID(r, "api key")
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	key, err := c.Keys.Revoke(r.Context(), ID)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(key)
+}
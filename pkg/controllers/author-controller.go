@@ -0,0 +1,259 @@
+package controllers
+
+import (
+	"encoding/json"
+	"go-bookstore-mysql-crud/pkg/apperrors"
+	"go-bookstore-mysql-crud/pkg/models"
+	"go-bookstore-mysql-crud/pkg/utils"
+	"go-bookstore-mysql-crud/pkg/validation"
+	"net/http"
+)
+
+// AuthorController serves the author endpoints
+type AuthorController struct {
+	Authors models.AuthorRepository
+	// Books is used to list an author's books; it also serves them in the
+	// same envelope as GET /books
+	Books *BookController
+}
+
+// NewAuthorController returns an AuthorController backed by the given
+// repository, listing books through books
+func NewAuthorController(authors models.AuthorRepository, books *BookController) *AuthorController {
+	return &AuthorController{Authors: authors, Books: books}
+}
+
+// GetAuthors godoc
+// @Summary List authors
+// @Description Retrieve a page of authors ordered by name
+// @Tags authors
+// @Accept json
+// @Produce json
+// @Security api_key
+// @Param limit query int false "Maximum number of authors to return (default 20, max 100)"
+// @Param offset query int false "Number of authors to skip"
+// @Param name query string false "Case-insensitive substring of the name"
+// @Success 200 {object} models.AuthorListResponse "Page of authors"
+// @Failure 400 {object} apperrors.Problem "invalid_query - Invalid query parameters"
+// @Failure 401 {object} apperrors.Problem "unauthorized - Missing or invalid credentials"
+// @Failure 403 {object} apperrors.Problem "forbidden - Requires the read scope"
+// @Failure 500 {object} apperrors.Problem "internal_error - Internal server error"
+// @Router /authors [get]
+func (c *AuthorController) GetAuthors(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	query := models.AuthorQuery{Name: params.Get("name")}
+	var err error
+	if query.Limit, err = intParam(params, "limit"); err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	if query.Offset, err = intParam(params, "offset"); err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	page, err := c.Authors.List(r.Context(), query)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.AuthorListResponse{
+		Data:       page.Authors,
+		Pagination: offsetPagination(r, page.Total, query.Limit, query.Offset),
+	})
+}
+
+// GetAuthorById godoc
+// @Summary Get an author by ID
+// @Description Retrieve a specific author by its ID
+// @Tags authors
+// @Accept json
+// @Produce json
+// @Security api_key
+// @Param id path int true "Author ID"
+// @Success 200 {object} models.Author "Author details"
+// @Failure 400 {object} apperrors.Problem "bad_request - Invalid ID"
+// @Failure 401 {object} apperrors.Problem "unauthorized - Missing or invalid credentials"
+// @Failure 403 {object} apperrors.Problem "forbidden - Requires the read scope"
+// @Failure 404 {object} apperrors.Problem "not_found - Author not found"
+// @Failure 500 {object} apperrors.Problem "internal_error - Internal server error"
+// @Router /authors/{id} [get]
+func (c *AuthorController) GetAuthorById(w http.ResponseWriter, r *http.Request) {
+	ID, err := parseID(r, "author")
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	author, err := c.Authors.Get(r.Context(), ID)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(author)
+}
+
+// GetAuthorBooks godoc
+// @Summary List an author's books
+// @Description Retrieve a page of the books crediting an author in any role. Accepts the same parameters as GET /books.
+// @Tags authors
+// @Accept json
+// @Produce json
+// @Security api_key
+// @Param id path int true "Author ID"
+// @Param limit query int false "Maximum number of books to return (default 20, max 100)"
+// @Param offset query int false "Number of books to skip; cannot be combined with cursor"
+// @Param cursor query string false "Opaque cursor from next_cursor or prev_cursor of a previous page"
+// @Param sort query string false "Comma separated sort fields (id, title, author, price, created_at); prefix with - for descending"
+// @Success 200 {object} models.BookListResponse "Page of books"
+// @Failure 400 {object} apperrors.Problem "bad_request - Invalid ID or query parameters"
+// @Failure 401 {object} apperrors.Problem "unauthorized - Missing or invalid credentials"
+// @Failure 403 {object} apperrors.Problem "forbidden - Requires the read scope"
+// @Failure 404 {object} apperrors.Problem "not_found - Author not found"
+// @Failure 500 {object} apperrors.Problem "internal_error - Internal server error"
+// @Router /authors/{id}/books [get]
+func (c *AuthorController) GetAuthorBooks(w http.ResponseWriter, r *http.Request) {
+	ID, err := parseID(r, "author")
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	if _, err := c.Authors.Get(r.Context(), ID); err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	query, err := parseBookQuery(r)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	query.AuthorID = ID
+	c.Books.writeBookPage(w, r, query)
+}
+
+// CreateAuthor godoc
+// @Summary Create a new author
+// @Description Add a new author. Names differing only in case, punctuation or spacing are considered the same author.
+// @Tags authors
+// @Accept json
+// @Produce json
+// @Security api_key
+// @Param author body models.AuthorRequest true "Author object"
+// @Success 200 {object} models.Author "Created author"
+// @Failure 400 {object} apperrors.Problem "bad_request - Malformed JSON or unknown fields"
+// @Failure 401 {object} apperrors.Problem "unauthorized - Missing or invalid credentials"
+// @Failure 403 {object} apperrors.Problem "forbidden - Requires the write scope"
+// @Failure 409 {object} apperrors.Problem "conflict - Author already exists"
+// @Failure 422 {object} apperrors.Problem "validation_failed - Validation failed"
+// @Failure 500 {object} apperrors.Problem "internal_error - Internal server error"
+// @Router /authors [post]
+func (c *AuthorController) CreateAuthor(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeAuthorRequest(r)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	author := req.ToAuthor()
+	if err := c.Authors.Create(r.Context(), author); err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(author)
+}
+
+// UpdateAuthor godoc
+// @Summary Update an author
+// @Description Rename an existing author
+// @Tags authors
+// @Accept json
+// @Produce json
+// @Security api_key
+// @Param id path int true "Author ID"
+// @Param author body models.AuthorRequest true "Updated author object"
+// @Success 200 {object} models.Author "Updated author"
+// @Failure 400 {object} apperrors.Problem "bad_request - Invalid ID, malformed JSON or unknown fields"
+// @Failure 401 {object} apperrors.Problem "unauthorized - Missing or invalid credentials"
+// @Failure 403 {object} apperrors.Problem "forbidden - Requires the write scope"
+// @Failure 404 {object} apperrors.Problem "not_found - Author not found"
+// @Failure 409 {object} apperrors.Problem "conflict - Another author has an equivalent name"
+// @Failure 422 {object} apperrors.Problem "validation_failed - Validation failed"
+// @Failure 500 {object} apperrors.Problem "internal_error - Internal server error"
+// @Router /authors/{id} [put]
+func (c *AuthorController) UpdateAuthor(w http.ResponseWriter, r *http.Request) {
+	ID, err := parseID(r, "author")
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	req, err := decodeAuthorRequest(r)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	author, err := c.Authors.Update(r.Context(), ID, req.ToAuthor())
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(author)
+}
+
+// DeleteAuthor godoc
+// @Summary Delete an author
+// @Description Remove an author that is not credited on any book
+// @Tags authors
+// @Accept json
+// @Produce json
+// @Security api_key
+// @Param id path int true "Author ID"
+// @Success 200 {object} models.Author "Deleted author"
+// @Failure 400 {object} apperrors.Problem "bad_request - Invalid ID"
+// @Failure 401 {object} apperrors.Problem "unauthorized - Missing or invalid credentials"
+// @Failure 403 {object} apperrors.Problem "forbidden - Requires the admin scope"
+// @Failure 404 {object} apperrors.Problem "not_found - Author not found"
+// @Failure 409 {object} apperrors.Problem "conflict - Author is credited on books"
+// @Failure 500 {object} apperrors.Problem "internal_error - Internal server error"
+// @Router /authors/{id} [delete]
+func (c *AuthorController) DeleteAuthor(w http.ResponseWriter, r *http.Request) {
+	ID, err := parseID(r, "author")
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	books, err := c.Books.Books.List(r.Context(), models.BookQuery{AuthorID: ID, Limit: 1})
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	if books.Total > 0 {
+		apperrors.Write(w, r, models.ErrAuthorInUse)
+		return
+	}
+	author, err := c.Authors.Delete(r.Context(), ID)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(author)
+}
+
+// decodeAuthorRequest strictly decodes and validates an AuthorRequest body
+func decodeAuthorRequest(r *http.Request) (*models.AuthorRequest, error) {
+	var req models.AuthorRequest
+	if err := utils.ParseBody(r, &req); err != nil {
+		return nil, err
+	}
+	if err := validation.Struct(&req); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
@@ -0,0 +1,93 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"go-bookstore-mysql-crud/pkg/models"
+	"go-bookstore-mysql-crud/pkg/validation"
+)
+
+// errAuthorRequired is returned for books that credit nobody
+var errAuthorRequired = validation.Errors{{Field: "author", Code: "required", Message: "author is required unless authors is given"}}
+
+// linkAuthors turns the author fields of req into the author links of
+// book. Explicit authors must exist; the names of a free-text author string
+// are left as links without an author ID, for the BookRepository to match
+// to, or create, author records as it stores the book. book.Author is kept
+// as the display string of the credited authors.
+func (c *BookController) linkAuthors(ctx context.Context, req *models.BookRequest, book *models.Book) error {
+	book.Authors = []models.BookAuthor{}
+
+	if len(req.Authors) == 0 {
+		names := models.SplitAuthorNames(req.Author)
+		if len(names) == 0 {
+			return errAuthorRequired
+		}
+		for i, name := range names {
+			book.Authors = append(book.Authors, models.BookAuthor{Name: name, Role: models.RoleAuthor, Position: i})
+		}
+		return nil
+	}
+
+	ids := make([]uint, len(req.Authors))
+	for i, a := range req.Authors {
+		ids[i] = a.AuthorID
+	}
+	authors, err := c.Authors.GetMany(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	var (
+		errs validation.Errors
+		seen = make(map[string]bool)
+	)
+	for i, a := range req.Authors {
+		author, ok := authors[a.AuthorID]
+		if !ok {
+			field := fmt.Sprintf("authors[%d].author_id", i)
+			errs = append(errs, validation.FieldError{Field: field, Code: "not_found", Message: fmt.Sprintf("%s: author %d does not exist", field, a.AuthorID)})
+			continue
+		}
+		role := a.Role
+		if role == "" {
+			role = models.RoleAuthor
+		}
+		key := fmt.Sprintf("%d/%s", a.AuthorID, role)
+		if seen[key] {
+			field := fmt.Sprintf("authors[%d]", i)
+			errs = append(errs, validation.FieldError{Field: field, Code: "duplicate", Message: fmt.Sprintf("%s: author %d is already credited as %s", field, a.AuthorID, role)})
+			continue
+		}
+		seen[key] = true
+		book.Authors = append(book.Authors, models.BookAuthor{AuthorID: author.ID, Name: author.Name, Role: role, Position: i})
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	book.Author = models.CreditLine(book.Authors)
+	return nil
+}
+
+// attachAuthorNames fills in the author names of the books' author links
+func attachAuthorNames(ctx context.Context, authors models.AuthorRepository, books ...*models.Book) error {
+	var ids []uint
+	for _, book := range books {
+		for _, link := range book.Authors {
+			ids = append(ids, link.AuthorID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	found, err := authors.GetMany(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for _, book := range books {
+		for i := range book.Authors {
+			book.Authors[i].Name = found[book.Authors[i].AuthorID].Name
+		}
+	}
+	return nil
+}
@@ -0,0 +1,292 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-bookstore-mysql-crud/pkg/apperrors"
+	"go-bookstore-mysql-crud/pkg/auth"
+	"go-bookstore-mysql-crud/pkg/models"
+	"go-bookstore-mysql-crud/pkg/utils"
+	"go-bookstore-mysql-crud/pkg/validation"
+	"net/http"
+)
+
+// DefaultMaxBatchOperations is the number of operations a batch may hold
+// unless BookController.MaxBatchOperations is changed
+const DefaultMaxBatchOperations = 500
+
+// Modes of a batch
+const (
+	batchAtomic     = "atomic"
+	batchBestEffort = "best_effort"
+)
+
+// BatchRequest is the body of a book batch
+// @Description Book operations applied together
+type BatchRequest struct {
+	// @Description atomic (default) stores every operation or none; best_effort stores each operation that succeeds
+	// @Example "atomic"
+	Mode string `json:"mode,omitempty" example:"atomic"`
+
+	// @Description Operations to apply, in order
+	Operations []BatchOperation `json:"operations"`
+}
+
+// BatchOperation is one item of a BatchRequest
+// @Description Creation, replacement or deletion of one book
+type BatchOperation struct {
+	// @Description create, update or delete
+	// @Example "update"
+	Op string `json:"op" example:"update"`
+
+	// @Description ID of the book to update or delete
+	// @Example 42
+	ID uint `json:"id,omitempty" example:"42"`
+
+	// @Description Version of the book to update or delete, as sent in If-Match; required unless APP_REQUIRE_IF_MATCH is false
+	// @Example 3
+	Version uint `json:"version,omitempty" example:"3"`
+
+	// @Description Book to create, or to replace the book with on update, with the fields of models.BookRequest
+	Book json.RawMessage `json:"book,omitempty" swaggertype:"object"`
+}
+
+// BatchResponse is the outcome of a book batch
+// @Description Outcome of every operation of a book batch
+type BatchResponse struct {
+	// @Description Mode the batch was applied in
+	// @Example "atomic"
+	Mode string `json:"mode" example:"atomic"`
+
+	// @Description Number of operations stored
+	// @Example 2
+	Succeeded int `json:"succeeded" example:"2"`
+
+	// @Description Number of operations that failed or were aborted
+	// @Example 0
+	Failed int `json:"failed" example:"0"`
+
+	// @Description Outcome of each operation, in request order
+	Results []BatchResult `json:"results"`
+}
+
+// BatchResult is the outcome of one operation of a batch
+// @Description Outcome of one operation of a book batch
+type BatchResult struct {
+	// @Description Position of the operation in the request, starting at 0
+	// @Example 0
+	Index int `json:"index" example:"0"`
+
+	// @Description Operation as sent
+	// @Example "create"
+	Op string `json:"op" example:"create"`
+
+	// @Description HTTP status the operation would have been answered with on its own
+	// @Example 200
+	Status int `json:"status" example:"200"`
+
+	// @Description ID of the created, updated or deleted book
+	// @Example 42
+	ID uint `json:"id,omitempty" example:"42"`
+
+	// @Description The book as stored, when the operation succeeded
+	Book *models.Book `json:"book,omitempty"`
+
+	// @Description Why the operation failed; batch_aborted when it was rolled back because another operation of an atomic batch failed
+	Error *apperrors.Problem `json:"error,omitempty"`
+}
+
+// fail records err as the outcome of the operation
+func (res *BatchResult) fail(r *http.Request, err error) {
+	problem := apperrors.ProblemFor(r, err)
+	res.Status = problem.Status
+	res.Book = nil
+	res.Error = &problem
+}
+
+// BatchBooks godoc
+// @Summary Create, update and delete books in bulk
+// @Description Apply up to APP_BATCH_MAX_OPERATIONS (default 500) book operations in order. Creates take a book like POST /books, updates replace a book like PUT /books/{id} and deletes move one to the trash; updates and deletes carry the book's version in place of If-Match. Consecutive creates are inserted together. In atomic mode, the default, the operations share one transaction: if any fails nothing is stored, and the other operations report batch_aborted. In best_effort mode each operation is stored unless it fails. Every result carries the status the operation would have been answered with on its own. Deletes require the admin scope.
+// @Tags books
+// @Accept json
+// @Produce json
+// @Security api_key
+// @Param batch body controllers.BatchRequest true "Operations to apply"
+// @Success 200 {object} controllers.BatchResponse "Every operation was stored"
+// @Success 207 {object} controllers.BatchResponse "Some operations failed; see the status and error of each result"
+// @Failure 400 {object} apperrors.Problem "bad_request - Malformed JSON or unknown fields"
+// @Failure 401 {object} apperrors.Problem "unauthorized - Missing or invalid credentials"
+// @Failure 403 {object} apperrors.Problem "forbidden - Requires the write scope, or the admin scope for deletes"
+// @Failure 413 {object} apperrors.Problem "payload_too_large - Request body too large"
+// @Failure 422 {object} apperrors.Problem "validation_failed - Unknown mode, or no or too many operations"
+// @Failure 500 {object} apperrors.Problem "internal_error - Internal server error"
+// @Router /books:batch [post]
+func (c *BookController) BatchBooks(w http.ResponseWriter, r *http.Request) {
+	var req BatchRequest
+	if err := utils.ParseBody(r, &req); err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	atomic, err := c.checkBatch(r, &req)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	// Operations are validated and their explicit authors checked up front,
+	// so that the repository only sees well formed books. Authors named in
+	// free text are created by the repository, within the batch.
+	results := make([]BatchResult, len(req.Operations))
+	ops := make([]models.BookOperation, 0, len(req.Operations))
+	indexes := make([]int, 0, len(req.Operations))
+	for i, item := range req.Operations {
+		results[i] = BatchResult{Index: i, Op: item.Op, ID: item.ID}
+		op, err := c.prepareOperation(r.Context(), item)
+		if err != nil {
+			results[i].fail(r, err)
+			continue
+		}
+		ops = append(ops, op)
+		indexes = append(indexes, i)
+	}
+	// An atomic batch with an invalid operation is not attempted at all
+	if atomic && len(ops) < len(results) {
+		for _, i := range indexes {
+			results[i].fail(r, models.ErrBatchAborted)
+		}
+		ops = nil
+	}
+
+	if len(ops) > 0 {
+		outcomes, err := c.Books.Batch(r.Context(), ops, atomic)
+		if err != nil {
+			apperrors.Write(w, r, err)
+			return
+		}
+		var books []*models.Book
+		for j, outcome := range outcomes {
+			res := &results[indexes[j]]
+			if outcome.Err != nil {
+				res.fail(r, outcome.Err)
+				continue
+			}
+			res.Status = http.StatusOK
+			res.ID = outcome.Book.ID
+			res.Book = outcome.Book
+			books = append(books, outcome.Book)
+		}
+		if err := attachAuthorNames(r.Context(), c.Authors, books...); err != nil {
+			apperrors.Write(w, r, err)
+			return
+		}
+	}
+
+	response := BatchResponse{Mode: batchBestEffort, Results: results}
+	if atomic {
+		response.Mode = batchAtomic
+	}
+	for _, res := range results {
+		if res.Error != nil {
+			response.Failed++
+		} else {
+			response.Succeeded++
+		}
+	}
+	status := http.StatusOK
+	if response.Failed > 0 {
+		status = http.StatusMultiStatus
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+// checkBatch validates the envelope of a batch and reports whether it is
+// atomic. Deleting books takes the admin scope, which the route itself does
+// not require.
+func (c *BookController) checkBatch(r *http.Request, req *BatchRequest) (bool, error) {
+	atomic := true
+	switch req.Mode {
+	case "", batchAtomic:
+	case batchBestEffort:
+		atomic = false
+	default:
+		return false, validation.Errors{{Field: "mode", Code: "oneof", Message: "mode must be one of " + batchAtomic + ", " + batchBestEffort}}
+	}
+	if n := len(req.Operations); n == 0 || n > c.MaxBatchOperations {
+		code := "max"
+		if n == 0 {
+			code = "min"
+		}
+		message := fmt.Sprintf("operations must hold between 1 and %d items", c.MaxBatchOperations)
+		return false, validation.Errors{{Field: "operations", Code: code, Message: message}}
+	}
+	for _, item := range req.Operations {
+		if models.BookOperationKind(item.Op) != models.BookDelete {
+			continue
+		}
+		if p, ok := auth.PrincipalFrom(r.Context()); ok && !p.Scopes.Allows(models.ScopeAdmin) {
+			return false, apperrors.Forbidden("deleting books requires the " + models.ScopeAdmin + " scope")
+		}
+		break
+	}
+	return atomic, nil
+}
+
+// prepareOperation validates one item of a batch and turns it into a
+// repository operation, linking the authors of the book it carries
+func (c *BookController) prepareOperation(ctx context.Context, item BatchOperation) (models.BookOperation, error) {
+	op := models.BookOperation{Kind: models.BookOperationKind(item.Op), ID: item.ID, Version: item.Version}
+	switch op.Kind {
+	case models.BookCreate:
+		if item.ID != 0 || item.Version != 0 {
+			return op, validation.Errors{{Field: "id", Code: "excluded", Message: "id and version cannot be set on create"}}
+		}
+	case models.BookUpdate, models.BookDelete:
+		if item.ID == 0 {
+			return op, validation.Errors{{Field: "id", Code: "required", Message: "id is required"}}
+		}
+		if item.Version == 0 && c.RequireIfMatch {
+			return op, apperrors.PreconditionRequired("send the book's version to " + item.Op + " it")
+		}
+	default:
+		return op, validation.Errors{{Field: "op", Code: "oneof", Message: "op must be one of create, update, delete"}}
+	}
+
+	if op.Kind == models.BookDelete {
+		if item.Book != nil {
+			return op, validation.Errors{{Field: "book", Code: "excluded", Message: "book cannot be set on delete"}}
+		}
+		return op, nil
+	}
+	if item.Book == nil {
+		return op, validation.Errors{{Field: "book", Code: "required", Message: "book is required"}}
+	}
+	req, err := decodeBookJSON(item.Book)
+	if err != nil {
+		return op, inBatchBook(err)
+	}
+	op.Book = req.ToBook()
+	if err := c.linkAuthors(ctx, req, op.Book); err != nil {
+		return op, inBatchBook(err)
+	}
+	return op, nil
+}
+
+// inBatchBook prefixes the fields of validation errors with "book.", as
+// the book is nested in a batch operation
+func inBatchBook(err error) error {
+	var fields validation.Errors
+	if !errors.As(err, &fields) {
+		return err
+	}
+	nested := make(validation.Errors, len(fields))
+	for i, field := range fields {
+		field.Field = "book." + field.Field
+		field.Message = "book." + field.Message
+		nested[i] = field
+	}
+	return nested
+}
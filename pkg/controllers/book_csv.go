@@ -0,0 +1,514 @@
+package controllers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-bookstore-mysql-crud/pkg/apperrors"
+	"go-bookstore-mysql-crud/pkg/models"
+	"go-bookstore-mysql-crud/pkg/validation"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// MaxImportBytes caps the size of the CSV files accepted by ImportBooks
+	MaxImportBytes = 32 << 20
+	// importBatchSize is the number of rows stored per repository batch
+	importBatchSize = 500
+)
+
+// exportColumns is the header of CSV exports; imports read it back
+var exportColumns = []string{"id", "title", "author", "price", "currency", "isbn_13", "isbn_10", "publisher", "description", "category", "published_year", "version", "created_at", "updated_at"}
+
+// importFields are the book fields CSV columns can be mapped to
+var importFields = []string{"title", "author", "price", "currency", "isbn", "publisher", "description", "category", "published_year"}
+
+// importColumnAliases maps normalized header names to the field their
+// column fills when the request does not map them explicitly
+var importColumnAliases = map[string]string{
+	"title":    "title",
+	"author":   "author",
+	"authors":  "author",
+	"price":    "price",
+	"currency": "currency",
+	"isbn":     "isbn",
+	"isbn_13":  "isbn",
+	"isbn13":   "isbn",
+	"isbn_10":  "isbn",
+	"isbn10":   "isbn",
+	"ean":      "isbn",
+
+	"publisher":        "publisher",
+	"description":      "description",
+	"summary":          "description",
+	"category":         "category",
+	"genre":            "category",
+	"subject":          "category",
+	"published_year":   "published_year",
+	"publication_year": "published_year",
+	"year":             "published_year",
+}
+
+// ImportReport summarises a CSV import
+// @Description Outcome of a CSV import
+type ImportReport struct {
+	// @Description Whether the import only checked the file
+	// @Example false
+	DryRun bool `json:"dry_run" example:"false"`
+
+	// @Description Number of data rows read
+	// @Example 120
+	Rows int `json:"rows" example:"120"`
+
+	// @Description Number of books created, or that would be in a dry run
+	// @Example 100
+	Created int `json:"created" example:"100"`
+
+	// @Description Number of books updated, or that would be in a dry run
+	// @Example 17
+	Updated int `json:"updated" example:"17"`
+
+	// @Description Number of rows rejected
+	// @Example 3
+	Failed int `json:"failed" example:"3"`
+
+	// @Description Book field read from each mapped column, by header
+	Columns map[string]string `json:"columns"`
+
+	// @Description Headers of the columns that were not read
+	IgnoredColumns []string `json:"ignored_columns,omitempty"`
+
+	// @Description Why each rejected row was rejected, in file order
+	Errors []ImportRowError `json:"errors"`
+}
+
+// ImportRowError is the failure of one row of a CSV import
+// @Description Failure of one row of a CSV import
+type ImportRowError struct {
+	// @Description Line of the row in the file; the header is line 1
+	// @Example 7
+	Line int `json:"line" example:"7"`
+
+	// @Description ISBN of the row, as written in the file
+	// @Example "978-0-7432-7356-5"
+	ISBN string `json:"isbn,omitempty" example:"978-0-7432-7356-5"`
+
+	// @Description Why the row was rejected
+	Error apperrors.Problem `json:"error"`
+}
+
+// csvEncoder writes books as the rows of a CSV export
+type csvEncoder struct {
+	w *csv.Writer
+}
+
+// newCSVEncoder starts a CSV export with its header row
+func newCSVEncoder(w io.Writer) bookEncoder {
+	enc := csvEncoder{w: csv.NewWriter(w)}
+	enc.w.Write(exportColumns)
+	return enc
+}
+
+func (e csvEncoder) Encode(book *models.Book) error {
+	return e.w.Write(bookRecord(book))
+}
+
+func (e csvEncoder) Close() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// bookRecord renders a book as a row of exportColumns
+func bookRecord(book *models.Book) []string {
+	var isbn13, isbn10, year string
+	if book.ISBN13 != nil {
+		isbn13 = *book.ISBN13
+	}
+	if book.ISBN10 != nil {
+		isbn10 = *book.ISBN10
+	}
+	if book.PublishedYear != 0 {
+		year = strconv.Itoa(book.PublishedYear)
+	}
+	return []string{
+		strconv.FormatUint(uint64(book.ID), 10),
+		spreadsheetSafe(book.Title),
+		spreadsheetSafe(book.Author),
+		book.Price.Decimal(),
+		book.Price.Currency,
+		isbn13,
+		isbn10,
+		spreadsheetSafe(book.Publisher),
+		spreadsheetSafe(book.Description),
+		spreadsheetSafe(book.Category),
+		year,
+		strconv.FormatUint(uint64(book.Version), 10),
+		book.CreatedAt.UTC().Format(time.RFC3339),
+		book.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// escapedLeads are the first characters of the cells spreadsheetSafe
+// escapes: those a spreadsheet would read as the start of a formula, and
+// the quote itself, so that a cell starting with a quote keeps it on import
+const escapedLeads = "'=+-@\t\r"
+
+// spreadsheetSafe prefixes cells that spreadsheets would evaluate as a
+// formula with a quote; unescapeCell removes it again on import
+func spreadsheetSafe(cell string) string {
+	if cell != "" && strings.ContainsRune(escapedLeads, rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
+
+// unescapeCell reverses spreadsheetSafe, removing exactly one quote
+func unescapeCell(cell string) string {
+	if len(cell) > 1 && cell[0] == '\'' && strings.ContainsRune(escapedLeads, rune(cell[1])) {
+		return cell[1:]
+	}
+	return cell
+}
+
+// ImportBooks godoc
+// @Summary Import books from CSV
+// @Description Create and update books from a CSV file with a header row. Columns named title, author, price, currency, isbn (or isbn_13, isbn_10, ean), publisher, description, category and published_year (or year) are read; map others with map=Header:field, or ignore a column with map=Header:-. Rows whose ISBN belongs to a book update it, changing only the non-empty mapped cells; other rows create a book. Prices are read in major units in the row's currency, or as strings such as "$15.99" when there is no currency column. Rows are validated and stored on their own, so a bad row does not stop the import; the report lists every rejected row by line. With dry_run=true nothing is stored. A GET /books/export file can be imported as is.
+// @Tags books
+// @Accept text/csv
+// @Produce json
+// @Security api_key
+// @Param dry_run query bool false "Check the file and report what would change without storing anything"
+// @Param map query []string false "Column mapping as Header:field, where field is title, author, price, currency, isbn, publisher, description, category, published_year or - to ignore the column" collectionFormat(multi)
+// @Param delimiter query string false "Field delimiter (default ,)"
+// @Param file body string true "CSV file with a header row"
+// @Success 200 {object} controllers.ImportReport "Import report"
+// @Failure 400 {object} apperrors.Problem "invalid_query - Invalid dry_run, map or delimiter; bad_request - The file could not be read"
+// @Failure 401 {object} apperrors.Problem "unauthorized - Missing or invalid credentials"
+// @Failure 403 {object} apperrors.Problem "forbidden - Requires the write scope"
+// @Failure 413 {object} apperrors.Problem "payload_too_large - File larger than 32 MiB"
+// @Failure 422 {object} apperrors.Problem "validation_failed - Missing header row or no column maps to a book field"
+// @Failure 500 {object} apperrors.Problem "internal_error - Internal server error"
+// @Router /books/import [post]
+func (c *BookController) ImportBooks(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseImportOptions(r)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	defer r.Body.Close()
+	reader := csv.NewReader(c.progress(w).reader(http.MaxBytesReader(w, r.Body, MaxImportBytes)))
+	reader.Comma = opts.delimiter
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		apperrors.Write(w, r, validation.Errors{{Field: "file", Code: "required", Message: "file must start with a header row"}})
+		return
+	}
+	if err != nil {
+		apperrors.Write(w, r, importReadError(err))
+		return
+	}
+	imp := &bookImport{
+		c:      c,
+		r:      r,
+		seen:   make(map[string]int),
+		report: ImportReport{DryRun: opts.dryRun, Columns: make(map[string]string), Errors: []ImportRowError{}},
+	}
+	if err := imp.mapColumns(header, opts.mapping); err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	reader.FieldsPerRecord = len(header)
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			imp.report.Rows++
+			imp.fail(parseErr.StartLine, "", apperrors.BadRequest(parseErr.Err.Error()))
+			continue
+		}
+		if err != nil {
+			apperrors.Write(w, r, importReadError(err))
+			return
+		}
+		line, _ := reader.FieldPos(0)
+		imp.row(line, record)
+		if len(imp.pending) >= importBatchSize {
+			if err := imp.flush(); err != nil {
+				apperrors.Write(w, r, err)
+				return
+			}
+		}
+	}
+	if err := imp.flush(); err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	slices.SortStableFunc(imp.report.Errors, func(a, b ImportRowError) int { return a.Line - b.Line })
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(imp.report)
+}
+
+// importOptions are the query parameters of ImportBooks
+type importOptions struct {
+	dryRun    bool
+	delimiter rune
+	// mapping maps normalized headers to a field, or "-" to ignore the column
+	mapping map[string]string
+}
+
+// parseImportOptions reads the query parameters of ImportBooks
+func parseImportOptions(r *http.Request) (importOptions, error) {
+	params := r.URL.Query()
+	opts := importOptions{delimiter: ',', mapping: make(map[string]string)}
+	if raw := params.Get("dry_run"); raw != "" {
+		dryRun, err := strconv.ParseBool(raw)
+		if err != nil {
+			return opts, fmt.Errorf("%w: dry_run must be true or false", models.ErrInvalidQuery)
+		}
+		opts.dryRun = dryRun
+	}
+	if raw := params.Get("delimiter"); raw != "" {
+		delimiter, size := utf8.DecodeRuneInString(raw)
+		if size != len(raw) || delimiter == '"' || delimiter == '\r' || delimiter == '\n' || delimiter == utf8.RuneError {
+			return opts, fmt.Errorf("%w: delimiter must be a single character other than a quote or line break", models.ErrInvalidQuery)
+		}
+		opts.delimiter = delimiter
+	}
+	for _, m := range params["map"] {
+		// Headers may contain colons, fields never do
+		i := strings.LastIndex(m, ":")
+		if i <= 0 {
+			return opts, fmt.Errorf("%w: map %q must be Header:field", models.ErrInvalidQuery, m)
+		}
+		field := strings.TrimSpace(m[i+1:])
+		if field != "-" && !slices.Contains(importFields, field) {
+			return opts, fmt.Errorf("%w: map %q: field must be one of %s or -", models.ErrInvalidQuery, m, strings.Join(importFields, ", "))
+		}
+		opts.mapping[normalizeColumn(m[:i])] = field
+	}
+	return opts, nil
+}
+
+// normalizeColumn reduces a header to the form aliases and mappings are
+// matched in, e.g. "ISBN-13 " to "isbn_13"
+func normalizeColumn(header string) string {
+	header = strings.ToLower(strings.TrimSpace(header))
+	return strings.Join(strings.FieldsFunc(header, func(r rune) bool { return r == ' ' || r == '-' || r == '_' }), "_")
+}
+
+// importReadError maps a failure to read the uploaded file onto an API error
+func importReadError(err error) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return apperrors.New(apperrors.CodePayloadTooLarge, fmt.Sprintf("file exceeds %d bytes", tooLarge.Limit))
+	}
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return apperrors.BadRequest(parseErr.Error())
+	}
+	return err
+}
+
+// bookImport holds the state of one CSV import
+type bookImport struct {
+	c *BookController
+	r *http.Request
+	// columns holds the field each column fills, "" for ignored columns
+	columns []string
+	// seen holds the line each ISBN-13 was first read on
+	seen map[string]int
+	// pending holds the operations not stored yet and pendingRows the
+	// rows they come from
+	pending     []models.BookOperation
+	pendingRows []importRow
+	report      ImportReport
+}
+
+// importRow identifies a row in the report
+type importRow struct {
+	line int
+	isbn string
+}
+
+// mapColumns decides which field each column of header fills. Mappings
+// take precedence over aliases; when several columns fill the same field
+// the first one is read.
+func (imp *bookImport) mapColumns(header []string, mapping map[string]string) error {
+	imp.columns = make([]string, len(header))
+	used := make(map[string]bool)
+	for i, name := range header {
+		if i == 0 {
+			// Spreadsheets often save CSV with a byte order mark
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		key := normalizeColumn(name)
+		field, ok := mapping[key]
+		if !ok {
+			field = importColumnAliases[key]
+		}
+		if field == "" || field == "-" || used[field] {
+			imp.report.IgnoredColumns = append(imp.report.IgnoredColumns, name)
+			continue
+		}
+		used[field] = true
+		imp.columns[i] = field
+		imp.report.Columns[name] = field
+	}
+	if len(used) == 0 {
+		message := "no column of the header maps to a book field; name them " + strings.Join(importFields, ", ") + " or map them with map=Header:field"
+		return validation.Errors{{Field: "file", Code: "header", Message: message}}
+	}
+	return nil
+}
+
+// row checks one data row and queues the change it makes, or counts it
+// in a dry run
+func (imp *bookImport) row(line int, record []string) {
+	imp.report.Rows++
+	cells := make(map[string]string)
+	for i, field := range imp.columns {
+		if field != "" {
+			cells[field] = unescapeCell(strings.TrimSpace(record[i]))
+		}
+	}
+	row := importRow{line: line, isbn: cells["isbn"]}
+	op, err := imp.prepare(line, cells)
+	if err != nil {
+		imp.fail(row.line, row.isbn, err)
+		return
+	}
+	if imp.report.DryRun {
+		imp.count(op)
+		return
+	}
+	imp.pending = append(imp.pending, op)
+	imp.pendingRows = append(imp.pendingRows, row)
+}
+
+// prepare turns the cells of a row into an update of the book with the
+// row's ISBN, if there is one, or into a create
+func (imp *bookImport) prepare(line int, cells map[string]string) (models.BookOperation, error) {
+	ctx := imp.r.Context()
+	op := models.BookOperation{Kind: models.BookCreate}
+	req := &models.BookRequest{}
+	if isbn := cells["isbn"]; isbn != "" {
+		isbn13, err := models.NormalizeISBN(isbn)
+		if err != nil {
+			return op, validation.Errors{{Field: "isbn", Code: "isbn", Message: "isbn is not a valid ISBN-10 or ISBN-13"}}
+		}
+		if first, ok := imp.seen[isbn13]; ok {
+			return op, apperrors.Conflict(fmt.Sprintf("ISBN %s is also on line %d", isbn13, first))
+		}
+		imp.seen[isbn13] = line
+		current, err := imp.c.Books.GetByISBN(ctx, isbn13)
+		switch {
+		case err == nil:
+			op = models.BookOperation{Kind: models.BookUpdate, ID: current.ID, Version: current.Version}
+			req = current.ToRequest()
+		case !errors.Is(err, models.ErrBookNotFound):
+			return op, err
+		}
+		req.ISBN = isbn13
+	}
+
+	// Empty cells keep the current value of the book being updated
+	if title := cells["title"]; title != "" {
+		req.Title = title
+	}
+	if author := cells["author"]; author != "" && author != req.Author {
+		// authors takes precedence over author, so credits are rebuilt
+		// from the new free text
+		req.Author = author
+		req.Authors = nil
+	}
+	if price := cells["price"]; price != "" {
+		money, err := parseCSVPrice(price, cells["currency"])
+		if err != nil {
+			return op, validation.Errors{{Field: "price", Code: "invalid", Message: "price is invalid: " + err.Error()}}
+		}
+		req.Price = money
+	}
+	if publisher := cells["publisher"]; publisher != "" {
+		req.Publisher = publisher
+	}
+	if description := cells["description"]; description != "" {
+		req.Description = description
+	}
+	if category := cells["category"]; category != "" {
+		req.Category = category
+	}
+	if year := cells["published_year"]; year != "" {
+		published, err := strconv.Atoi(year)
+		if err != nil {
+			return op, validation.Errors{{Field: "published_year", Code: "invalid", Message: "published_year must be a year such as 1925"}}
+		}
+		req.PublishedYear = published
+	}
+	if err := validation.Struct(req); err != nil {
+		return op, err
+	}
+
+	op.Book = req.ToBook()
+	// The repository creates the missing authors when the row is stored,
+	// which a dry run never does
+	return op, imp.c.linkAuthors(ctx, req, op.Book)
+}
+
+// parseCSVPrice reads a price cell, in major units of currency when the row
+// has one and as a legacy price string such as "$15.99" otherwise
+func parseCSVPrice(price, currency string) (models.Money, error) {
+	if currency == "" {
+		return models.ParseLegacyPrice(price)
+	}
+	return models.ParseDecimal(strings.ReplaceAll(price, ",", ""), currency)
+}
+
+// flush stores the pending operations, each on its own
+func (imp *bookImport) flush() error {
+	if len(imp.pending) == 0 {
+		return nil
+	}
+	results, err := imp.c.Books.Batch(imp.r.Context(), imp.pending, false)
+	if err != nil {
+		return err
+	}
+	for i, result := range results {
+		if result.Err != nil {
+			imp.fail(imp.pendingRows[i].line, imp.pendingRows[i].isbn, result.Err)
+			continue
+		}
+		imp.count(imp.pending[i])
+	}
+	imp.pending, imp.pendingRows = imp.pending[:0], imp.pendingRows[:0]
+	return nil
+}
+
+// count records a successful row in the report
+func (imp *bookImport) count(op models.BookOperation) {
+	if op.Kind == models.BookCreate {
+		imp.report.Created++
+	} else {
+		imp.report.Updated++
+	}
+}
+
+// fail records a rejected row in the report
+func (imp *bookImport) fail(line int, isbn string, err error) {
+	imp.report.Failed++
+	imp.report.Errors = append(imp.report.Errors, ImportRowError{Line: line, ISBN: isbn, Error: apperrors.ProblemFor(imp.r, err)})
+}
@@ -0,0 +1,189 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"go-bookstore-mysql-crud/pkg/apperrors"
+	"go-bookstore-mysql-crud/pkg/marc"
+	"go-bookstore-mysql-crud/pkg/models"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// bookEncoder writes the books of an export in one format
+type bookEncoder interface {
+	Encode(book *models.Book) error
+	// Close ends the export; it does not close the response
+	Close() error
+}
+
+// exportFormat describes a format books can be exported in
+type exportFormat struct {
+	contentType string
+	filename    string
+	// credits is set for formats naming each contributor, which need the
+	// names of the author links
+	credits    bool
+	newEncoder func(w io.Writer) bookEncoder
+}
+
+// exportFormats are the formats ExportBooks supports, by name
+var exportFormats = map[string]exportFormat{
+	"csv":     {contentType: "text/csv; charset=utf-8", filename: "books.csv", newEncoder: newCSVEncoder},
+	"marc":    {contentType: "application/marc", filename: "books.mrc", credits: true, newEncoder: newMARCEncoder},
+	"marcxml": {contentType: "application/marcxml+xml; charset=utf-8", filename: "books.xml", credits: true, newEncoder: newMARCXMLEncoder},
+}
+
+// ExportBooks godoc
+// @Summary Export books
+// @Description Stream every book matching the filters, in the order GET /books would list them. format=csv (the default) has the columns id, title, author, price (in major units), currency, isbn_13, isbn_10, publisher, description, category, published_year, version, created_at and updated_at, and can be imported again; cells starting with =, +, - or @ are prefixed with ' so that spreadsheets do not run them as formulas. format=marc renders binary MARC 21 bibliographic records and format=marcxml a MARCXML collection, with the ID (001), ISBNs and price (020, 365), main author (100), title (245), publisher and year (264), description (520), category (653) and other contributors (700) of each book.
+// @Tags books
+// @Produce text/csv
+// @Produce application/marc
+// @Produce application/marcxml+xml
+// @Security api_key
+// @Param format query string false "csv (default), marc or marcxml"
+// @Param sort query string false "Comma separated sort fields (id, title, author, price, created_at); prefix with - for descending"
+// @Param title query string false "Case-insensitive substring of the title"
+// @Param author query string false "Case-insensitive substring of the author"
+// @Param author_id query int false "ID of an author the books credit"
+// @Param category query string false "Category, ignoring case"
+// @Param year query int false "Publication year"
+// @Param min_price query string false "Minimum price in major units, inclusive, e.g. 9.99"
+// @Param max_price query string false "Maximum price in major units, inclusive, e.g. 19.99"
+// @Param currency query string false "ISO-4217 currency of min_price and max_price (default USD)"
+// @Success 200 {string} string "The books in the requested format"
+// @Failure 400 {object} apperrors.Problem "invalid_query - Invalid format or query parameters"
+// @Failure 401 {object} apperrors.Problem "unauthorized - Missing or invalid credentials"
+// @Failure 403 {object} apperrors.Problem "forbidden - Requires the read scope"
+// @Failure 500 {object} apperrors.Problem "internal_error - Internal server error"
+// @Router /books/export [get]
+func (c *BookController) ExportBooks(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("format")
+	if name == "" {
+		name = "csv"
+	}
+	format, ok := exportFormats[name]
+	if !ok {
+		names := slices.Sorted(func(yield func(string) bool) {
+			for n := range exportFormats {
+				if !yield(n) {
+					return
+				}
+			}
+		})
+		apperrors.Write(w, r, fmt.Errorf("%w: format must be one of %s", models.ErrInvalidQuery, strings.Join(names, ", ")))
+		return
+	}
+	query, err := parseBookQuery(r)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	// The response starts with the first book, so that a query the
+	// repository rejects is still answered with a problem
+	var enc bookEncoder
+	start := func() {
+		w.Header().Set("Content-Type", format.contentType)
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", format.filename))
+		w.WriteHeader(http.StatusOK)
+		enc = format.newEncoder(w)
+	}
+	names := creditNames{authors: c.Authors, names: make(map[uint]string)}
+	progress := c.progress(w)
+	err = c.Books.Each(r.Context(), query, func(book *models.Book) error {
+		progress.advance()
+		if format.credits {
+			if err := names.fill(r.Context(), book); err != nil {
+				return err
+			}
+		}
+		if enc == nil {
+			start()
+		}
+		return enc.Encode(book)
+	})
+	if err != nil && enc == nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	if err == nil {
+		if enc == nil {
+			start()
+		}
+		err = enc.Close()
+	}
+	if err != nil {
+		// Abort the connection so that the client sees the download fail
+		// instead of a silently truncated file
+		slog.ErrorContext(r.Context(), "export failed", "format", name, "error", err)
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// creditNames fills in the names of the author links of exported books,
+// looking each author up once per export
+type creditNames struct {
+	authors models.AuthorRepository
+	names   map[uint]string
+}
+
+func (n *creditNames) fill(ctx context.Context, book *models.Book) error {
+	var missing []uint
+	for _, link := range book.Authors {
+		if _, ok := n.names[link.AuthorID]; !ok {
+			missing = append(missing, link.AuthorID)
+		}
+	}
+	if len(missing) > 0 {
+		found, err := n.authors.GetMany(ctx, missing)
+		if err != nil {
+			return err
+		}
+		for _, id := range missing {
+			n.names[id] = found[id].Name
+		}
+	}
+	for i := range book.Authors {
+		book.Authors[i].Name = n.names[book.Authors[i].AuthorID]
+	}
+	return nil
+}
+
+// marcEncoder writes books as binary MARC 21 records
+type marcEncoder struct {
+	w *marc.Writer
+}
+
+func newMARCEncoder(w io.Writer) bookEncoder {
+	return marcEncoder{w: marc.NewWriter(w)}
+}
+
+func (e marcEncoder) Encode(book *models.Book) error {
+	return e.w.Write(marc.FromBook(book))
+}
+
+func (e marcEncoder) Close() error {
+	return nil
+}
+
+// marcXMLEncoder writes books as the records of a MARCXML collection
+type marcXMLEncoder struct {
+	w *marc.XMLWriter
+}
+
+func newMARCXMLEncoder(w io.Writer) bookEncoder {
+	return marcXMLEncoder{w: marc.NewXMLWriter(w)}
+}
+
+func (e marcXMLEncoder) Encode(book *models.Book) error {
+	return e.w.Write(marc.FromBook(book))
+}
+
+func (e marcXMLEncoder) Close() error {
+	return e.w.Close()
+}
@@ -0,0 +1,157 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-bookstore-mysql-crud/pkg/apperrors"
+	"go-bookstore-mysql-crud/pkg/models"
+	"go-bookstore-mysql-crud/pkg/onix"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// MaxONIXBytes caps the size of the ONIX messages accepted by ImportONIX;
+// larger feeds are imported with the onix command
+const MaxONIXBytes = 512 << 20
+
+// ONIXReport is the outcome of an ONIX import
+// @Description Outcome of an ONIX import
+type ONIXReport struct {
+	// @Description Whether the import only checked the message
+	// @Example false
+	DryRun bool `json:"dry_run" example:"false"`
+
+	// @Description Number of products read
+	// @Example 250
+	Products int `json:"products" example:"250"`
+
+	// @Description Number of books created, or that would be in a dry run
+	// @Example 180
+	Created int `json:"created" example:"180"`
+
+	// @Description Number of books updated, or that would be in a dry run
+	// @Example 50
+	Updated int `json:"updated" example:"50"`
+
+	// @Description Number of products skipped: deletion notices, products without an ISBN and books already up to date
+	// @Example 15
+	Skipped int `json:"skipped" example:"15"`
+
+	// @Description Number of products rejected
+	// @Example 5
+	Failed int `json:"failed" example:"5"`
+
+	// @Description Outcome of each skipped or rejected product, in message order; created and updated products are only counted
+	Results []ONIXResult `json:"results"`
+
+	// @Description Why the message could not be read to its end; the products before it were imported
+	Error *apperrors.Problem `json:"error,omitempty"`
+}
+
+// ONIXResult is the outcome of a product of an ONIX import that was skipped
+// or rejected
+// @Description Outcome of a skipped or rejected product of an ONIX import
+type ONIXResult struct {
+	// @Description Line of the message the product starts on
+	// @Example 42
+	Line int `json:"line" example:"42"`
+
+	// @Description RecordReference of the product
+	// @Example "com.example.9780743273565"
+	RecordReference string `json:"record_reference,omitempty" example:"com.example.9780743273565"`
+
+	// @Description ISBN of the product, as written in the message
+	// @Example "9780743273565"
+	ISBN string `json:"isbn,omitempty" example:"9780743273565"`
+
+	// @Description skipped or failed
+	// @Example "skipped"
+	Status onix.Status `json:"status" swaggertype:"string" example:"skipped"`
+
+	// @Description ID of the book the product was matched to by ISBN
+	// @Example 42
+	BookID uint `json:"book_id,omitempty" example:"42"`
+
+	// @Description Why the product was skipped
+	// @Example "the book is up to date"
+	Reason string `json:"reason,omitempty" example:"the book is up to date"`
+
+	// @Description Why the product was rejected
+	Error *apperrors.Problem `json:"error,omitempty"`
+}
+
+// ImportONIX godoc
+// @Summary Import books from an ONIX 3.0 feed
+// @Description Create and update books from the products of an ONIX 3.0 message, in reference or short tags. The distinctive title, the authors, editors and translators, the publisher, the description, the main subject as the category, the year of publication, the recommended retail price and the ISBN of each product are read. Products whose ISBN belongs to a book update it, keeping the fields the product does not provide; other products create a book. Deletion notices, products without an ISBN and books already up to date are skipped. The message is read and stored a product at a time, and a bad product does not stop the import; the report counts the products created and updated and lists those skipped or rejected. With dry_run=true nothing is stored. The import runs past APP_READ_TIMEOUT and APP_WRITE_TIMEOUT as long as the message keeps arriving. Messages over 512 MiB are imported with the onix command.
+// @Tags books
+// @Accept xml
+// @Produce json
+// @Security api_key
+// @Param dry_run query bool false "Check the message and report what would change without storing anything"
+// @Param currency query string false "ISO-4217 currency of the prices to import; by default the recommended retail price in any supported currency"
+// @Param message body string true "ONIX 3.0 message"
+// @Success 200 {object} controllers.ONIXReport "Import report"
+// @Failure 400 {object} apperrors.Problem "invalid_query - Invalid dry_run or currency; bad_request - Not an ONIX 3.0 message"
+// @Failure 401 {object} apperrors.Problem "unauthorized - Missing or invalid credentials"
+// @Failure 403 {object} apperrors.Problem "forbidden - Requires the write scope"
+// @Failure 413 {object} apperrors.Problem "payload_too_large - Message larger than 512 MiB"
+// @Failure 500 {object} apperrors.Problem "internal_error - Internal server error"
+// @Router /books/import/onix [post]
+func (c *BookController) ImportONIX(w http.ResponseWriter, r *http.Request) {
+	importer := &onix.Importer{Books: c.Books, Authors: c.Authors}
+	params := r.URL.Query()
+	if raw := params.Get("dry_run"); raw != "" {
+		dryRun, err := strconv.ParseBool(raw)
+		if err != nil {
+			apperrors.Write(w, r, fmt.Errorf("%w: dry_run must be true or false", models.ErrInvalidQuery))
+			return
+		}
+		importer.DryRun = dryRun
+	}
+	if currency := strings.ToUpper(params.Get("currency")); currency != "" {
+		if _, err := models.NewMoney(0, currency); err != nil {
+			apperrors.Write(w, r, fmt.Errorf("%w: currency %q is not supported", models.ErrInvalidQuery, currency))
+			return
+		}
+		importer.Currency = currency
+	}
+
+	defer r.Body.Close()
+	report, err := importer.Import(r.Context(), c.progress(w).reader(http.MaxBytesReader(w, r.Body, MaxONIXBytes)))
+	if err != nil {
+		apperrors.Write(w, r, importReadError(err))
+		return
+	}
+
+	response := ONIXReport{
+		DryRun:   report.DryRun,
+		Products: report.Products,
+		Created:  report.Created,
+		Updated:  report.Updated,
+		Skipped:  report.Skipped,
+		Failed:   report.Failed,
+		Results:  make([]ONIXResult, len(report.Results)),
+	}
+	for i, res := range report.Results {
+		response.Results[i] = ONIXResult{
+			Line:            res.Line,
+			RecordReference: res.RecordReference,
+			ISBN:            res.ISBN,
+			Status:          res.Status,
+			BookID:          res.BookID,
+			Reason:          res.Reason,
+		}
+		if res.Err != nil {
+			problem := apperrors.ProblemFor(r, res.Err)
+			response.Results[i].Error = &problem
+		}
+	}
+	if report.Error != nil {
+		problem := apperrors.ProblemFor(r, report.Error)
+		response.Error = &problem
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
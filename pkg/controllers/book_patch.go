@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"encoding/json"
+	"go-bookstore-mysql-crud/pkg/apperrors"
+	"go-bookstore-mysql-crud/pkg/patch"
+	"go-bookstore-mysql-crud/pkg/utils"
+	"mime"
+	"net/http"
+	"slices"
+)
+
+// PatchBook godoc
+// @Summary Patch a book
+// @Description Change some fields of a book. The patch applies to the book in the shape of a PUT body (title, author, authors, price, publisher, description, category, published_year and isbn) and the result is validated like one before anything is stored. Send a JSON Merge Patch (RFC 7396) as application/merge-patch+json, e.g. {"isbn": null, "category": "Classics"} to clear the ISBN and recategorize the book, or a JSON Patch (RFC 6902) as application/json-patch+json, e.g. [{"op": "test", "path": "/title", "value": "Old"}, {"op": "replace", "path": "/title", "value": "New"}]. Changing author but not authors credits the book from the new free text.
+// @Tags books
+// @Accept application/merge-patch+json,application/json-patch+json
+// @Produce json
+// @Security api_key
+// @Param id path int true "Book ID"
+// @Param If-Match header string false "ETag of the version being patched; required unless APP_REQUIRE_IF_MATCH is false"
+// @Param patch body []patch.Operation true "JSON Patch operations, or a JSON Merge Patch document with the fields of models.BookRequest"
+// @Success 200 {object} models.BookResponse "Patched book"
+// @Header 200 {string} ETag "New version of the book"
+// @Failure 400 {object} apperrors.Problem "bad_request - Invalid ID, malformed patch or unknown fields"
+// @Failure 401 {object} apperrors.Problem "unauthorized - Missing or invalid credentials"
+// @Failure 403 {object} apperrors.Problem "forbidden - Requires the write scope"
+// @Failure 404 {object} apperrors.Problem "not_found - Book not found"
+// @Failure 409 {object} apperrors.Problem "conflict - A test operation failed, a path does not exist or the ISBN is used by another book"
+// @Failure 412 {object} apperrors.Problem "precondition_failed - The book has changed since the ETag in If-Match"
+// @Failure 413 {object} apperrors.Problem "payload_too_large - Request body too large"
+// @Failure 415 {object} apperrors.Problem "unsupported_media_type - Content-Type is not a supported patch format"
+// @Failure 422 {object} apperrors.Problem "validation_failed - The patched book is invalid or credits an unknown author"
+// @Failure 428 {object} apperrors.Problem "precondition_required - If-Match is missing"
+// @Failure 500 {object} apperrors.Problem "internal_error - Internal server error"
+// @Router /books/{id} [patch]
+func (c *BookController) PatchBook(w http.ResponseWriter, r *http.Request) {
+	ID, err := parseBookID(r)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	var apply func(doc, p []byte) ([]byte, error)
+	switch mediaType {
+	case patch.MergePatchType:
+		apply = patch.MergePatch
+	case patch.JSONPatchType:
+		apply = patch.JSONPatch
+	default:
+		w.Header().Set("Accept-Patch", patch.MergePatchType+", "+patch.JSONPatchType)
+		apperrors.Write(w, r, apperrors.New(apperrors.CodeUnsupportedMediaType,
+			"send a "+patch.MergePatchType+" or "+patch.JSONPatchType+" document"))
+		return
+	}
+	body, err := utils.ReadBody(r)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	current, err := c.Books.Get(r.Context(), ID)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	version, err := c.ifMatchVersion(r, current)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	base := current.ToRequest()
+	doc, _ := json.Marshal(base)
+	patched, err := apply(doc, body)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	req, err := decodeBookJSON(patched)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	// authors takes precedence over author, so a patch that only rewrites
+	// the free text would otherwise be ignored
+	if req.Author != base.Author && slices.Equal(req.Authors, base.Authors) {
+		req.Authors = nil
+	}
+	c.replaceBook(w, r, ID, req, version)
+}
@@ -0,0 +1,79 @@
+package controllers
+
+import (
+	"encoding/json"
+	"go-bookstore-mysql-crud/pkg/apperrors"
+	"go-bookstore-mysql-crud/pkg/models"
+	"net/http"
+)
+
+// SearchBooks godoc
+// @Summary Search books
+// @Description Full-text search of the title, author and description of the books, most relevant first; title matches weigh the most. A book matches when it contains every word of q. Quote words to search for a "phrase" and end a word with * to search for a prefix, e.g. gats*. Words shorter than 3 characters and common words such as "the" are ignored outside phrases. Each result carries its highlighted matches.
+// @Tags books
+// @Produce json
+// @Security api_key
+// @Param q query string true "Search query, e.g. \"great gatsby\" fitzg*"
+// @Param limit query int false "Maximum number of books to return (default 20, max 100)"
+// @Param offset query int false "Number of books to skip"
+// @Success 200 {object} models.BookSearchResponse "Page of results"
+// @Failure 400 {object} apperrors.Problem "invalid_query - Missing q, q without searchable words or invalid pagination"
+// @Failure 401 {object} apperrors.Problem "unauthorized - Missing or invalid credentials"
+// @Failure 403 {object} apperrors.Problem "forbidden - Requires the read scope"
+// @Failure 500 {object} apperrors.Problem "internal_error - Internal server error"
+// @Router /books/search [get]
+func (c *BookController) SearchBooks(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	terms, err := models.ParseSearch(params.Get("q"))
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	limit, err := intParam(params, "limit")
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	offset, err := intParam(params, "offset")
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+
+	page, err := c.Books.Search(r.Context(), models.BookSearch{Terms: terms, Limit: limit, Offset: offset})
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	books := make([]*models.Book, len(page.Hits))
+	for i := range page.Hits {
+		books[i] = &page.Hits[i].Book
+	}
+	if err := attachAuthorNames(r.Context(), c.Authors, books...); err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	data := make([]bookSearchHit, len(page.Hits))
+	for i, hit := range page.Hits {
+		data[i] = bookSearchHit{Book: hit.Book, Score: hit.Score, Highlights: models.HighlightBook(terms, &hit.Book)}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(bookSearchResponse{
+		Data:       data,
+		Pagination: offsetPagination(r, page.Total, limit, offset),
+	})
+}
+
+// bookSearchResponse is the envelope returned by SearchBooks
+type bookSearchResponse struct {
+	Data       []bookSearchHit   `json:"data"`
+	Pagination models.Pagination `json:"pagination"`
+}
+
+// bookSearchHit is a book of the search results with its relevance
+type bookSearchHit struct {
+	models.Book
+	Score      float64           `json:"score"`
+	Highlights map[string]string `json:"highlights"`
+}
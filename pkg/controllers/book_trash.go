@@ -0,0 +1,135 @@
+package controllers
+
+import (
+	"encoding/json"
+	"go-bookstore-mysql-crud/pkg/apperrors"
+	"go-bookstore-mysql-crud/pkg/models"
+	"net/http"
+)
+
+// GetTrash godoc
+// @Summary List deleted books
+// @Description Retrieve a page of the books in the trash, most recently deleted first. Deleted books can be restored until they are purged, by hand or once the retention period has passed.
+// @Tags books
+// @Accept json
+// @Produce json
+// @Security api_key
+// @Param limit query int false "Maximum number of books to return (default 20, max 100)"
+// @Param offset query int false "Number of books to skip"
+// @Success 200 {object} models.BookListResponse "Page of deleted books"
+// @Failure 400 {object} apperrors.Problem "invalid_query - Invalid query parameters"
+// @Failure 401 {object} apperrors.Problem "unauthorized - Missing or invalid credentials"
+// @Failure 403 {object} apperrors.Problem "forbidden - Requires the read scope"
+// @Failure 500 {object} apperrors.Problem "internal_error - Internal server error"
+// @Router /books/trash [get]
+func (c *BookController) GetTrash(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	limit, err := intParam(params, "limit")
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	offset, err := intParam(params, "offset")
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	pagination := offsetPagination(r, 0, limit, offset)
+
+	page, err := c.Books.ListDeleted(r.Context(), pagination.Limit, offset)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	books := make([]*models.Book, len(page.Books))
+	for i := range page.Books {
+		books[i] = &page.Books[i]
+	}
+	if err := attachAuthorNames(r.Context(), c.Authors, books...); err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	data := page.Books
+	if data == nil {
+		data = []models.Book{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(bookListResponse{
+		Data:       data,
+		Pagination: offsetPagination(r, page.Total, limit, offset),
+	})
+}
+
+// RestoreBook godoc
+// @Summary Restore a deleted book
+// @Description Take a book out of the trash, with its authors and stock as they were when it was deleted
+// @Tags books
+// @Accept json
+// @Produce json
+// @Security api_key
+// @Param id path int true "Book ID"
+// @Success 200 {object} models.BookResponse "Restored book"
+// @Failure 400 {object} apperrors.Problem "bad_request - Invalid ID"
+// @Failure 401 {object} apperrors.Problem "unauthorized - Missing or invalid credentials"
+// @Failure 403 {object} apperrors.Problem "forbidden - Requires the write scope"
+// @Failure 404 {object} apperrors.Problem "not_found - Book not found"
+// @Failure 409 {object} apperrors.Problem "conflict - Book is not in the trash"
+// @Failure 500 {object} apperrors.Problem "internal_error - Internal server error"
+// @Router /books/{id}/restore [post]
+func (c *BookController) RestoreBook(w http.ResponseWriter, r *http.Request) {
+	ID, err := parseBookID(r)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	book, err := c.Books.Restore(r.Context(), ID)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	if err := attachAuthorNames(r.Context(), c.Authors, book); err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	w.Header().Set("ETag", bookETag(book))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(book)
+}
+
+// PurgeBook godoc
+// @Summary Purge a deleted book
+// @Description Permanently remove a book from the trash, together with its author credits and stock ledger. This cannot be undone.
+// @Tags books
+// @Accept json
+// @Produce json
+// @Security api_key
+// @Param id path int true "Book ID"
+// @Success 200 {object} models.BookResponse "Purged book"
+// @Failure 400 {object} apperrors.Problem "bad_request - Invalid ID"
+// @Failure 401 {object} apperrors.Problem "unauthorized - Missing or invalid credentials"
+// @Failure 403 {object} apperrors.Problem "forbidden - Requires the admin scope"
+// @Failure 404 {object} apperrors.Problem "not_found - Book not found"
+// @Failure 409 {object} apperrors.Problem "conflict - Book is not in the trash"
+// @Failure 500 {object} apperrors.Problem "internal_error - Internal server error"
+// @Router /books/trash/{id} [delete]
+func (c *BookController) PurgeBook(w http.ResponseWriter, r *http.Request) {
+	ID, err := parseBookID(r)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	book, err := c.Books.Purge(r.Context(), ID)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	if err := attachAuthorNames(r.Context(), c.Authors, book); err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(book)
+}
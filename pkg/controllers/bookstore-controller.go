@@ -1,37 +1,125 @@
 package controllers
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
+	"go-bookstore-mysql-crud/pkg/apperrors"
 	"go-bookstore-mysql-crud/pkg/models"
-	"go-bookstore-mysql-crud/pkg/utils"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
 )
 
-// var NewBook models.Book
+// BookController serves the book endpoints on top of a BookRepository
+type BookController struct {
+	Books   models.BookRepository
+	Authors models.AuthorRepository
+	// RequireIfMatch rejects changes to a book sent without If-Match with
+	// 428 Precondition Required; otherwise If-Match is only checked when sent
+	RequireIfMatch bool
+	// MaxBatchOperations caps the number of operations of a batch
+	MaxBatchOperations int
+	// ReadTimeout and WriteTimeout, when set, bound how long exports and
+	// imports may go without reading or writing a book. As long as they
+	// make progress they run past the server's timeouts.
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+}
+
+// NewBookController returns a BookController backed by the given repositories
+func NewBookController(books models.BookRepository, authors models.AuthorRepository) *BookController {
+	return &BookController{Books: books, Authors: authors, MaxBatchOperations: DefaultMaxBatchOperations}
+}
 
 // GetBooks godoc
-// @Summary Get all books
-// @Description Retrieve all books from the database
+// @Summary List books
+// @Description Retrieve a page of books, optionally filtered and sorted. Pages are addressed either by offset or by the opaque cursors returned in the previous response. With facets, the response also counts the books matching the filters by author, category, price range and publication year; the counts cover every page and respect every filter, including the one a facet is about.
 // @Tags books
 // @Accept json
 // @Produce json
-// @Success 200 {array} models.BookResponse "List of books"
-// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Security api_key
+// @Param limit query int false "Maximum number of books to return (default 20, max 100)"
+// @Param offset query int false "Number of books to skip; cannot be combined with cursor"
+// @Param cursor query string false "Opaque cursor from next_cursor or prev_cursor of a previous page"
+// @Param sort query string false "Comma separated sort fields (id, title, author, price, created_at); prefix with - for descending, e.g. title,-price"
+// @Param title query string false "Case-insensitive substring of the title"
+// @Param author query string false "Case-insensitive substring of the author"
+// @Param author_id query int false "ID of an author the books credit"
+// @Param category query string false "Category, ignoring case"
+// @Param year query int false "Publication year"
+// @Param min_price query string false "Minimum price in major units, inclusive, e.g. 9.99"
+// @Param max_price query string false "Maximum price in major units, inclusive, e.g. 19.99"
+// @Param currency query string false "ISO-4217 currency of min_price, max_price and the price facet (default USD)"
+// @Param facets query string false "Comma separated facets to count: author, category, price, year"
+// @Success 200 {object} models.BookListResponse "Page of books"
+// @Failure 400 {object} apperrors.Problem "invalid_query - Invalid query parameters"
+// @Failure 401 {object} apperrors.Problem "unauthorized - Missing or invalid credentials"
+// @Failure 403 {object} apperrors.Problem "forbidden - Requires the read scope"
+// @Failure 500 {object} apperrors.Problem "internal_error - Internal server error"
 // @Router /books [get]
-func GetBooks(w http.ResponseWriter, r *http.Request) {
-	db, books := models.GetAllBooks()
-	if db.Error != nil {
-		fmt.Printf("error while db operation: %v", db.Error.Error())
-		http.Error(w, db.Error.Error(), http.StatusInternalServerError)
+func (c *BookController) GetBooks(w http.ResponseWriter, r *http.Request) {
+	query, err := parseBookQuery(r)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	c.writeBookPage(w, r, query)
+}
+
+// writeBookPage lists the books matching query and writes them in the list envelope
+func (c *BookController) writeBookPage(w http.ResponseWriter, r *http.Request, query models.BookQuery) {
+	page, err := c.Books.List(r.Context(), query)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	books := make([]*models.Book, len(page.Books))
+	for i := range page.Books {
+		books[i] = &page.Books[i]
+	}
+	if err := attachAuthorNames(r.Context(), c.Authors, books...); err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	if err := c.attachFacetNames(r.Context(), page.Facets); err != nil {
+		apperrors.Write(w, r, err)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(books)
+	json.NewEncoder(w).Encode(bookListResponse{
+		Data:       page.Books,
+		Pagination: buildPagination(r, query, page),
+		Facets:     page.Facets,
+	})
+}
+
+// attachFacetNames fills in the names of the authors of an author facet
+func (c *BookController) attachFacetNames(ctx context.Context, facets *models.BookFacets) error {
+	if facets == nil || len(facets.Authors) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(facets.Authors))
+	for i, facet := range facets.Authors {
+		ids[i] = facet.AuthorID
+	}
+	found, err := c.Authors.GetMany(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for i := range facets.Authors {
+		facets.Authors[i].Name = found[facets.Authors[i].AuthorID].Name
+	}
+	return nil
+}
+
+// bookListResponse is the envelope returned by GetBooks
+type bookListResponse struct {
+	Data       []models.Book      `json:"data"`
+	Pagination models.Pagination  `json:"pagination"`
+	Facets     *models.BookFacets `json:"facets,omitempty"`
 }
 
 // GetBookById godoc
@@ -40,25 +128,75 @@ func GetBooks(w http.ResponseWriter, r *http.Request) {
 // @Tags books
 // @Accept json
 // @Produce json
+// @Security api_key
 // @Param id path int true "Book ID"
+// @Param If-None-Match header string false "ETag of a cached copy; answered with 304 if it is still current"
 // @Success 200 {object} models.BookResponse "Book details"
-// @Failure 400 {object} map[string]interface{} "Bad request - Invalid ID"
-// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Header 200 {string} ETag "Current version of the book"
+// @Success 304 "Not modified - the cached copy is current"
+// @Failure 400 {object} apperrors.Problem "bad_request - Invalid ID"
+// @Failure 401 {object} apperrors.Problem "unauthorized - Missing or invalid credentials"
+// @Failure 403 {object} apperrors.Problem "forbidden - Requires the read scope"
+// @Failure 404 {object} apperrors.Problem "not_found - Book not found"
+// @Failure 500 {object} apperrors.Problem "internal_error - Internal server error"
 // @Router /books/{id} [get]
-func GetBookById(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	bookId := vars["id"]
-	// "auto-detect the base from the string, and parse as an int64 of any size."
-	ID, err := strconv.ParseInt(bookId, 0, 0)
+func (c *BookController) GetBookById(w http.ResponseWriter, r *http.Request) {
+	ID, err := parseBookID(r)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	bookDetails, err := c.Books.Get(r.Context(), ID)
 	if err != nil {
-		fmt.Printf("error while parsing: %v", err.Error())
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apperrors.Write(w, r, err)
 		return
 	}
-	bookDetails, db := models.GetBookById(ID)
-	if db.Error != nil {
-		fmt.Printf("error while db operation: %v", db.Error.Error())
-		http.Error(w, db.Error.Error(), http.StatusInternalServerError)
+	if err := attachAuthorNames(r.Context(), c.Authors, bookDetails); err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	if notModified(w, r, bookETag(bookDetails)) {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(bookDetails)
+}
+
+// GetBookByISBN godoc
+// @Summary Get a book by ISBN
+// @Description Resolve an ISBN-10 or ISBN-13, e.g. from a scanned barcode, to a catalog entry. Hyphens and spaces are ignored.
+// @Tags books
+// @Accept json
+// @Produce json
+// @Security api_key
+// @Param isbn path string true "ISBN-10 or ISBN-13"
+// @Param If-None-Match header string false "ETag of a cached copy; answered with 304 if it is still current"
+// @Success 200 {object} models.BookResponse "Book details"
+// @Header 200 {string} ETag "Current version of the book"
+// @Success 304 "Not modified - the cached copy is current"
+// @Failure 400 {object} apperrors.Problem "bad_request - Invalid ISBN"
+// @Failure 401 {object} apperrors.Problem "unauthorized - Missing or invalid credentials"
+// @Failure 403 {object} apperrors.Problem "forbidden - Requires the read scope"
+// @Failure 404 {object} apperrors.Problem "not_found - No book has this ISBN"
+// @Failure 500 {object} apperrors.Problem "internal_error - Internal server error"
+// @Router /books/isbn/{isbn} [get]
+func (c *BookController) GetBookByISBN(w http.ResponseWriter, r *http.Request) {
+	isbn, err := models.NormalizeISBN(mux.Vars(r)["isbn"])
+	if err != nil {
+		apperrors.Write(w, r, apperrors.BadRequest(err.Error()))
+		return
+	}
+	bookDetails, err := c.Books.GetByISBN(r.Context(), isbn)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	if err := attachAuthorNames(r.Context(), c.Authors, bookDetails); err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	if notModified(w, r, bookETag(bookDetails)) {
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -72,20 +210,39 @@ func GetBookById(w http.ResponseWriter, r *http.Request) {
 // @Tags books
 // @Accept json
 // @Produce json
+// @Security api_key
 // @Param book body models.BookRequest true "Book object"
 // @Success 200 {object} models.BookResponse "Created book"
-// @Failure 400 {object} map[string]interface{} "Bad request - Invalid book data"
-// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Header 200 {string} ETag "Version of the new book"
+// @Failure 400 {object} apperrors.Problem "bad_request - Malformed JSON or unknown fields"
+// @Failure 401 {object} apperrors.Problem "unauthorized - Missing or invalid credentials"
+// @Failure 403 {object} apperrors.Problem "forbidden - Requires the write scope"
+// @Failure 409 {object} apperrors.Problem "conflict - Book already exists or ISBN is taken"
+// @Failure 413 {object} apperrors.Problem "payload_too_large - Request body too large"
+// @Failure 422 {object} apperrors.Problem "validation_failed - Validation failed or unknown author"
+// @Failure 500 {object} apperrors.Problem "internal_error - Internal server error"
 // @Router /books [post]
-func CreateBook(w http.ResponseWriter, r *http.Request) {
-	CreateBook := &models.Book{}
-	utils.ParseBody(r, CreateBook)
-	b, _, err := CreateBook.CreateBook()
+func (c *BookController) CreateBook(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeBookRequest(r)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperrors.Write(w, r, err)
+		return
+	}
+	CreateBook := req.ToBook()
+	if err := c.linkAuthors(r.Context(), req, CreateBook); err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	if err := c.Books.Create(r.Context(), CreateBook); err != nil {
+		apperrors.Write(w, r, err)
 		return
 	}
-	res, _ := json.Marshal(b)
+	if err := attachAuthorNames(r.Context(), c.Authors, CreateBook); err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	res, _ := json.Marshal(CreateBook)
+	w.Header().Set("ETag", bookETag(CreateBook))
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	w.Write(res)
@@ -93,29 +250,45 @@ func CreateBook(w http.ResponseWriter, r *http.Request) {
 
 // DeleteBook godoc
 // @Summary Delete a book
-// @Description Remove a book from the database by ID
+// @Description Move a book to the trash by ID. It can be restored until it is purged, by hand or once the retention period has passed.
 // @Tags books
 // @Accept json
 // @Produce json
+// @Security api_key
 // @Param id path int true "Book ID"
+// @Param If-Match header string false "ETag of the version being deleted; required unless APP_REQUIRE_IF_MATCH is false"
 // @Success 200 {object} models.BookResponse "Deleted book"
-// @Failure 400 {object} map[string]interface{} "Bad request - Invalid ID"
-// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Failure 400 {object} apperrors.Problem "bad_request - Invalid ID"
+// @Failure 401 {object} apperrors.Problem "unauthorized - Missing or invalid credentials"
+// @Failure 403 {object} apperrors.Problem "forbidden - Requires the admin scope"
+// @Failure 404 {object} apperrors.Problem "not_found - Book not found"
+// @Failure 412 {object} apperrors.Problem "precondition_failed - The book has changed since the ETag in If-Match"
+// @Failure 428 {object} apperrors.Problem "precondition_required - If-Match is missing"
+// @Failure 500 {object} apperrors.Problem "internal_error - Internal server error"
 // @Router /books/{id} [delete]
-func DeleteBook(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	bookId := vars["id"]
-	ID, err := strconv.ParseInt(bookId, 0, 0)
+func (c *BookController) DeleteBook(w http.ResponseWriter, r *http.Request) {
+	ID, err := parseBookID(r)
 	if err != nil {
-		fmt.Println("error while parsing")
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apperrors.Write(w, r, err)
 		return
 	}
-	_, book, err := models.DeleteBook(ID)
+	current, err := c.Books.Get(r.Context(), ID)
 	if err != nil {
-		fmt.Printf("error while parsing: %v", err.Error())
-
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperrors.Write(w, r, err)
+		return
+	}
+	version, err := c.ifMatchVersion(r, current)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	book, err := c.Books.Delete(r.Context(), ID, version)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	if err := attachAuthorNames(r.Context(), c.Authors, book); err != nil {
+		apperrors.Write(w, r, err)
 		return
 	}
 
@@ -125,39 +298,90 @@ func DeleteBook(w http.ResponseWriter, r *http.Request) {
 }
 
 // UpdateBook godoc
-// @Summary Update a book
-// @Description Update an existing book's information
+// @Summary Replace a book
+// @Description Replace all fields of an existing book; optional fields left out, such as isbn, are cleared. Use PATCH to change only some fields.
 // @Tags books
 // @Accept json
 // @Produce json
+// @Security api_key
 // @Param id path int true "Book ID"
+// @Param If-Match header string false "ETag of the version being replaced; required unless APP_REQUIRE_IF_MATCH is false"
 // @Param book body models.BookRequest true "Updated book object"
 // @Success 200 {object} models.BookResponse "Updated book"
-// @Failure 400 {object} map[string]interface{} "Bad request - Invalid ID or book data"
-// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Header 200 {string} ETag "New version of the book"
+// @Failure 400 {object} apperrors.Problem "bad_request - Invalid ID, malformed JSON or unknown fields"
+// @Failure 401 {object} apperrors.Problem "unauthorized - Missing or invalid credentials"
+// @Failure 403 {object} apperrors.Problem "forbidden - Requires the write scope"
+// @Failure 404 {object} apperrors.Problem "not_found - Book not found"
+// @Failure 409 {object} apperrors.Problem "conflict - ISBN is used by another book"
+// @Failure 412 {object} apperrors.Problem "precondition_failed - The book has changed since the ETag in If-Match"
+// @Failure 413 {object} apperrors.Problem "payload_too_large - Request body too large"
+// @Failure 422 {object} apperrors.Problem "validation_failed - Validation failed or unknown author"
+// @Failure 428 {object} apperrors.Problem "precondition_required - If-Match is missing"
+// @Failure 500 {object} apperrors.Problem "internal_error - Internal server error"
 // @Router /books/{id} [put]
-func UpdateBook(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	bookId := vars["id"]
-	ID, err := strconv.ParseInt(bookId, 0, 0)
+func (c *BookController) UpdateBook(w http.ResponseWriter, r *http.Request) {
+	ID, err := parseBookID(r)
 	if err != nil {
-		fmt.Println("error while parsing")
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apperrors.Write(w, r, err)
 		return
 	}
 
-	updatedBook := &models.Book{}
-	utils.ParseBody(r, updatedBook)
+	req, err := decodeBookRequest(r)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
 
-	_, book, err := models.UpdateBook(ID, updatedBook)
+	current, err := c.Books.Get(r.Context(), ID)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	version, err := c.ifMatchVersion(r, current)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	c.replaceBook(w, r, ID, req, version)
+}
 
+// replaceBook stores the validated req as the new state of the book at the
+// given version and writes the result
+func (c *BookController) replaceBook(w http.ResponseWriter, r *http.Request, ID uint, req *models.BookRequest, version uint) {
+	changes := req.ToBook()
+	changes.Version = version
+	if err := c.linkAuthors(r.Context(), req, changes); err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	book, err := c.Books.Update(r.Context(), ID, changes)
 	if err != nil {
-		fmt.Printf("error while db operation: %v", err.Error())
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperrors.Write(w, r, err)
+		return
+	}
+	if err := attachAuthorNames(r.Context(), c.Authors, book); err != nil {
+		apperrors.Write(w, r, err)
 		return
 	}
 
+	w.Header().Set("ETag", bookETag(book))
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(book)
 }
+
+// parseBookID reads the {id} path variable of the request
+func parseBookID(r *http.Request) (uint, error) {
+	return parseID(r, "book")
+}
+
+// parseID reads the {id} path variable of the request as the ID of a resource
+func parseID(r *http.Request, resource string) (uint, error) {
+	// "auto-detect the base from the string, and parse as an unsigned int of any size."
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 0, 0)
+	if err != nil || id == 0 {
+		return 0, apperrors.BadRequest(resource + " ID must be a positive integer")
+	}
+	return uint(id), nil
+}
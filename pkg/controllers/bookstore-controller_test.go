@@ -0,0 +1,639 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-bookstore-mysql-crud/pkg/apperrors"
+	"go-bookstore-mysql-crud/pkg/models"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// testServer serves the book endpoints from in-memory repositories
+type testServer struct {
+	t      *testing.T
+	books  *BookController
+	repos  models.Repositories
+	router *mux.Router
+}
+
+func newTestServer(t *testing.T) *testServer {
+	repos := models.NewMemoryRepositories()
+	books := NewBookController(repos.Books, repos.Authors)
+	books.RequireIfMatch = true
+
+	router := mux.NewRouter()
+	router.HandleFunc("/books", books.GetBooks).Methods("GET")
+	router.HandleFunc("/books/search", books.SearchBooks).Methods("GET")
+	router.HandleFunc("/books/export", books.ExportBooks).Methods("GET")
+	router.HandleFunc("/books/{id}", books.GetBookById).Methods("GET")
+	router.HandleFunc("/books/isbn/{isbn}", books.GetBookByISBN).Methods("GET")
+	router.HandleFunc("/books", books.CreateBook).Methods("POST")
+	router.HandleFunc("/books:batch", books.BatchBooks).Methods("POST")
+	router.HandleFunc("/books/import", books.ImportBooks).Methods("POST")
+	router.HandleFunc("/books/import/onix", books.ImportONIX).Methods("POST")
+	router.HandleFunc("/books/{id}", books.UpdateBook).Methods("PUT")
+	router.HandleFunc("/books/{id}", books.PatchBook).Methods("PATCH")
+	router.HandleFunc("/books/{id}", books.DeleteBook).Methods("DELETE")
+	return &testServer{t: t, books: books, repos: repos, router: router}
+}
+
+// do sends a request with the given body and headers, given as name and
+// value pairs
+func (s *testServer) do(method, target, body string, headers ...string) *httptest.ResponseRecorder {
+	s.t.Helper()
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		r.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, r)
+	return w
+}
+
+// create stores a book through the API and returns it
+func (s *testServer) create(body string) *models.Book {
+	s.t.Helper()
+	w := s.do("POST", "/books", body)
+	if w.Code != http.StatusOK {
+		s.t.Fatalf("POST /books = %d %s", w.Code, w.Body)
+	}
+	return decode[models.Book](s.t, w)
+}
+
+func decode[T any](t *testing.T, w *httptest.ResponseRecorder) *T {
+	t.Helper()
+	var v T
+	if err := json.Unmarshal(w.Body.Bytes(), &v); err != nil {
+		t.Fatalf("malformed response %s: %v", w.Body, err)
+	}
+	return &v
+}
+
+// expectProblem checks that the response is a problem with the given status
+// and code
+func expectProblem(t *testing.T, w *httptest.ResponseRecorder, status int, code apperrors.Code) {
+	t.Helper()
+	if w.Code != status {
+		t.Errorf("status %d, want %d: %s", w.Code, status, w.Body)
+		return
+	}
+	if problem := decode[apperrors.Problem](t, w); problem.Code != code {
+		t.Errorf("problem code %q, want %q: %s", problem.Code, code, w.Body)
+	}
+}
+
+const gatsby = `{"title": "The Great Gatsby", "author": "F. Scott Fitzgerald", "price": "$15.99", "isbn": "0-7432-7356-7", "category": "Fiction", "published_year": 1925}`
+
+func TestCreateAndGetBook(t *testing.T) {
+	s := newTestServer(t)
+	book := s.create(gatsby)
+	if book.ID != 1 || book.Version != 1 || book.Title != "The Great Gatsby" || book.Price != (models.Money{Amount: 1599, Currency: "USD"}) ||
+		*book.ISBN13 != "9780743273565" || *book.ISBN10 != "0743273567" || book.Category != "Fiction" || book.PublishedYear != 1925 {
+		t.Errorf("created %+v", book)
+	}
+	if len(book.Authors) != 1 || book.Authors[0].Name != "F. Scott Fitzgerald" || book.Authors[0].Role != models.RoleAuthor {
+		t.Errorf("created book credits %+v", book.Authors)
+	}
+
+	w := s.do("GET", "/books/1", "")
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != `"1"` {
+		t.Fatalf("GET = %d with ETag %q", w.Code, w.Header().Get("ETag"))
+	}
+	if got := decode[models.Book](t, w); got.Title != "The Great Gatsby" || got.Authors[0].Name != "F. Scott Fitzgerald" {
+		t.Errorf("GET = %+v", got)
+	}
+	if w := s.do("GET", "/books/isbn/978-0-7432-7356-5", ""); w.Code != http.StatusOK {
+		t.Errorf("GET by ISBN = %d %s", w.Code, w.Body)
+	}
+
+	// The same author record is credited again rather than duplicated
+	again := s.create(`{"title": "Tender Is the Night", "author": "F Scott  Fitzgerald", "price": {"amount": 999, "currency": "USD"}}`)
+	if again.Authors[0].AuthorID != book.Authors[0].AuthorID {
+		t.Errorf("second book credits author %d, want %d", again.Authors[0].AuthorID, book.Authors[0].AuthorID)
+	}
+	if w := s.do("GET", "/books", ""); w.Code != http.StatusOK || len(decode[bookListResponse](t, w).Data) != 2 {
+		t.Errorf("GET /books = %d %s", w.Code, w.Body)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		status int
+		code   apperrors.Code
+	}{
+		{"missing book", "GET", "/books/42", "", http.StatusNotFound, apperrors.CodeNotFound},
+		{"invalid ID", "GET", "/books/abc", "", http.StatusBadRequest, apperrors.CodeBadRequest},
+		{"invalid ISBN", "GET", "/books/isbn/123", "", http.StatusBadRequest, apperrors.CodeBadRequest},
+		{"unknown ISBN", "GET", "/books/isbn/9780141439518", "", http.StatusNotFound, apperrors.CodeNotFound},
+		{"taken ISBN", "POST", "/books", gatsby, http.StatusConflict, apperrors.CodeConflict},
+		{"no title", "POST", "/books", `{"author": "A", "price": "1.00"}`, http.StatusUnprocessableEntity, apperrors.CodeValidation},
+		{"no author", "POST", "/books", `{"title": "A", "price": "1.00"}`, http.StatusUnprocessableEntity, apperrors.CodeValidation},
+		{"bad price", "POST", "/books", `{"title": "A", "author": "B", "price": "cheap"}`, http.StatusUnprocessableEntity, apperrors.CodeValidation},
+		{"bad checksum", "POST", "/books", `{"title": "A", "author": "B", "price": "1.00", "isbn": "9780743273566"}`, http.StatusUnprocessableEntity, apperrors.CodeValidation},
+		{"unknown author ID", "POST", "/books", `{"title": "A", "authors": [{"author_id": 99}], "price": "1.00"}`, http.StatusUnprocessableEntity, apperrors.CodeValidation},
+		{"unknown field", "POST", "/books", `{"title": "A", "author": "B", "price": "1.00", "pages": 3}`, http.StatusBadRequest, apperrors.CodeBadRequest},
+		{"malformed body", "POST", "/books", `{"title": "A"`, http.StatusBadRequest, apperrors.CodeBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expectProblem(t, s.do(tt.method, tt.target, tt.body), tt.status, tt.code)
+		})
+	}
+}
+
+func TestFailedWritesCreateNoAuthors(t *testing.T) {
+	s := newTestServer(t)
+	s.create(gatsby)
+	s.create(`{"title": "Emma", "author": "Jane Austen", "price": "7.99", "isbn": "9780141439587"}`)
+
+	// Both ISBNs are taken, so neither the books nor their new authors are stored
+	w := s.do("POST", "/books", `{"title": "Copy", "author": "Zelda Fitzgerald", "price": "1.00", "isbn": "0-7432-7356-7"}`)
+	expectProblem(t, w, http.StatusConflict, apperrors.CodeConflict)
+	w = s.do("PUT", "/books/1", `{"title": "Gatsby", "author": "Maxwell Perkins", "price": "1.00", "isbn": "9780141439587"}`, "If-Match", `"1"`)
+	expectProblem(t, w, http.StatusConflict, apperrors.CodeConflict)
+
+	page, err := s.repos.Authors.List(t.Context(), models.AuthorQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 2 {
+		t.Errorf("%d authors after the failed writes, want 2: %+v", page.Total, page.Authors)
+	}
+}
+
+func TestConditionalRequests(t *testing.T) {
+	s := newTestServer(t)
+	s.create(gatsby)
+	update := `{"title": "Gatsby", "author": "F. Scott Fitzgerald", "price": "9.99"}`
+
+	tests := []struct {
+		name    string
+		method  string
+		body    string
+		headers []string
+		status  int
+		code    apperrors.Code
+	}{
+		{"If-None-Match current", "GET", "", []string{"If-None-Match", `"1"`}, http.StatusNotModified, ""},
+		{"If-None-Match weak", "GET", "", []string{"If-None-Match", `W/"1"`}, http.StatusNotModified, ""},
+		{"If-None-Match list", "GET", "", []string{"If-None-Match", `"7", "1"`}, http.StatusNotModified, ""},
+		{"If-None-Match stale", "GET", "", []string{"If-None-Match", `"0"`}, http.StatusOK, ""},
+		{"PUT without If-Match", "PUT", update, nil, http.StatusPreconditionRequired, apperrors.CodePreconditionRequired},
+		{"PUT with a stale If-Match", "PUT", update, []string{"If-Match", `"7"`}, http.StatusPreconditionFailed, apperrors.CodePreconditionFailed},
+		{"PUT with a weak If-Match", "PUT", update, []string{"If-Match", `W/"1"`}, http.StatusPreconditionFailed, apperrors.CodePreconditionFailed},
+		{"DELETE without If-Match", "DELETE", "", nil, http.StatusPreconditionRequired, apperrors.CodePreconditionRequired},
+		{"DELETE with a stale If-Match", "DELETE", "", []string{"If-Match", `"0"`}, http.StatusPreconditionFailed, apperrors.CodePreconditionFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := s.do(tt.method, "/books/1", tt.body, tt.headers...)
+			if tt.code != "" {
+				expectProblem(t, w, tt.status, tt.code)
+			} else if w.Code != tt.status {
+				t.Errorf("status %d, want %d", w.Code, tt.status)
+			}
+		})
+	}
+
+	// A matching If-Match applies the change and returns the new ETag
+	w := s.do("PUT", "/books/1", update, "If-Match", `"1"`)
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != `"2"` {
+		t.Fatalf("PUT with a current If-Match = %d with ETag %q: %s", w.Code, w.Header().Get("ETag"), w.Body)
+	}
+	// PUT replaces every field, so the ISBN left out is cleared
+	if book := decode[models.Book](t, w); book.Title != "Gatsby" || book.ISBN13 != nil || book.Category != "" {
+		t.Errorf("PUT = %+v", book)
+	}
+	if w := s.do("PUT", "/books/1", update, "If-Match", `"1"`); w.Code != http.StatusPreconditionFailed {
+		t.Errorf("PUT with the ETag it replaced = %d, want 412", w.Code)
+	}
+	if w := s.do("PUT", "/books/1", update, "If-Match", "*"); w.Code != http.StatusOK {
+		t.Errorf("PUT with If-Match * = %d, want 200", w.Code)
+	}
+
+	// Without RequireIfMatch the header is only checked when sent
+	s.books.RequireIfMatch = false
+	if w := s.do("PUT", "/books/1", update); w.Code != http.StatusOK {
+		t.Errorf("PUT without If-Match when it is optional = %d, want 200", w.Code)
+	}
+	if w := s.do("DELETE", "/books/1", "", "If-Match", `"1"`); w.Code != http.StatusPreconditionFailed {
+		t.Errorf("DELETE with a stale If-Match when it is optional = %d, want 412", w.Code)
+	}
+	if w := s.do("DELETE", "/books/1", "", "If-Match", `"4"`); w.Code != http.StatusOK {
+		t.Errorf("DELETE with a current If-Match = %d %s", w.Code, w.Body)
+	}
+	if w := s.do("GET", "/books/1", ""); w.Code != http.StatusNotFound {
+		t.Errorf("GET of a deleted book = %d, want 404", w.Code)
+	}
+}
+
+func TestETagListMatches(t *testing.T) {
+	tests := []struct {
+		values []string
+		weak   bool
+		want   bool
+	}{
+		{[]string{`"3"`}, false, true},
+		{[]string{`"2", "3"`}, false, true},
+		{[]string{`"2"`, `"3"`}, false, true},
+		{[]string{`"2"`}, false, false},
+		{[]string{"*"}, false, true},
+		{[]string{`W/"3"`}, false, false},
+		{[]string{`W/"3"`}, true, true},
+		{[]string{`"33"`}, true, false},
+		{nil, true, false},
+	}
+	for _, tt := range tests {
+		if got := etagListMatches(tt.values, `"3"`, tt.weak); got != tt.want {
+			t.Errorf("etagListMatches(%q, weak %v) = %v, want %v", tt.values, tt.weak, got, tt.want)
+		}
+	}
+}
+
+func TestPatchBook(t *testing.T) {
+	s := newTestServer(t)
+	s.create(gatsby)
+	etag := `"1"`
+	patch := func(contentType, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		w := s.do("PATCH", "/books/1", body, "Content-Type", contentType, "If-Match", etag)
+		if w.Code == http.StatusOK {
+			etag = w.Header().Get("ETag")
+		}
+		return w
+	}
+
+	w := patch("application/merge-patch+json", `{"price": {"amount": 1299}, "isbn": null}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("merge patch = %d %s", w.Code, w.Body)
+	}
+	book := decode[models.Book](t, w)
+	if book.Price.Amount != 1299 || book.Price.Currency != "USD" || book.ISBN13 != nil || book.Title != "The Great Gatsby" || book.Category != "Fiction" || book.PublishedYear != 1925 {
+		t.Errorf("merge patch = %+v; want only the price and ISBN changed", book)
+	}
+
+	w = patch("application/json-patch+json", `[{"op": "test", "path": "/title", "value": "The Great Gatsby"}, {"op": "replace", "path": "/title", "value": "Gatsby"}, {"op": "move", "from": "/category", "path": "/publisher"}]`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("JSON patch = %d %s", w.Code, w.Body)
+	}
+	if book := decode[models.Book](t, w); book.Title != "Gatsby" || book.Publisher != "Fiction" || book.Category != "" || book.Version != 3 {
+		t.Errorf("JSON patch = %+v", book)
+	}
+
+	// Changing the free-text author credits the book anew
+	w = patch("application/merge-patch+json", `{"author": "Maxwell Perkins"}`)
+	if book := decode[models.Book](t, w); w.Code != http.StatusOK || len(book.Authors) != 1 || book.Authors[0].Name != "Maxwell Perkins" {
+		t.Errorf("author patch = %d %+v", w.Code, book.Authors)
+	}
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+		code        apperrors.Code
+	}{
+		{"failed test", "application/json-patch+json", `[{"op": "test", "path": "/title", "value": "Emma"}, {"op": "replace", "path": "/title", "value": "Emma"}]`, http.StatusConflict, apperrors.CodeConflict},
+		{"missing path", "application/json-patch+json", `[{"op": "remove", "path": "/isbn"}]`, http.StatusConflict, apperrors.CodeConflict},
+		{"malformed patch", "application/json-patch+json", `{"op": "remove"}`, http.StatusBadRequest, apperrors.CodeBadRequest},
+		{"invalid result", "application/merge-patch+json", `{"title": null}`, http.StatusUnprocessableEntity, apperrors.CodeValidation},
+		{"unknown field", "application/merge-patch+json", `{"pages": 3}`, http.StatusBadRequest, apperrors.CodeBadRequest},
+		{"plain JSON", "application/json", `{"title": "Emma"}`, http.StatusUnsupportedMediaType, apperrors.CodeUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expectProblem(t, patch(tt.contentType, tt.body), tt.status, tt.code)
+		})
+	}
+	// None of the failed patches changed the book
+	if book, _ := s.repos.Books.Get(t.Context(), 1); book.Title != "Gatsby" || book.Version != 4 {
+		t.Errorf("book after failed patches: %q version %d", book.Title, book.Version)
+	}
+}
+
+func TestListBooksByCursor(t *testing.T) {
+	s := newTestServer(t)
+	for _, title := range []string{"Emma", "Dune", "Beloved", "Carrie", "Ulysses"} {
+		s.create(`{"title": "` + title + `", "author": "Anon", "price": "1.00"}`)
+	}
+
+	// The first page is addressed by offset; its next_cursor switches to
+	// keyset pagination, whose links carry cursors from then on
+	w := s.do("GET", "/books?sort=title&limit=2", "")
+	first := decode[bookListResponse](t, w)
+	if w.Code != http.StatusOK || first.Pagination.NextCursor == "" {
+		t.Fatalf("first page = %d %s", w.Code, w.Body)
+	}
+	var titles []string
+	for _, book := range first.Data {
+		titles = append(titles, book.Title)
+	}
+	target := "/books?sort=title&limit=2&cursor=" + first.Pagination.NextCursor
+	for pages := 0; target != ""; pages++ {
+		if pages > 3 {
+			t.Fatalf("pagination does not end")
+		}
+		w := s.do("GET", target, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s = %d %s", target, w.Code, w.Body)
+		}
+		page := decode[bookListResponse](t, w)
+		for _, book := range page.Data {
+			titles = append(titles, book.Title)
+		}
+		if page.Pagination.Total != 5 {
+			t.Errorf("total %d, want 5", page.Pagination.Total)
+		}
+		target = page.Pagination.Next
+		if target != "" {
+			link, _ := url.Parse(target)
+			if link.Query().Get("sort") != "title" || link.Query().Get("cursor") == "" {
+				t.Errorf("next link %q does not keep the sort order or carry a cursor", target)
+			}
+		}
+	}
+	if want := []string{"Beloved", "Carrie", "Dune", "Emma", "Ulysses"}; !reflect.DeepEqual(titles, want) {
+		t.Errorf("pages hold %v, want %v", titles, want)
+	}
+
+	cursor := first.Pagination.NextCursor
+	tests := []struct {
+		name   string
+		target string
+	}{
+		{"cursor of another sort order", "/books?sort=-price&cursor=" + cursor},
+		{"cursor and offset", "/books?sort=title&offset=2&cursor=" + cursor},
+		{"malformed cursor", "/books?cursor=abc"},
+		{"negative limit", "/books?limit=-1"},
+		{"unknown sort field", "/books?sort=isbn"},
+		{"bad price", "/books?min_price=1.999"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expectProblem(t, s.do("GET", tt.target, ""), http.StatusBadRequest, apperrors.CodeInvalidQuery)
+		})
+	}
+}
+
+func TestBatchBooks(t *testing.T) {
+	// The update of book 1 at version 1 fails once the earlier operation of
+	// the batch has moved the book on to version 2
+	const batch = `{"mode": %q, "operations": [
+		{"op": "create", "book": {"title": "Dune", "author": "Frank Herbert", "price": "9.99"}},
+		{"op": "update", "id": 1, "version": 1, "book": {"title": "Gatsby", "author": "F. Scott Fitzgerald", "price": "15.99"}},
+		{"op": "update", "id": 1, "version": 1, "book": {"title": "Gatsby Again", "author": "F. Scott Fitzgerald", "price": "15.99"}}
+	]}`
+	statuses := func(resp *BatchResponse) []int {
+		out := make([]int, len(resp.Results))
+		for i, res := range resp.Results {
+			out[i] = res.Status
+		}
+		return out
+	}
+
+	t.Run("atomic", func(t *testing.T) {
+		s := newTestServer(t)
+		s.create(gatsby)
+		w := s.do("POST", "/books:batch", strings.ReplaceAll(batch, "%q", `"atomic"`))
+		if w.Code != http.StatusMultiStatus {
+			t.Fatalf("status %d, want 207: %s", w.Code, w.Body)
+		}
+		resp := decode[BatchResponse](t, w)
+		if resp.Succeeded != 0 || resp.Failed != 3 {
+			t.Errorf("%d succeeded and %d failed, want 0 and 3", resp.Succeeded, resp.Failed)
+		}
+		if got := statuses(resp); !reflect.DeepEqual(got, []int{http.StatusFailedDependency, http.StatusFailedDependency, http.StatusPreconditionFailed}) {
+			t.Errorf("statuses %v", got)
+		}
+		if code := resp.Results[0].Error.Code; code != apperrors.CodeBatchAborted {
+			t.Errorf("first operation failed with %q, want batch_aborted", code)
+		}
+		if book, _ := s.repos.Books.Get(t.Context(), 1); book.Title != "The Great Gatsby" || book.Version != 1 {
+			t.Errorf("book 1 after a rolled back batch: %q version %d", book.Title, book.Version)
+		}
+		if page, _ := s.repos.Books.List(t.Context(), models.BookQuery{}); page.Total != 1 {
+			t.Errorf("%d books after a rolled back batch, want 1", page.Total)
+		}
+		// Frank Herbert was only credited by the rolled back create
+		if page, _ := s.repos.Authors.List(t.Context(), models.AuthorQuery{}); page.Total != 1 {
+			t.Errorf("%d authors after a rolled back batch, want 1: %+v", page.Total, page.Authors)
+		}
+	})
+
+	t.Run("best effort", func(t *testing.T) {
+		s := newTestServer(t)
+		s.create(gatsby)
+		w := s.do("POST", "/books:batch", strings.ReplaceAll(batch, "%q", `"best_effort"`))
+		if w.Code != http.StatusMultiStatus {
+			t.Fatalf("status %d, want 207: %s", w.Code, w.Body)
+		}
+		resp := decode[BatchResponse](t, w)
+		if resp.Succeeded != 2 || resp.Failed != 1 {
+			t.Errorf("%d succeeded and %d failed, want 2 and 1", resp.Succeeded, resp.Failed)
+		}
+		if got := statuses(resp); !reflect.DeepEqual(got, []int{http.StatusOK, http.StatusOK, http.StatusPreconditionFailed}) {
+			t.Errorf("statuses %v", got)
+		}
+		if created := resp.Results[0].Book; created == nil || created.ID != 2 || created.Authors[0].Name != "Frank Herbert" {
+			t.Errorf("created book %+v", created)
+		}
+		if book, _ := s.repos.Books.Get(t.Context(), 1); book.Title != "Gatsby" || book.Version != 2 {
+			t.Errorf("book 1 after the batch: %q version %d", book.Title, book.Version)
+		}
+	})
+
+	t.Run("invalid operation aborts an atomic batch", func(t *testing.T) {
+		s := newTestServer(t)
+		s.create(gatsby)
+		w := s.do("POST", "/books:batch", `{"operations": [
+			{"op": "create", "book": {"title": "Dune", "author": "Frank Herbert", "price": "9.99"}},
+			{"op": "create", "book": {"title": "", "author": "Nobody", "price": "1.00"}}
+		]}`)
+		resp := decode[BatchResponse](t, w)
+		if got := statuses(resp); w.Code != http.StatusMultiStatus || !reflect.DeepEqual(got, []int{http.StatusFailedDependency, http.StatusUnprocessableEntity}) {
+			t.Errorf("status %d with operation statuses %v", w.Code, got)
+		}
+		if problem := resp.Results[1].Error; problem == nil || len(problem.Errors) != 1 || problem.Errors[0].Field != "book.title" {
+			t.Errorf("invalid operation error %+v does not name book.title", problem)
+		}
+		if page, _ := s.repos.Books.List(t.Context(), models.BookQuery{}); page.Total != 1 {
+			t.Errorf("%d books after an aborted batch, want 1", page.Total)
+		}
+	})
+
+	t.Run("all succeed", func(t *testing.T) {
+		s := newTestServer(t)
+		s.create(gatsby)
+		w := s.do("POST", "/books:batch", `{"operations": [
+			{"op": "update", "id": 1, "version": 1, "book": {"title": "Gatsby", "author": "F. Scott Fitzgerald", "price": "15.99"}},
+			{"op": "delete", "id": 1, "version": 2}
+		]}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status %d, want 200: %s", w.Code, w.Body)
+		}
+		if resp := decode[BatchResponse](t, w); resp.Mode != "atomic" || resp.Succeeded != 2 {
+			t.Errorf("mode %q with %d succeeded", resp.Mode, resp.Succeeded)
+		}
+	})
+
+	envelopes := []struct {
+		name string
+		body string
+	}{
+		{"no operations", `{"operations": []}`},
+		{"unknown mode", `{"mode": "eventually", "operations": [{"op": "delete", "id": 1, "version": 1}]}`},
+	}
+	for _, tt := range envelopes {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t)
+			expectProblem(t, s.do("POST", "/books:batch", tt.body), http.StatusUnprocessableEntity, apperrors.CodeValidation)
+		})
+	}
+}
+
+func TestCSVExportAndImport(t *testing.T) {
+	s := newTestServer(t)
+	s.create(`{"title": "The Great Gatsby", "author": "F. Scott Fitzgerald", "price": "$15.99", "isbn": "0-7432-7356-7",
+		"publisher": "Scribner", "description": "=Jazz Age.\nA novel.", "category": "Fiction", "published_year": 1925}`)
+	// A title that already starts with an escaping quote keeps it
+	s.create(`{"title": "'=Untitled", "author": "Anonymous", "price": "$1.00"}`)
+
+	w := s.do("GET", "/books/export?sort=id", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("export = %d %s", w.Code, w.Body)
+	}
+	exported := w.Body.String()
+	header, _, _ := strings.Cut(exported, "\n")
+	if header != strings.Join(exportColumns, ",") {
+		t.Errorf("export header %q", header)
+	}
+	if !strings.Contains(exported, `9780743273565,0743273567,Scribner,"'=Jazz Age.`+"\n"+`A novel.",Fiction,1925,1,`) || !strings.Contains(exported, ",''=Untitled,Anonymous,") {
+		t.Errorf("export rows:\n%s", exported)
+	}
+
+	// The export creates the same books in another catalog
+	other := newTestServer(t)
+	w = other.do("POST", "/books/import", exported)
+	if report := decode[ImportReport](t, w); w.Code != http.StatusOK || report.Created != 2 || report.Failed != 0 {
+		t.Fatalf("import = %d %s", w.Code, w.Body)
+	}
+	book, err := other.repos.Books.GetByISBN(t.Context(), "9780743273565")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if book.Publisher != "Scribner" || book.Description != "=Jazz Age.\nA novel." || book.Category != "Fiction" || book.PublishedYear != 1925 {
+		t.Errorf("imported %+v", book)
+	}
+	untitled, err := other.repos.Books.Get(t.Context(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if untitled.Title != "'=Untitled" {
+		t.Errorf("imported title %q, want '=Untitled", untitled.Title)
+	}
+
+	// Empty cells keep the stored values, and years must be numbers
+	w = other.do("POST", "/books/import", "isbn,year,genre,price\n9780743273565,,,$9.99\n9780141439518,circa 1813,Classics,$7.99\n")
+	report := decode[ImportReport](t, w)
+	if report.Updated != 1 || report.Failed != 1 || report.Errors[0].Line != 3 || report.Columns["year"] != "published_year" || report.Columns["genre"] != "category" {
+		t.Errorf("import = %d %s", w.Code, w.Body)
+	}
+	book, err = other.repos.Books.GetByISBN(t.Context(), "9780743273565")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if book.Price.Amount != 999 || book.Publisher != "Scribner" || book.Category != "Fiction" || book.PublishedYear != 1925 {
+		t.Errorf("updated %+v", book)
+	}
+}
+
+// slowBooks hands out the books of Each one at a time, after a delay
+type slowBooks struct {
+	models.BookRepository
+	delay time.Duration
+}
+
+func (b slowBooks) Each(ctx context.Context, query models.BookQuery, fn func(*models.Book) error) error {
+	return b.BookRepository.Each(ctx, query, func(book *models.Book) error {
+		time.Sleep(b.delay)
+		return fn(book)
+	})
+}
+
+func TestExportRunsPastTheWriteTimeout(t *testing.T) {
+	s := newTestServer(t)
+	for i := range 8 {
+		s.create(fmt.Sprintf(`{"title": "Book %d", "author": "Anonymous", "price": "1.00"}`, i))
+	}
+	// The export takes twice the server's write timeout
+	books := NewBookController(slowBooks{BookRepository: s.repos.Books, delay: 50 * time.Millisecond}, s.repos.Authors)
+	books.WriteTimeout = 200 * time.Millisecond
+	server := httptest.NewUnstartedServer(http.HandlerFunc(books.ExportBooks))
+	server.Config.WriteTimeout = books.WriteTimeout
+	server.Start()
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("export failed after %d bytes: %v", len(body), err)
+	}
+	if rows := strings.Count(string(body), "\n"); rows != 9 {
+		t.Errorf("export has %d lines, want 9:\n%s", rows, body)
+	}
+}
+
+func TestONIXImportRunsPastTheReadTimeout(t *testing.T) {
+	s := newTestServer(t)
+	s.books.ReadTimeout = 200 * time.Millisecond
+	server := httptest.NewUnstartedServer(http.HandlerFunc(s.books.ImportONIX))
+	server.Config.ReadTimeout = s.books.ReadTimeout
+	server.Start()
+	defer server.Close()
+
+	// The feed takes twice the server's read timeout to upload
+	isbns := []string{"9780000000002", "9780000000019", "9780000000026", "9780000000033", "9780000000040", "9780000000057", "9780000000064", "9780000000071"}
+	body, feed := io.Pipe()
+	go func() {
+		fmt.Fprint(feed, `<ONIXMessage release="3.0"><Header><DefaultCurrencyCode>USD</DefaultCurrencyCode></Header>`)
+		for i, isbn := range isbns {
+			time.Sleep(50 * time.Millisecond)
+			fmt.Fprintf(feed, `<Product><RecordReference>%d</RecordReference><NotificationType>03</NotificationType>
+				<ProductIdentifier><ProductIDType>15</ProductIDType><IDValue>%s</IDValue></ProductIdentifier>
+				<DescriptiveDetail><TitleDetail><TitleType>01</TitleType><TitleElement><TitleElementLevel>01</TitleElementLevel><TitleText>Book %[1]d</TitleText></TitleElement></TitleDetail>
+				<Contributor><ContributorRole>A01</ContributorRole><PersonName>Anonymous</PersonName></Contributor></DescriptiveDetail>
+				<ProductSupply><SupplyDetail><Price><PriceAmount>1.00</PriceAmount></Price></SupplyDetail></ProductSupply></Product>`,
+				i, isbn)
+		}
+		fmt.Fprint(feed, `</ONIXMessage>`)
+		feed.Close()
+	}()
+	resp, err := http.Post(server.URL, "application/xml", body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var report ONIXReport
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil || report.Created != len(isbns) {
+		t.Errorf("import = %d, %+v, %v", resp.StatusCode, report, err)
+	}
+}
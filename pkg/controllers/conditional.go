@@ -0,0 +1,66 @@
+package controllers
+
+import (
+	"go-bookstore-mysql-crud/pkg/apperrors"
+	"go-bookstore-mysql-crud/pkg/models"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// bookETag returns the entity tag of the book's current version
+func bookETag(book *models.Book) string {
+	return `"` + strconv.FormatUint(uint64(book.Version), 10) + `"`
+}
+
+// notModified sets the ETag header and, when the request's If-None-Match
+// already names it, answers 304 Not Modified and reports true
+func notModified(w http.ResponseWriter, r *http.Request, etag string) bool {
+	w.Header().Set("ETag", etag)
+	if !etagListMatches(r.Header.Values("If-None-Match"), etag, true) {
+		return false
+	}
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// ifMatchVersion evaluates the If-Match header of a request changing book
+// and returns the version the change must be applied to, zero for any. A
+// missing header is rejected when c.RequireIfMatch is set.
+func (c *BookController) ifMatchVersion(r *http.Request, book *models.Book) (uint, error) {
+	values := r.Header.Values("If-Match")
+	if len(values) == 0 {
+		if c.RequireIfMatch {
+			return 0, apperrors.PreconditionRequired("send the book's ETag in an If-Match header to change it")
+		}
+		return 0, nil
+	}
+	if strings.TrimSpace(strings.Join(values, ",")) == "*" {
+		return 0, nil
+	}
+	if !etagListMatches(values, bookETag(book), false) {
+		return 0, apperrors.PreconditionFailed("If-Match does not match the current ETag " + bookETag(book))
+	}
+	return book.Version, nil
+}
+
+// etagListMatches reports whether the comma separated entity tags of an
+// If-Match or If-None-Match header include etag or "*". Weak tags only
+// match under weak comparison (RFC 9110, section 8.8.3.2).
+func etagListMatches(values []string, etag string, weak bool) bool {
+	for _, value := range values {
+		for _, tag := range strings.Split(value, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" {
+				return true
+			}
+			if weak {
+				tag = strings.TrimPrefix(tag, "W/")
+			}
+			if tag == etag {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,167 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// Health statuses reported by the probe endpoints
+const (
+	HealthOK          = "ok"
+	HealthUnavailable = "unavailable"
+)
+
+// readinessTimeout bounds each readiness check so that a hung database
+// fails the probe instead of hanging it
+const readinessTimeout = 2 * time.Second
+
+// Pinger is satisfied by *sql.DB
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// SchemaVersioner is satisfied by *migrations.Migrator
+type SchemaVersioner interface {
+	Current(ctx context.Context) (int, error)
+	Latest() int
+}
+
+// HealthController serves the liveness and readiness probes
+type HealthController struct {
+	DB         Pinger
+	Migrations SchemaVersioner
+	draining   atomic.Bool
+}
+
+// NewHealthController returns a HealthController that checks db and the
+// schema version reported by migrations
+func NewHealthController(db Pinger, migrations SchemaVersioner) *HealthController {
+	return &HealthController{DB: db, Migrations: migrations}
+}
+
+// ComponentHealth is the status of one dependency
+// @Description Status of one dependency
+type ComponentHealth struct {
+	// @Description ok or unavailable
+	// @Example "ok"
+	Status string `json:"status" example:"ok"`
+
+	// @Description Why the component is unavailable
+	// @Example "dial tcp 10.0.0.5:3306: connect: connection refused"
+	Error string `json:"error,omitempty" example:"dial tcp 10.0.0.5:3306: connect: connection refused"`
+
+	// @Description How long the check took in milliseconds
+	// @Example 3
+	LatencyMS int64 `json:"latency_ms" example:"3"`
+
+	// @Description Applied schema version, for the migrations component
+	// @Example 13
+	Version *int `json:"version,omitempty" example:"13"`
+
+	// @Description Schema version the binary expects, for the migrations component
+	// @Example 13
+	Latest *int `json:"latest,omitempty" example:"13"`
+}
+
+// HealthResponse is the body of the probe endpoints
+// @Description Overall and per-component health
+type HealthResponse struct {
+	// @Description ok when every component is ok, otherwise unavailable
+	// @Example "ok"
+	Status string `json:"status" example:"ok"`
+
+	// @Description Status of each dependency, by name
+	Components map[string]ComponentHealth `json:"components,omitempty"`
+}
+
+// SetDraining makes the readiness probe fail from now on, so that load
+// balancers stop routing to a server that is shutting down
+func (c *HealthController) SetDraining() {
+	c.draining.Store(true)
+}
+
+// Healthz godoc
+// @Summary Liveness probe
+// @Description Report that the process is running. Does not check dependencies.
+// @Tags health
+// @Produce json
+// @Success 200 {object} controllers.HealthResponse "Process is alive"
+// @Router /healthz [get]
+func (c *HealthController) Healthz(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, HealthResponse{Status: HealthOK})
+}
+
+// Readyz godoc
+// @Summary Readiness probe
+// @Description Report whether the service can serve traffic: the database answers a ping and its schema is at the version the binary expects.
+// @Tags health
+// @Produce json
+// @Success 200 {object} controllers.HealthResponse "Ready"
+// @Failure 503 {object} controllers.HealthResponse "Not ready"
+// @Router /readyz [get]
+func (c *HealthController) Readyz(w http.ResponseWriter, r *http.Request) {
+	resp := HealthResponse{
+		Status: HealthOK,
+		Components: map[string]ComponentHealth{
+			"database":   c.checkDatabase(r.Context()),
+			"migrations": c.checkMigrations(r.Context()),
+		},
+	}
+	if c.draining.Load() {
+		resp.Components["server"] = ComponentHealth{Status: HealthUnavailable, Error: "shutting down"}
+	}
+	for _, component := range resp.Components {
+		if component.Status != HealthOK {
+			resp.Status = HealthUnavailable
+		}
+	}
+	writeHealth(w, resp)
+}
+
+func (c *HealthController) checkDatabase(ctx context.Context) ComponentHealth {
+	ctx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+	start := time.Now()
+	err := c.DB.PingContext(ctx)
+	return componentHealth(start, err)
+}
+
+func (c *HealthController) checkMigrations(ctx context.Context) ComponentHealth {
+	ctx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+	start := time.Now()
+	current, err := c.Migrations.Current(ctx)
+	latest := c.Migrations.Latest()
+	health := componentHealth(start, err)
+	if err == nil {
+		health.Version, health.Latest = &current, &latest
+		if current != latest {
+			health.Status, health.Error = HealthUnavailable, "schema version does not match the binary"
+		}
+	}
+	return health
+}
+
+// componentHealth returns the status of a check that started at start and failed with err
+func componentHealth(start time.Time, err error) ComponentHealth {
+	health := ComponentHealth{Status: HealthOK, LatencyMS: time.Since(start).Milliseconds()}
+	if err != nil {
+		health.Status, health.Error = HealthUnavailable, err.Error()
+	}
+	return health
+}
+
+// writeHealth writes resp with 200 when healthy and 503 otherwise
+func writeHealth(w http.ResponseWriter, resp HealthResponse) {
+	status := http.StatusOK
+	if resp.Status != HealthOK {
+		status = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,154 @@
+package controllers
+
+import (
+	"fmt"
+	"go-bookstore-mysql-crud/pkg/models"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// parseBookQuery reads the listing parameters of GET /books
+func parseBookQuery(r *http.Request) (models.BookQuery, error) {
+	params := r.URL.Query()
+	query := models.BookQuery{
+		Title:    params.Get("title"),
+		Author:   params.Get("author"),
+		Category: strings.TrimSpace(params.Get("category")),
+	}
+
+	authorID, err := intParam(params, "author_id")
+	if err != nil {
+		return query, err
+	}
+	query.AuthorID = uint(authorID)
+	if query.Year, err = intParam(params, "year"); err != nil {
+		return query, err
+	}
+	if query.Limit, err = intParam(params, "limit"); err != nil {
+		return query, err
+	}
+	if query.Offset, err = intParam(params, "offset"); err != nil {
+		return query, err
+	}
+	currency := params.Get("currency")
+	if currency == "" {
+		currency = models.DefaultCurrency
+	}
+	if query.MinPrice, err = priceParam(params, "min_price", currency); err != nil {
+		return query, err
+	}
+	if query.MaxPrice, err = priceParam(params, "max_price", currency); err != nil {
+		return query, err
+	}
+	if query.Sort, err = models.ParseSort(params.Get("sort")); err != nil {
+		return query, err
+	}
+	if query.Facets, err = models.ParseFacets(params.Get("facets")); err != nil {
+		return query, err
+	}
+	if slices.Contains(query.Facets, models.FacetPrice) {
+		if _, err := models.NewMoney(0, currency); err != nil {
+			return query, fmt.Errorf("%w: currency %q is not supported", models.ErrInvalidQuery, currency)
+		}
+		query.FacetCurrency = strings.ToUpper(currency)
+	}
+	if cursor := params.Get("cursor"); cursor != "" {
+		if params.Has("offset") {
+			return query, fmt.Errorf("%w: cursor and offset cannot be combined", models.ErrInvalidQuery)
+		}
+		if query.Cursor, err = models.DecodeCursor(cursor); err != nil {
+			return query, err
+		}
+	}
+	return query, nil
+}
+
+// buildPagination describes page in the response envelope, linking to the
+// neighbouring pages with the same filters and sort order as r
+func buildPagination(r *http.Request, query models.BookQuery, page *models.BookPage) models.Pagination {
+	limit := query.Limit
+	if limit <= 0 {
+		limit = models.DefaultPageSize
+	}
+	limit = min(limit, models.MaxPageSize)
+
+	p := models.Pagination{Total: page.Total, Limit: limit, Offset: query.Offset}
+	if page.Next != nil {
+		p.NextCursor = page.Next.Encode()
+	}
+	if page.Prev != nil {
+		p.PrevCursor = page.Prev.Encode()
+	}
+
+	if query.Cursor != nil {
+		p.Offset = 0
+		if p.NextCursor != "" {
+			p.Next = pageLink(r, "cursor", p.NextCursor)
+		}
+		if p.PrevCursor != "" {
+			p.Prev = pageLink(r, "cursor", p.PrevCursor)
+		}
+		return p
+	}
+
+	offsetLinks(r, &p, page.Next != nil)
+	return p
+}
+
+// offsetPagination describes a page of a listing that only supports offsets
+func offsetPagination(r *http.Request, total int64, limit, offset int) models.Pagination {
+	if limit <= 0 {
+		limit = models.DefaultPageSize
+	}
+	limit = min(limit, models.MaxPageSize)
+	p := models.Pagination{Total: total, Limit: limit, Offset: offset}
+	offsetLinks(r, &p, int64(offset+limit) < total)
+	return p
+}
+
+// offsetLinks sets the next and previous links of an offset paginated page
+func offsetLinks(r *http.Request, p *models.Pagination, hasNext bool) {
+	if hasNext {
+		p.Next = pageLink(r, "offset", strconv.Itoa(p.Offset+p.Limit))
+	}
+	if p.Offset > 0 {
+		p.Prev = pageLink(r, "offset", strconv.Itoa(max(0, p.Offset-p.Limit)))
+	}
+}
+
+// pageLink returns the request URL with one pagination parameter replaced
+func pageLink(r *http.Request, key, value string) string {
+	params := r.URL.Query()
+	params.Del("cursor")
+	params.Del("offset")
+	params.Set(key, value)
+	link := url.URL{Path: r.URL.Path, RawQuery: params.Encode()}
+	return link.String()
+}
+
+func intParam(params url.Values, key string) (int, error) {
+	raw := params.Get(key)
+	if raw == "" {
+		return 0, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("%w: %s must be a non-negative integer", models.ErrInvalidQuery, key)
+	}
+	return value, nil
+}
+
+func priceParam(params url.Values, key, currency string) (*models.Money, error) {
+	raw := params.Get(key)
+	if raw == "" {
+		return nil, nil
+	}
+	value, err := models.ParseDecimal(raw, currency)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", models.ErrInvalidQuery, key, err)
+	}
+	return &value, nil
+}
@@ -0,0 +1,65 @@
+package controllers
+
+import (
+	"io"
+	"net/http"
+	"time"
+)
+
+// progress keeps the connection of a long request, such as an export or an
+// import, open past the server's read and write timeouts for as long as it
+// makes progress. The server measures its timeouts from the start of the
+// request; every advance moves them readTimeout and writeTimeout ahead of
+// now instead, so that a stalled client still times out.
+type progress struct {
+	rc           *http.ResponseController
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+	// interval is how long advance waits before moving the deadlines again
+	interval time.Duration
+	moved    time.Time
+}
+
+// progress returns the progress of the request w answers, with the timeouts
+// of the controller; zero timeouts leave the server's deadlines in place
+func (c *BookController) progress(w http.ResponseWriter) *progress {
+	p := &progress{rc: http.NewResponseController(w), readTimeout: c.ReadTimeout, writeTimeout: c.WriteTimeout}
+	for _, timeout := range []time.Duration{c.ReadTimeout, c.WriteTimeout} {
+		if timeout > 0 && (p.interval == 0 || timeout/4 < p.interval) {
+			p.interval = timeout / 4
+		}
+	}
+	return p
+}
+
+// advance records that the request made progress
+func (p *progress) advance() {
+	now := time.Now()
+	if p.interval == 0 || now.Sub(p.moved) < p.interval {
+		return
+	}
+	p.moved = now
+	// Writers that cannot move their deadlines, such as test recorders, are
+	// left with the server's
+	if p.readTimeout > 0 {
+		p.rc.SetReadDeadline(now.Add(p.readTimeout))
+	}
+	if p.writeTimeout > 0 {
+		p.rc.SetWriteDeadline(now.Add(p.writeTimeout))
+	}
+}
+
+// reader returns r, advancing the progress on every read
+func (p *progress) reader(r io.Reader) io.Reader {
+	return progressReader{r: r, p: p}
+}
+
+type progressReader struct {
+	r io.Reader
+	p *progress
+}
+
+func (pr progressReader) Read(b []byte) (int, error) {
+	pr.p.advance()
+	return pr.r.Read(b)
+}
@@ -0,0 +1,39 @@
+package controllers
+
+import (
+	"errors"
+	"go-bookstore-mysql-crud/pkg/models"
+	"go-bookstore-mysql-crud/pkg/utils"
+	"go-bookstore-mysql-crud/pkg/validation"
+	"net/http"
+	"strings"
+)
+
+// decodeBookRequest strictly decodes and validates a BookRequest body
+func decodeBookRequest(r *http.Request) (*models.BookRequest, error) {
+	var req models.BookRequest
+	return checkBookRequest(&req, utils.ParseBody(r, &req))
+}
+
+// decodeBookJSON strictly decodes and validates a BookRequest document, such
+// as a patched book or an item of a batch
+func decodeBookJSON(data []byte) (*models.BookRequest, error) {
+	var req models.BookRequest
+	return checkBookRequest(&req, utils.DecodeJSON(data, &req))
+}
+
+// checkBookRequest returns the error of decoding req, if any, or validates it
+func checkBookRequest(req *models.BookRequest, err error) (*models.BookRequest, error) {
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidMoney) {
+			// The JSON was well formed but the price string could not be parsed
+			message := strings.TrimPrefix(err.Error(), utils.ErrInvalidBody.Error()+": ")
+			return nil, validation.Errors{{Field: "price", Code: "invalid", Message: "price is invalid: " + message}}
+		}
+		return nil, err
+	}
+	if err := validation.Struct(req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
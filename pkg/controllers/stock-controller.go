@@ -0,0 +1,215 @@
+package controllers
+
+import (
+	"encoding/json"
+	"go-bookstore-mysql-crud/pkg/apperrors"
+	"go-bookstore-mysql-crud/pkg/models"
+	"go-bookstore-mysql-crud/pkg/utils"
+	"go-bookstore-mysql-crud/pkg/validation"
+	"net/http"
+)
+
+// StockController serves the inventory endpoints of a book
+type StockController struct {
+	Stock models.StockRepository
+	Books models.BookRepository
+}
+
+// NewStockController returns a StockController backed by the given
+// repositories; books is used to reject stock operations on unknown books
+func NewStockController(stock models.StockRepository, books models.BookRepository) *StockController {
+	return &StockController{Stock: stock, Books: books}
+}
+
+// GetStock godoc
+// @Summary Get a book's stock level
+// @Description Retrieve the on-hand, reserved and available quantity of a book and whether it needs reordering
+// @Tags stock
+// @Accept json
+// @Produce json
+// @Security api_key
+// @Param id path int true "Book ID"
+// @Success 200 {object} models.StockLevel "Stock level"
+// @Failure 400 {object} apperrors.Problem "bad_request - Invalid ID"
+// @Failure 401 {object} apperrors.Problem "unauthorized - Missing or invalid credentials"
+// @Failure 403 {object} apperrors.Problem "forbidden - Requires the read scope"
+// @Failure 404 {object} apperrors.Problem "not_found - Book not found"
+// @Failure 500 {object} apperrors.Problem "internal_error - Internal server error"
+// @Router /books/{id}/stock [get]
+func (c *StockController) GetStock(w http.ResponseWriter, r *http.Request) {
+	ID, err := c.bookID(r)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	level, err := c.Stock.Level(r.Context(), ID)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(level)
+}
+
+// UpdateStockSettings godoc
+// @Summary Update a book's stock settings
+// @Description Change the reorder threshold of a book. Quantities can only be changed by posting movements.
+// @Tags stock
+// @Accept json
+// @Produce json
+// @Security api_key
+// @Param id path int true "Book ID"
+// @Param settings body models.StockSettingsRequest true "Stock settings"
+// @Success 200 {object} models.StockLevel "Updated stock level"
+// @Failure 400 {object} apperrors.Problem "bad_request - Invalid ID, malformed JSON or unknown fields"
+// @Failure 401 {object} apperrors.Problem "unauthorized - Missing or invalid credentials"
+// @Failure 403 {object} apperrors.Problem "forbidden - Requires the write scope"
+// @Failure 404 {object} apperrors.Problem "not_found - Book not found"
+// @Failure 422 {object} apperrors.Problem "validation_failed - Validation failed"
+// @Failure 500 {object} apperrors.Problem "internal_error - Internal server error"
+// @Router /books/{id}/stock [put]
+func (c *StockController) UpdateStockSettings(w http.ResponseWriter, r *http.Request) {
+	ID, err := c.bookID(r)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	var req models.StockSettingsRequest
+	if err := utils.ParseBody(r, &req); err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	if err := validation.Struct(&req); err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	level, err := c.Stock.SetReorderThreshold(r.Context(), ID, req.ReorderThreshold)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(level)
+}
+
+// GetStockMovements godoc
+// @Summary List a book's stock movements
+// @Description Retrieve a page of the book's stock ledger, newest first
+// @Tags stock
+// @Accept json
+// @Produce json
+// @Security api_key
+// @Param id path int true "Book ID"
+// @Param limit query int false "Maximum number of movements to return (default 20, max 100)"
+// @Param offset query int false "Number of movements to skip"
+// @Success 200 {object} models.StockMovementListResponse "Page of movements"
+// @Failure 400 {object} apperrors.Problem "bad_request - Invalid ID or query parameters"
+// @Failure 401 {object} apperrors.Problem "unauthorized - Missing or invalid credentials"
+// @Failure 403 {object} apperrors.Problem "forbidden - Requires the read scope"
+// @Failure 404 {object} apperrors.Problem "not_found - Book not found"
+// @Failure 500 {object} apperrors.Problem "internal_error - Internal server error"
+// @Router /books/{id}/stock/movements [get]
+func (c *StockController) GetStockMovements(w http.ResponseWriter, r *http.Request) {
+	ID, err := c.bookID(r)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	params := r.URL.Query()
+	limit, err := intParam(params, "limit")
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	offset, err := intParam(params, "offset")
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	pagination := offsetPagination(r, 0, limit, offset)
+
+	page, err := c.Stock.Movements(r.Context(), ID, pagination.Limit, offset)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	movements := page.Movements
+	if movements == nil {
+		movements = []models.StockMovement{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.StockMovementListResponse{
+		Data:       movements,
+		Pagination: offsetPagination(r, page.Total, limit, offset),
+	})
+}
+
+// CreateStockMovement godoc
+// @Summary Record a stock movement
+// @Description Append a movement to the book's stock ledger and apply it to the stock level atomically. Sales and reservations are rejected when fewer copies are available, so concurrent sales can never drive stock negative.
+// @Tags stock
+// @Accept json
+// @Produce json
+// @Security api_key
+// @Param id path int true "Book ID"
+// @Param movement body models.StockMovementRequest true "Stock movement"
+// @Success 200 {object} models.StockMovementResponse "Recorded movement and resulting stock level"
+// @Failure 400 {object} apperrors.Problem "bad_request - Invalid ID, malformed JSON or unknown fields"
+// @Failure 401 {object} apperrors.Problem "unauthorized - Missing or invalid credentials"
+// @Failure 403 {object} apperrors.Problem "forbidden - Requires the write scope"
+// @Failure 404 {object} apperrors.Problem "not_found - Book not found"
+// @Failure 409 {object} apperrors.Problem "insufficient_stock - Not enough stock for the movement"
+// @Failure 422 {object} apperrors.Problem "validation_failed - Validation failed"
+// @Failure 500 {object} apperrors.Problem "internal_error - Internal server error"
+// @Router /books/{id}/stock/movements [post]
+func (c *StockController) CreateStockMovement(w http.ResponseWriter, r *http.Request) {
+	ID, err := c.bookID(r)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	req, err := decodeStockMovementRequest(r)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	movement := req.ToMovement(ID)
+	level, err := c.Stock.ApplyMovement(r.Context(), movement)
+	if err != nil {
+		apperrors.Write(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.StockMovementResponse{Movement: *movement, Level: *level})
+}
+
+// bookID parses the book ID from the path and checks that the book exists
+func (c *StockController) bookID(r *http.Request) (uint, error) {
+	ID, err := parseBookID(r)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := c.Books.Get(r.Context(), ID); err != nil {
+		return 0, err
+	}
+	return ID, nil
+}
+
+// decodeStockMovementRequest strictly decodes and validates a StockMovementRequest body
+func decodeStockMovementRequest(r *http.Request) (*models.StockMovementRequest, error) {
+	var req models.StockMovementRequest
+	if err := utils.ParseBody(r, &req); err != nil {
+		return nil, err
+	}
+	if err := validation.Struct(&req); err != nil {
+		return nil, err
+	}
+	if req.Type != models.MovementAdjust && req.Quantity < 0 {
+		return nil, validation.Errors{{Field: "quantity", Code: "min", Message: "quantity must be positive for " + req.Type}}
+	}
+	return &req, nil
+}
@@ -0,0 +1,100 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// GormLogger is a GORM logger.Interface writing through slog. Statements are
+// logged at debug level, slow ones as warnings and failed ones as errors;
+// since GORM passes the statement context along, each line carries the ID
+// of the request that ran the query.
+type GormLogger struct {
+	// Logger receives the records; nil means slog.Default()
+	Logger *slog.Logger
+	// SlowThreshold is the duration above which statements are logged as
+	// warnings; zero disables slow query warnings
+	SlowThreshold time.Duration
+	// ParameterizedQueries leaves the bound values out of logged statements
+	ParameterizedQueries bool
+
+	level gormlogger.LogLevel
+}
+
+// NewGormLogger returns a GormLogger writing to the default slog logger
+func NewGormLogger(slowThreshold time.Duration, parameterizedQueries bool) *GormLogger {
+	return &GormLogger{
+		SlowThreshold:        slowThreshold,
+		ParameterizedQueries: parameterizedQueries,
+		level:                gormlogger.Info,
+	}
+}
+
+// LogMode returns a copy of the logger limited to level; slog still applies
+// its own level on top
+func (l *GormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	clone := *l
+	clone.level = level
+	return &clone
+}
+
+func (l *GormLogger) Info(ctx context.Context, msg string, data ...any) {
+	if l.level >= gormlogger.Info {
+		l.logger().InfoContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *GormLogger) Warn(ctx context.Context, msg string, data ...any) {
+	if l.level >= gormlogger.Warn {
+		l.logger().WarnContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *GormLogger) Error(ctx context.Context, msg string, data ...any) {
+	if l.level >= gormlogger.Error {
+		l.logger().ErrorContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+// Trace logs a statement once it has run. A missing record is an expected
+// outcome rather than a failure, so it is logged like any other statement.
+func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if l.level <= gormlogger.Silent {
+		return
+	}
+	elapsed := time.Since(begin)
+	logger := l.logger()
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && l.level >= gormlogger.Error:
+		sql, rows := fc()
+		logger.ErrorContext(ctx, "query failed", "sql", sql, "rows", rows, "duration", elapsed, "error", err)
+	case l.SlowThreshold > 0 && elapsed > l.SlowThreshold && l.level >= gormlogger.Warn:
+		sql, rows := fc()
+		logger.WarnContext(ctx, "slow query", "sql", sql, "rows", rows, "duration", elapsed, "threshold", l.SlowThreshold)
+	case l.level >= gormlogger.Info && logger.Enabled(ctx, slog.LevelDebug):
+		sql, rows := fc()
+		logger.DebugContext(ctx, "query", "sql", sql, "rows", rows, "duration", elapsed)
+	}
+}
+
+// ParamsFilter implements gorm's logger.ParamsFilter, dropping the bound
+// values when ParameterizedQueries is set
+func (l *GormLogger) ParamsFilter(ctx context.Context, sql string, params ...any) (string, []any) {
+	if l.ParameterizedQueries {
+		return sql, nil
+	}
+	return sql, params
+}
+
+func (l *GormLogger) logger() *slog.Logger {
+	if l.Logger != nil {
+		return l.Logger
+	}
+	return slog.Default()
+}
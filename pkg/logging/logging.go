@@ -0,0 +1,65 @@
+// Package logging configures the log/slog logger of the application and
+// carries the request ID of each HTTP request into every line logged while
+// serving it, including the SQL statements GORM runs.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Output formats selectable with LOG_FORMAT
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// New returns a logger writing records of level and above to w, as JSON or
+// as logfmt style text. Records logged with a context are annotated with the
+// request ID and trace of that context.
+func New(w io.Writer, level slog.Level, format string) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	if format == FormatJSON {
+		handler = slog.NewJSONHandler(w, opts)
+	} else {
+		handler = slog.NewTextHandler(w, opts)
+	}
+	return slog.New(contextHandler{handler})
+}
+
+// Setup installs a logger writing to stderr as the slog default, which the
+// standard log package then writes through as well
+func Setup(level slog.Level, format string) *slog.Logger {
+	logger := New(os.Stderr, level, format)
+	slog.SetDefault(logger)
+	return logger
+}
+
+// contextHandler adds the request_id, trace_id and span_id found in the
+// context of a record to its attributes
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestIDFrom(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,172 @@
+package marc
+
+import (
+	"fmt"
+	"go-bookstore-mysql-crud/pkg/models"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// bookLeader is the leader of book records: a new or corrected record of
+// language material, monograph, in Unicode, at minimal level, without
+// ISBD punctuation
+const bookLeader = "     nam a22     7  4500"
+
+// maxSummaryBytes is the longest summary field written; longer
+// descriptions are split over several fields
+const maxSummaryBytes = 8000
+
+// relators maps contributor roles to their MARC relator code
+var relators = map[string]string{
+	models.RoleAuthor:     "aut",
+	models.RoleEditor:     "edt",
+	models.RoleTranslator: "trl",
+}
+
+// FromBook renders a book as a MARC 21 bibliographic record with its ID
+// (001), ISBNs and price (020, 365), main author (100), title and
+// statement of responsibility (245), publisher and year (264), description
+// (520), category (653) and other contributors (700). The book's author links must carry their
+// names; names are given in direct order, as they are stored.
+func FromBook(book *models.Book) *Record {
+	leader := []byte(bookLeader)
+	if book.Version > 1 {
+		leader[5] = 'c'
+	}
+	r := &Record{Leader: string(leader)}
+	r.control("001", strconv.FormatUint(uint64(book.ID), 10))
+	r.control("005", book.UpdatedAt.UTC().Format("20060102150405")+".0")
+	// Date entered, publication date, unknown place, uncoded book
+	// characteristics, undetermined language
+	dates := "nuuuuuuuu"
+	if book.PublishedYear != 0 {
+		dates = fmt.Sprintf("s%04d    ", book.PublishedYear)
+	}
+	r.control("008", book.CreatedAt.UTC().Format("060102")+dates+"xx "+strings.Repeat("|", 17)+"und d")
+
+	if book.ISBN13 != nil {
+		isbn := []Subfield{{'a', *book.ISBN13}}
+		if !book.Price.IsZero() {
+			isbn = append(isbn, Subfield{'c', book.Price.String()})
+		}
+		r.data("020", ' ', ' ', isbn...)
+	}
+	if book.ISBN10 != nil {
+		r.data("020", ' ', ' ', Subfield{'a', *book.ISBN10})
+	}
+
+	mainEntry := -1
+	for i, link := range book.Authors {
+		if link.Role == models.RoleAuthor {
+			mainEntry = i
+			break
+		}
+	}
+	if mainEntry >= 0 {
+		r.data("100", '0', ' ', contributor(book.Authors[mainEntry])...)
+	}
+
+	titleInd := byte('0')
+	if mainEntry >= 0 {
+		titleInd = '1'
+	}
+	title := []Subfield{{'a', clean(book.Title)}}
+	if book.Author != "" {
+		title = append(title, Subfield{'c', clean(book.Author)})
+	}
+	r.data("245", titleInd, nonFiling(book.Title), title...)
+
+	var publication []Subfield
+	if book.Publisher != "" {
+		publication = append(publication, Subfield{'b', clean(book.Publisher)})
+	}
+	if book.PublishedYear != 0 {
+		publication = append(publication, Subfield{'c', strconv.Itoa(book.PublishedYear)})
+	}
+	if len(publication) > 0 {
+		r.data("264", ' ', '1', publication...)
+	}
+	if !book.Price.IsZero() {
+		r.data("365", ' ', ' ', Subfield{'b', book.Price.Decimal()}, Subfield{'c', book.Price.Currency})
+	}
+	for _, paragraph := range strings.Split(book.Description, "\n") {
+		for _, part := range splitBytes(clean(paragraph), maxSummaryBytes) {
+			r.data("520", ' ', ' ', Subfield{'a', part})
+		}
+	}
+	if book.Category != "" {
+		r.data("653", ' ', ' ', Subfield{'a', clean(book.Category)})
+	}
+	for i, link := range book.Authors {
+		if i != mainEntry {
+			r.data("700", '0', ' ', contributor(link)...)
+		}
+	}
+	return r
+}
+
+// control appends a control field
+func (r *Record) control(tag, value string) {
+	r.Fields = append(r.Fields, Field{Tag: tag, Value: value})
+}
+
+// data appends a data field
+func (r *Record) data(tag string, ind1, ind2 byte, subfields ...Subfield) {
+	r.Fields = append(r.Fields, Field{Tag: tag, Indicators: [2]byte{ind1, ind2}, Subfields: subfields})
+}
+
+// contributor returns the name, relator term and relator code of a
+// personal name field
+func contributor(link models.BookAuthor) []Subfield {
+	subfields := []Subfield{{'a', clean(link.Name)}, {'e', link.Role}}
+	if code, ok := relators[link.Role]; ok {
+		subfields = append(subfields, Subfield{'4', code})
+	}
+	return subfields
+}
+
+// nonFiling returns the second indicator of a title field: the number of
+// characters of a leading English article that sorting skips
+func nonFiling(title string) byte {
+	lower := strings.ToLower(title)
+	for _, article := range []string{"the ", "an ", "a "} {
+		if strings.HasPrefix(lower, article) {
+			return byte('0' + len(article))
+		}
+	}
+	return '0'
+}
+
+// clean removes control characters, which include the delimiters of
+// binary records, from s
+func clean(s string) string {
+	return strings.TrimSpace(strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, s))
+}
+
+// splitBytes splits s into parts of at most limit bytes, between runes and
+// preferably at spaces
+func splitBytes(s string, limit int) []string {
+	var parts []string
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		if space := strings.LastIndexByte(s[:cut], ' '); space > limit/2 {
+			cut = space
+		}
+		parts = append(parts, strings.TrimSpace(s[:cut]))
+		s = strings.TrimSpace(s[cut:])
+	}
+	if s != "" {
+		parts = append(parts, s)
+	}
+	return parts
+}
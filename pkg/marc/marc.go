@@ -0,0 +1,244 @@
+// Package marc reads and writes MARC 21 bibliographic records, the format
+// library systems exchange catalog data in, both as binary ISO 2709
+// records and as MARCXML.
+package marc
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidRecord is returned for records that cannot be encoded or decoded
+var ErrInvalidRecord = errors.New("invalid MARC record")
+
+// Delimiters of binary records
+const (
+	subfieldDelimiter = 0x1F
+	fieldTerminator   = 0x1E
+	recordTerminator  = 0x1D
+)
+
+// Sizes of the parts of binary records
+const (
+	leaderLength   = 24
+	directoryEntry = 12
+	maxFieldBytes  = 9999
+	maxRecordBytes = 99999
+)
+
+// Record is a MARC record: a leader and its fields in tag order
+type Record struct {
+	// Leader is the 24 character leader; the record length and base
+	// address of data in it are computed when the record is written
+	Leader string
+	Fields []Field
+}
+
+// Field is a variable field. Control fields (tags 001 to 009) have a Value;
+// data fields have indicators and subfields.
+type Field struct {
+	Tag        string
+	Value      string
+	Indicators [2]byte
+	Subfields  []Subfield
+}
+
+// Subfield is a coded part of a data field
+type Subfield struct {
+	Code  byte
+	Value string
+}
+
+// IsControl reports whether the field is a control field
+func (f Field) IsControl() bool {
+	return f.Tag < "010"
+}
+
+// Subfield returns the value of the first subfield with the given code, or ""
+func (f Field) Subfield(code byte) string {
+	for _, sub := range f.Subfields {
+		if sub.Code == code {
+			return sub.Value
+		}
+	}
+	return ""
+}
+
+// FieldsByTag returns the fields of the record with the given tag, in order
+func (r *Record) FieldsByTag(tag string) []Field {
+	var fields []Field
+	for _, f := range r.Fields {
+		if f.Tag == tag {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// encode renders the record in ISO 2709 and returns the leader it computed
+func (r *Record) encode() ([]byte, string, error) {
+	if len(r.Leader) != leaderLength {
+		return nil, "", fmt.Errorf("%w: the leader must be %d characters long", ErrInvalidRecord, leaderLength)
+	}
+	var directory, data bytes.Buffer
+	for _, f := range r.Fields {
+		if len(f.Tag) != 3 {
+			return nil, "", fmt.Errorf("%w: tag %q is not 3 characters long", ErrInvalidRecord, f.Tag)
+		}
+		start := data.Len()
+		if f.IsControl() {
+			if hasDelimiter(f.Value) {
+				return nil, "", fmt.Errorf("%w: field %s contains a delimiter", ErrInvalidRecord, f.Tag)
+			}
+			data.WriteString(f.Value)
+		} else {
+			data.Write(f.Indicators[:])
+			for _, sub := range f.Subfields {
+				if hasDelimiter(string(sub.Code)) || hasDelimiter(sub.Value) {
+					return nil, "", fmt.Errorf("%w: field %s contains a delimiter", ErrInvalidRecord, f.Tag)
+				}
+				data.WriteByte(subfieldDelimiter)
+				data.WriteByte(sub.Code)
+				data.WriteString(sub.Value)
+			}
+		}
+		data.WriteByte(fieldTerminator)
+		length := data.Len() - start
+		if length > maxFieldBytes {
+			return nil, "", fmt.Errorf("%w: field %s is longer than %d bytes", ErrInvalidRecord, f.Tag, maxFieldBytes)
+		}
+		fmt.Fprintf(&directory, "%s%04d%05d", f.Tag, length, start)
+	}
+	directory.WriteByte(fieldTerminator)
+	data.WriteByte(recordTerminator)
+
+	base := leaderLength + directory.Len()
+	length := base + data.Len()
+	if length > maxRecordBytes {
+		return nil, "", fmt.Errorf("%w: the record is longer than %d bytes", ErrInvalidRecord, maxRecordBytes)
+	}
+	leader := fmt.Sprintf("%05d%s%05d%s", length, r.Leader[5:12], base, r.Leader[17:])
+	out := make([]byte, 0, length)
+	out = append(out, leader...)
+	out = append(out, directory.Bytes()...)
+	return append(out, data.Bytes()...), leader, nil
+}
+
+// hasDelimiter reports whether s contains a byte that delimits the parts
+// of binary records
+func hasDelimiter(s string) bool {
+	return strings.ContainsAny(s, "\x1d\x1e\x1f")
+}
+
+// MarshalBinary renders the record in ISO 2709
+func (r *Record) MarshalBinary() ([]byte, error) {
+	data, _, err := r.encode()
+	return data, err
+}
+
+// UnmarshalBinary parses one ISO 2709 record
+func (r *Record) UnmarshalBinary(data []byte) error {
+	if len(data) < leaderLength+1 || data[len(data)-1] != recordTerminator {
+		return fmt.Errorf("%w: the record is truncated", ErrInvalidRecord)
+	}
+	leader := string(data[:leaderLength])
+	base, err := strconv.Atoi(leader[12:17])
+	if err != nil || base <= leaderLength || base > len(data) || data[base-1] != fieldTerminator {
+		return fmt.Errorf("%w: bad base address of data %q", ErrInvalidRecord, leader[12:17])
+	}
+	directory := data[leaderLength : base-1]
+	if len(directory)%directoryEntry != 0 {
+		return fmt.Errorf("%w: the directory is malformed", ErrInvalidRecord)
+	}
+	fields := data[base : len(data)-1]
+
+	record := Record{Leader: leader}
+	for i := 0; i < len(directory); i += directoryEntry {
+		entry := string(directory[i : i+directoryEntry])
+		tag := entry[:3]
+		length, lenErr := strconv.Atoi(entry[3:7])
+		start, startErr := strconv.Atoi(entry[7:])
+		if lenErr != nil || startErr != nil || length < 1 || start+length > len(fields) || fields[start+length-1] != fieldTerminator {
+			return fmt.Errorf("%w: bad directory entry %q", ErrInvalidRecord, entry)
+		}
+		content := string(fields[start : start+length-1])
+
+		f := Field{Tag: tag}
+		if f.IsControl() {
+			f.Value = content
+		} else {
+			if len(content) < 2 {
+				return fmt.Errorf("%w: field %s has no indicators", ErrInvalidRecord, tag)
+			}
+			f.Indicators = [2]byte{content[0], content[1]}
+			for _, sub := range strings.Split(content[2:], string(rune(subfieldDelimiter)))[1:] {
+				if sub == "" {
+					return fmt.Errorf("%w: field %s has a subfield without a code", ErrInvalidRecord, tag)
+				}
+				f.Subfields = append(f.Subfields, Subfield{Code: sub[0], Value: sub[1:]})
+			}
+		}
+		record.Fields = append(record.Fields, f)
+	}
+	*r = record
+	return nil
+}
+
+// Writer writes binary MARC records
+type Writer struct {
+	w io.Writer
+}
+
+// NewWriter returns a Writer writing to w
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// Write writes one record
+func (w *Writer) Write(r *Record) error {
+	data, err := r.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	_, err = w.w.Write(data)
+	return err
+}
+
+// Reader reads binary MARC records
+type Reader struct {
+	r *bufio.Reader
+}
+
+// NewReader returns a Reader reading the records in r
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// Next returns the next record, or io.EOF after the last one
+func (r *Reader) Next() (*Record, error) {
+	prefix, err := r.r.Peek(5)
+	if errors.Is(err, io.EOF) && len(prefix) == 0 {
+		return nil, io.EOF
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: the record is truncated", ErrInvalidRecord)
+	}
+	length, err := strconv.Atoi(string(prefix))
+	if err != nil || length <= leaderLength {
+		return nil, fmt.Errorf("%w: bad record length %q", ErrInvalidRecord, prefix)
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r.r, data); err != nil {
+		return nil, fmt.Errorf("%w: the record is truncated", ErrInvalidRecord)
+	}
+	record := &Record{}
+	if err := record.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
@@ -0,0 +1,260 @@
+package marc
+
+import (
+	"bytes"
+	"errors"
+	"go-bookstore-mysql-crud/pkg/models"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func ptr(s string) *string { return &s }
+
+// render writes a field as its indicators followed by $-prefixed
+// subfields, or as its value for control fields
+func render(f Field) string {
+	if f.IsControl() {
+		return f.Value
+	}
+	var b strings.Builder
+	b.Write(f.Indicators[:])
+	for _, sub := range f.Subfields {
+		b.WriteByte('$')
+		b.WriteByte(sub.Code)
+		b.WriteString(sub.Value)
+	}
+	return b.String()
+}
+
+// codecs write a record and read it back in each format
+var codecs = []struct {
+	name      string
+	roundTrip func(t *testing.T, r *Record) *Record
+}{
+	{"binary", func(t *testing.T, r *Record) *Record {
+		var buf bytes.Buffer
+		if err := NewWriter(&buf).Write(r); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+		return readAll(t, NewReader(&buf))
+	}},
+	{"xml", func(t *testing.T, r *Record) *Record {
+		var buf bytes.Buffer
+		w := NewXMLWriter(&buf)
+		if err := w.Write(r); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("Close: %v", err)
+		}
+		return readAll(t, NewXMLReader(&buf))
+	}},
+}
+
+// readAll reads the single record a reader is expected to hold
+func readAll(t *testing.T, r interface{ Next() (*Record, error) }) *Record {
+	t.Helper()
+	record, err := r.Next()
+	if err != nil {
+		t.Fatalf("Next: %v", err)
+	}
+	if _, err := r.Next(); !errors.Is(err, io.EOF) {
+		t.Fatalf("Next after the last record = %v, want io.EOF", err)
+	}
+	return record
+}
+
+func TestFromBookRoundTrip(t *testing.T) {
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		book   models.Book
+		status byte
+		want   map[string][]string
+	}{
+		{
+			name: "full",
+			book: models.Book{
+				ID:            42,
+				Title:         "The Great Gatsby",
+				Author:        "F. Scott Fitzgerald; Maxwell Perkins",
+				Price:         models.Money{Amount: 1599, Currency: "USD"},
+				Publisher:     "Charles Scribner's Sons",
+				Description:   "A novel of the Jazz Age.\nSet on Long Island.",
+				Category:      "Fiction",
+				PublishedYear: 1925,
+				ISBN13:        ptr("9780743273565"),
+				ISBN10:        ptr("0743273567"),
+				Authors: []models.BookAuthor{
+					{AuthorID: 1, Name: "F. Scott Fitzgerald", Role: models.RoleAuthor},
+					{AuthorID: 2, Name: "Maxwell Perkins", Role: models.RoleEditor, Position: 1},
+				},
+				Version: 3,
+			},
+			status: 'c',
+			want: map[string][]string{
+				"001": {"42"},
+				"020": {"  $a9780743273565$c15.99 USD", "  $a0743273567"},
+				"100": {"0 $aF. Scott Fitzgerald$eauthor$4aut"},
+				"245": {"14$aThe Great Gatsby$cF. Scott Fitzgerald; Maxwell Perkins"},
+				"264": {" 1$bCharles Scribner's Sons$c1925"},
+				"365": {"  $b15.99$cUSD"},
+				"520": {"  $aA novel of the Jazz Age.", "  $aSet on Long Island."},
+				"653": {"  $aFiction"},
+				"700": {"0 $aMaxwell Perkins$eeditor$4edt"},
+			},
+		},
+		{
+			name:   "minimal",
+			book:   models.Book{ID: 7, Title: "Untitled", Version: 1},
+			status: 'n',
+			want: map[string][]string{
+				"001": {"7"},
+				"245": {"00$aUntitled"},
+			},
+		},
+		{
+			name: "editor only and control characters",
+			book: models.Book{
+				ID:      8,
+				Title:   "An Anthology\x1f",
+				Authors: []models.BookAuthor{{AuthorID: 3, Name: "Ed\x1eitor", Role: models.RoleEditor}},
+				Version: 1,
+			},
+			status: 'n',
+			want: map[string][]string{
+				"001": {"8"},
+				"245": {"03$aAn Anthology"},
+				"700": {"0 $aEditor$eeditor$4edt"},
+			},
+		},
+		{
+			name: "long description split at a space",
+			book: models.Book{
+				ID:          9,
+				Title:       "Long",
+				Description: strings.Repeat("a", 5000) + " " + strings.Repeat("b", 5000),
+				Version:     1,
+			},
+			status: 'n',
+			want: map[string][]string{
+				"001": {"9"},
+				"245": {"00$aLong"},
+				"520": {"  $a" + strings.Repeat("a", 5000), "  $a" + strings.Repeat("b", 5000)},
+			},
+		},
+		{
+			name: "long description split between runes",
+			book: models.Book{
+				ID:          10,
+				Title:       "Euros",
+				Description: strings.Repeat("€", 3000),
+				Version:     1,
+			},
+			status: 'n',
+			want: map[string][]string{
+				"001": {"10"},
+				"245": {"00$aEuros"},
+				"520": {"  $a" + strings.Repeat("€", 2666), "  $a" + strings.Repeat("€", 334)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt.book.CreatedAt, tt.book.UpdatedAt = created, created
+		record := FromBook(&tt.book)
+		binary, err := record.MarshalBinary()
+		if err != nil {
+			t.Fatalf("%s: MarshalBinary: %v", tt.name, err)
+		}
+		for _, codec := range codecs {
+			t.Run(tt.name+"/"+codec.name, func(t *testing.T) {
+				got := codec.roundTrip(t, record)
+
+				if len(got.Leader) != leaderLength {
+					t.Fatalf("leader %q has %d characters, want %d", got.Leader, len(got.Leader), leaderLength)
+				}
+				if length, _ := strconv.Atoi(got.Leader[:5]); length != len(binary) {
+					t.Errorf("leader record length = %d, want %d", length, len(binary))
+				}
+				wantBase := leaderLength + directoryEntry*len(record.Fields) + 1
+				if base, _ := strconv.Atoi(got.Leader[12:17]); base != wantBase {
+					t.Errorf("leader base address = %d, want %d", base, wantBase)
+				}
+				if got.Leader[5] != tt.status {
+					t.Errorf("leader record status = %q, want %q", got.Leader[5], tt.status)
+				}
+
+				fields := make(map[string][]string)
+				for _, f := range got.Fields {
+					if f.Tag != "005" && f.Tag != "008" {
+						fields[f.Tag] = append(fields[f.Tag], render(f))
+					}
+				}
+				if !reflect.DeepEqual(fields, tt.want) {
+					t.Errorf("fields = %q\nwant %q", fields, tt.want)
+				}
+				for _, f := range got.FieldsByTag("520") {
+					if len(f.Subfield('a')) > maxSummaryBytes {
+						t.Errorf("520 has %d bytes, want at most %d", len(f.Subfield('a')), maxSummaryBytes)
+					}
+				}
+				if date := got.FieldsByTag("008"); len(date) != 1 || len(date[0].Value) != 40 {
+					t.Errorf("008 = %q, want one field of 40 characters", date)
+				}
+			})
+		}
+	}
+}
+
+func TestFieldsByTag(t *testing.T) {
+	r := &Record{Fields: []Field{
+		{Tag: "001", Value: "1"},
+		{Tag: "020", Subfields: []Subfield{{'a', "first"}}},
+		{Tag: "245", Subfields: []Subfield{{'a', "title"}}},
+		{Tag: "020", Subfields: []Subfield{{'a', "second"}}},
+	}}
+	tests := []struct {
+		tag  string
+		want []string
+	}{
+		{"020", []string{"first", "second"}},
+		{"245", []string{"title"}},
+		{"100", nil},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, f := range r.FieldsByTag(tt.tag) {
+			got = append(got, f.Subfield('a'))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("FieldsByTag(%q) = %q, want %q", tt.tag, got, tt.want)
+		}
+	}
+}
+
+func TestUnmarshalBinaryRejectsMalformedRecords(t *testing.T) {
+	valid, err := FromBook(&models.Book{ID: 1, Title: "Title", Version: 1}).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"truncated", valid[:len(valid)-1]},
+		{"bad base address", append([]byte(string(valid[:12])+"00000"), valid[17:]...)},
+		{"bad directory entry", append(append([]byte{}, valid[:24]...), append([]byte("001x"), valid[28:]...)...)},
+	}
+	for _, tt := range tests {
+		var r Record
+		if err := r.UnmarshalBinary(tt.data); !errors.Is(err, ErrInvalidRecord) {
+			t.Errorf("%s: UnmarshalBinary = %v, want ErrInvalidRecord", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package marc
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Namespace is the XML namespace of MARCXML
+const Namespace = "http://www.loc.gov/MARC21/slim"
+
+// xmlRecord is the MARCXML form of a Record
+type xmlRecord struct {
+	XMLName       xml.Name          `xml:"record"`
+	Leader        string            `xml:"leader"`
+	ControlFields []xmlControlField `xml:"controlfield"`
+	DataFields    []xmlDataField    `xml:"datafield"`
+}
+
+type xmlControlField struct {
+	Tag   string `xml:"tag,attr"`
+	Value string `xml:",chardata"`
+}
+
+type xmlDataField struct {
+	Tag       string        `xml:"tag,attr"`
+	Ind1      string        `xml:"ind1,attr"`
+	Ind2      string        `xml:"ind2,attr"`
+	Subfields []xmlSubfield `xml:"subfield"`
+}
+
+type xmlSubfield struct {
+	Code  string `xml:"code,attr"`
+	Value string `xml:",chardata"`
+}
+
+// XMLWriter writes records as a MARCXML collection
+type XMLWriter struct {
+	w       io.Writer
+	enc     *xml.Encoder
+	started bool
+}
+
+// NewXMLWriter returns an XMLWriter writing to w. Close must be called to
+// end the collection.
+func NewXMLWriter(w io.Writer) *XMLWriter {
+	return &XMLWriter{w: w, enc: xml.NewEncoder(w)}
+}
+
+// start writes the XML declaration and opens the collection
+func (w *XMLWriter) start() error {
+	if w.started {
+		return nil
+	}
+	w.started = true
+	_, err := fmt.Fprintf(w.w, "%s<collection xmlns=%q>", xml.Header, Namespace)
+	return err
+}
+
+// Write writes one record. Its leader carries the record length and base
+// address the record has in binary form.
+func (w *XMLWriter) Write(r *Record) error {
+	// Encoding the binary form validates the record and computes its leader
+	_, leader, err := r.encode()
+	if err != nil {
+		return err
+	}
+	if err := w.start(); err != nil {
+		return err
+	}
+	rec := xmlRecord{Leader: leader}
+	for _, f := range r.Fields {
+		if f.IsControl() {
+			rec.ControlFields = append(rec.ControlFields, xmlControlField{Tag: f.Tag, Value: f.Value})
+			continue
+		}
+		df := xmlDataField{Tag: f.Tag, Ind1: string(f.Indicators[0]), Ind2: string(f.Indicators[1])}
+		for _, sub := range f.Subfields {
+			df.Subfields = append(df.Subfields, xmlSubfield{Code: string(sub.Code), Value: sub.Value})
+		}
+		rec.DataFields = append(rec.DataFields, df)
+	}
+	return w.enc.Encode(rec)
+}
+
+// Close ends the collection; it does not close the underlying writer
+func (w *XMLWriter) Close() error {
+	if err := w.start(); err != nil {
+		return err
+	}
+	if err := w.enc.Flush(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w.w, "</collection>\n")
+	return err
+}
+
+// XMLReader reads the records of a MARCXML document, whether a collection
+// or a single record
+type XMLReader struct {
+	dec *xml.Decoder
+}
+
+// NewXMLReader returns an XMLReader reading the document in r
+func NewXMLReader(r io.Reader) *XMLReader {
+	return &XMLReader{dec: xml.NewDecoder(r)}
+}
+
+// Next returns the next record, or io.EOF after the last one
+func (r *XMLReader) Next() (*Record, error) {
+	for {
+		token, err := r.dec.Token()
+		if errors.Is(err, io.EOF) {
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidRecord, err)
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "record" {
+			continue
+		}
+		var rec xmlRecord
+		if err := r.dec.DecodeElement(&rec, &start); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidRecord, err)
+		}
+		return rec.record()
+	}
+}
+
+// record converts a decoded MARCXML record to a Record; control fields are
+// listed before data fields, as their tags sort first
+func (rec xmlRecord) record() (*Record, error) {
+	r := &Record{Leader: rec.Leader}
+	for _, cf := range rec.ControlFields {
+		r.Fields = append(r.Fields, Field{Tag: cf.Tag, Value: cf.Value})
+	}
+	for _, df := range rec.DataFields {
+		if len(df.Ind1) != 1 || len(df.Ind2) != 1 {
+			return nil, fmt.Errorf("%w: field %s must have one character indicators", ErrInvalidRecord, df.Tag)
+		}
+		f := Field{Tag: df.Tag, Indicators: [2]byte{df.Ind1[0], df.Ind2[0]}}
+		for _, sub := range df.Subfields {
+			if len(sub.Code) != 1 {
+				return nil, fmt.Errorf("%w: field %s has subfield code %q", ErrInvalidRecord, df.Tag, sub.Code)
+			}
+			f.Subfields = append(f.Subfields, Subfield{Code: sub.Code[0], Value: sub.Value})
+		}
+		r.Fields = append(r.Fields, f)
+	}
+	return r, nil
+}
@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// startKey stores the start time of a statement in its GORM instance
+const startKey = "metrics:start"
+
+// InstrumentGORM records the duration and errors of every query db runs,
+// using GORM callbacks
+func (m *Metrics) InstrumentGORM(db *gorm.DB) error {
+	cb := db.Callback()
+	register := []struct {
+		operation     string
+		before, after registrar
+	}{
+		{"create", cb.Create().Before("gorm:create"), cb.Create().After("gorm:create")},
+		{"query", cb.Query().Before("gorm:query"), cb.Query().After("gorm:query")},
+		{"update", cb.Update().Before("gorm:update"), cb.Update().After("gorm:update")},
+		{"delete", cb.Delete().Before("gorm:delete"), cb.Delete().After("gorm:delete")},
+		{"row", cb.Row().Before("gorm:row"), cb.Row().After("gorm:row")},
+		{"raw", cb.Raw().Before("gorm:raw"), cb.Raw().After("gorm:raw")},
+	}
+	for _, r := range register {
+		if err := r.before.Register("metrics:before_"+r.operation, startQuery); err != nil {
+			return err
+		}
+		if err := r.after.Register("metrics:after_"+r.operation, m.endQuery(r.operation)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// registrar is the callback position returned by GORM's Before and After
+type registrar interface {
+	Register(name string, fn func(*gorm.DB)) error
+}
+
+func startQuery(db *gorm.DB) {
+	db.InstanceSet(startKey, time.Now())
+}
+
+func (m *Metrics) endQuery(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.InstanceGet(startKey)
+		if !ok {
+			return
+		}
+		start, ok := value.(time.Time)
+		if !ok {
+			return
+		}
+		table := db.Statement.Table
+		if table == "" {
+			table = "unknown"
+		}
+		m.dbDuration.WithLabelValues(operation, table).Observe(time.Since(start).Seconds())
+		if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+			m.dbErrors.WithLabelValues(operation, table).Inc()
+		}
+	}
+}
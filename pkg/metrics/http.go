@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"go-bookstore-mysql-crud/pkg/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// unmatchedRoute labels requests that match no route, so that scans of
+// random paths cannot create unbounded label values
+const unmatchedRoute = "unmatched"
+
+// Middleware records the request metrics of the routes registered on a
+// mux.Router; install it with router.Use
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := unmatchedRoute
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+		m.instrument(route, next).ServeHTTP(w, r)
+	})
+}
+
+// Unmatched records the request metrics of a router's NotFoundHandler or
+// MethodNotAllowedHandler, which route middleware does not see
+func (m *Metrics) Unmatched(next http.Handler) http.Handler {
+	return m.instrument(unmatchedRoute, next)
+}
+
+func (m *Metrics) instrument(route string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inFlight := m.httpInFlight.WithLabelValues(r.Method, route)
+		inFlight.Inc()
+		defer inFlight.Dec()
+
+		start := time.Now()
+		rec := utils.NewStatusRecorder(w)
+		next.ServeHTTP(rec, r)
+
+		status := strconv.Itoa(rec.Status)
+		m.httpRequests.WithLabelValues(r.Method, route, status).Inc()
+		m.httpDuration.WithLabelValues(r.Method, route, status).Observe(time.Since(start).Seconds())
+	})
+}
@@ -0,0 +1,129 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"go-bookstore-mysql-crud/pkg/models"
+)
+
+// Converts the free-form price strings ("$15.99") into integer minor units
+// and an ISO-4217 currency code. Written in Go because parsing legacy prices
+// needs models.ParseLegacyPrice. If any price cannot be parsed the migration
+// fails and names the offending rows so they can be fixed by hand.
+func init() {
+	Register(Migration{
+		Version: 2,
+		Name:    "book_price_money",
+		Up:      bookPriceMoneyUp,
+		Down:    bookPriceMoneyDown,
+	})
+}
+
+func bookPriceMoneyUp(ctx context.Context, tx *sql.Tx) error {
+	// Databases migrated by the interim AutoMigrate already have the columns
+	for _, stmt := range []struct{ column, ddl string }{
+		{"price_amount", "ALTER TABLE books ADD COLUMN price_amount BIGINT NOT NULL DEFAULT 0"},
+		{"price_currency", "ALTER TABLE books ADD COLUMN price_currency CHAR(3) NOT NULL DEFAULT ''"},
+	} {
+		exists, err := columnExists(ctx, tx, "books", stmt.column)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			if _, err := tx.ExecContext(ctx, stmt.ddl); err != nil {
+				return err
+			}
+		}
+	}
+
+	hasLegacy, err := columnExists(ctx, tx, "books", "price")
+	if err != nil || !hasLegacy {
+		return err
+	}
+
+	rows, err := tx.QueryContext(ctx, "SELECT id, COALESCE(price, '') FROM books WHERE price_currency = ''")
+	if err != nil {
+		return err
+	}
+	converted := make(map[uint]models.Money)
+	var failures []string
+	for rows.Next() {
+		var (
+			id    uint
+			price string
+		)
+		if err := rows.Scan(&id, &price); err != nil {
+			rows.Close()
+			return err
+		}
+		money, err := models.ParseLegacyPrice(price)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("book %d: %v", id, err))
+			continue
+		}
+		converted[id] = money
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("cannot convert legacy prices, fix these rows and retry: %s", strings.Join(failures, "; "))
+	}
+
+	for id, money := range converted {
+		_, err := tx.ExecContext(ctx, "UPDATE books SET price_amount = ?, price_currency = ? WHERE id = ?", money.Amount, money.Currency, id)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = tx.ExecContext(ctx, "ALTER TABLE books DROP COLUMN price")
+	return err
+}
+
+func bookPriceMoneyDown(ctx context.Context, tx *sql.Tx) error {
+	if _, err := tx.ExecContext(ctx, "ALTER TABLE books ADD COLUMN price LONGTEXT"); err != nil {
+		return err
+	}
+
+	rows, err := tx.QueryContext(ctx, "SELECT id, price_amount, price_currency FROM books")
+	if err != nil {
+		return err
+	}
+	prices := make(map[uint]string)
+	for rows.Next() {
+		var (
+			id    uint
+			money models.Money
+		)
+		if err := rows.Scan(&id, &money.Amount, &money.Currency); err != nil {
+			rows.Close()
+			return err
+		}
+		prices[id] = money.String()
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for id, price := range prices {
+		if _, err := tx.ExecContext(ctx, "UPDATE books SET price = ? WHERE id = ?", price, id); err != nil {
+			return err
+		}
+	}
+	_, err = tx.ExecContext(ctx, "ALTER TABLE books DROP COLUMN price_amount, DROP COLUMN price_currency")
+	return err
+}
+
+// columnExists reports whether table in the current database has column
+func columnExists(ctx context.Context, tx *sql.Tx, table, column string) (bool, error) {
+	var count int
+	err := tx.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND COLUMN_NAME = ?",
+		table, column).Scan(&count)
+	return count > 0, err
+}
@@ -0,0 +1,83 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"go-bookstore-mysql-crud/pkg/models"
+)
+
+// Splits the free-form author strings of existing books ("Neil Gaiman &
+// Terry Pratchett") into author records, merging spelling variants through
+// models.NormalizeAuthorName, and credits them on their books in order.
+func init() {
+	Register(Migration{
+		Version: 4,
+		Name:    "split_book_authors",
+		Up:      splitBookAuthorsUp,
+		Down:    splitBookAuthorsDown,
+	})
+}
+
+func splitBookAuthorsUp(ctx context.Context, tx *sql.Tx) error {
+	rows, err := tx.QueryContext(ctx, `SELECT id, COALESCE(author, '') FROM books
+		WHERE id NOT IN (SELECT book_id FROM book_authors)`)
+	if err != nil {
+		return err
+	}
+	books := make(map[uint][]string)
+	for rows.Next() {
+		var (
+			id     uint
+			author string
+		)
+		if err := rows.Scan(&id, &author); err != nil {
+			rows.Close()
+			return err
+		}
+		books[id] = models.SplitAuthorNames(author)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	authorIDs := make(map[string]uint)
+	now := time.Now().UTC()
+	for bookID, names := range books {
+		for position, name := range names {
+			normalized := models.NormalizeAuthorName(name)
+			authorID, ok := authorIDs[normalized]
+			if !ok {
+				// The first spelling seen becomes the display name
+				_, err := tx.ExecContext(ctx, `INSERT INTO authors (name, normalized_name, created_at, updated_at)
+					VALUES (?, ?, ?, ?) ON DUPLICATE KEY UPDATE id = id`, name, normalized, now, now)
+				if err != nil {
+					return err
+				}
+				err = tx.QueryRowContext(ctx, "SELECT id FROM authors WHERE normalized_name = ?", normalized).Scan(&authorID)
+				if err != nil {
+					return err
+				}
+				authorIDs[normalized] = authorID
+			}
+			_, err := tx.ExecContext(ctx, `INSERT IGNORE INTO book_authors (book_id, author_id, role, position)
+				VALUES (?, ?, ?, ?)`, bookID, authorID, models.RoleAuthor, position)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// splitBookAuthorsDown removes every author link and record; the books keep
+// their free-form author strings throughout, so nothing is lost
+func splitBookAuthorsDown(ctx context.Context, tx *sql.Tx) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM book_authors"); err != nil {
+		return err
+	}
+	_, err := tx.ExecContext(ctx, "DELETE FROM authors")
+	return err
+}
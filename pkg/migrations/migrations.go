@@ -0,0 +1,148 @@
+// Package migrations manages the database schema through numbered, versioned
+// migrations. SQL migrations live in sql/ as NNNN_name.up.sql and
+// NNNN_name.down.sql pairs and are embedded in the binary; migrations that
+// need Go code (such as data conversions) register themselves with Register.
+// Applied versions are recorded in the schema_migrations table.
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed sql/*.sql
+var sqlFiles embed.FS
+
+// ErrSchemaOutdated is returned by RequireCurrent when migrations are pending
+var ErrSchemaOutdated = errors.New("database schema is not up to date")
+
+// Migration is a single reversible schema change
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(ctx context.Context, tx *sql.Tx) error
+	Down    func(ctx context.Context, tx *sql.Tx) error
+}
+
+// Status describes whether a migration has been applied
+type Status struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt *time.Time
+}
+
+var registered []Migration
+
+// Register adds a Go migration. It is meant to be called from init functions
+// of files in this package and panics on duplicate versions.
+func Register(m Migration) {
+	for _, existing := range registered {
+		if existing.Version == m.Version {
+			panic(fmt.Sprintf("migrations: duplicate version %d", m.Version))
+		}
+	}
+	registered = append(registered, m)
+}
+
+// All returns every known migration ordered by version
+func All() ([]Migration, error) {
+	fromSQL, err := loadSQL(sqlFiles)
+	if err != nil {
+		return nil, err
+	}
+	all := append(fromSQL, registered...)
+	sort.Slice(all, func(i, j int) bool { return all[i].Version < all[j].Version })
+	for i := 1; i < len(all); i++ {
+		if all[i].Version == all[i-1].Version {
+			return nil, fmt.Errorf("migrations: duplicate version %d", all[i].Version)
+		}
+	}
+	return all, nil
+}
+
+var sqlFileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// loadSQL parses the embedded NNNN_name.(up|down).sql files
+func loadSQL(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, "sql")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		match := sqlFileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("migrations: unexpected file %s", entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+		body, err := fs.ReadFile(fsys, path.Join("sql", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migrations: version %d has files named %s and %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = execStatements(string(body))
+		} else {
+			m.Down = execStatements(string(body))
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == nil || m.Down == nil {
+			return nil, fmt.Errorf("migrations: version %d needs both an up and a down file", m.Version)
+		}
+		migrations = append(migrations, *m)
+	}
+	return migrations, nil
+}
+
+// execStatements returns a migration step running each statement of script.
+// Statements are separated by a semicolon at the end of a line because the
+// MySQL driver does not accept several statements in one Exec.
+func execStatements(script string) func(ctx context.Context, tx *sql.Tx) error {
+	var statements []string
+	var current strings.Builder
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSpace(current.String()))
+			current.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+
+	return func(ctx context.Context, tx *sql.Tx) error {
+		for _, stmt := range statements {
+			if _, err := tx.ExecContext(ctx, stmt); err != nil {
+				return fmt.Errorf("%w\n%s", err, stmt)
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,203 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Migrator applies and reverts migrations against a MySQL database
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// New returns a Migrator for db using every known migration
+func New(db *sql.DB) (*Migrator, error) {
+	all, err := All()
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: all}, nil
+}
+
+// Latest returns the highest known migration version
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Current returns the highest applied migration version, or 0 if none
+func (m *Migrator) Current(ctx context.Context) (int, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+	current := 0
+	for version := range applied {
+		current = max(current, version)
+	}
+	return current, nil
+}
+
+// Status lists every known migration and whether it has been applied
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]Status, len(m.migrations))
+	for i, mig := range m.migrations {
+		statuses[i] = Status{Version: mig.Version, Name: mig.Name}
+		if at, ok := applied[mig.Version]; ok {
+			at := at
+			statuses[i].Applied = true
+			statuses[i].AppliedAt = &at
+		}
+	}
+	return statuses, nil
+}
+
+// RequireCurrent returns ErrSchemaOutdated unless every known migration has
+// been applied
+func (m *Migrator) RequireCurrent(ctx context.Context) error {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+	for _, s := range statuses {
+		if !s.Applied {
+			return fmt.Errorf("%w: migration %d (%s) is pending, run `migrate up`", ErrSchemaOutdated, s.Version, s.Name)
+		}
+	}
+	return nil
+}
+
+// Up applies every pending migration
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.To(ctx, m.Latest())
+}
+
+// Down reverts the most recently applied migration
+func (m *Migrator) Down(ctx context.Context) error {
+	current, err := m.Current(ctx)
+	if err != nil {
+		return err
+	}
+	if current == 0 {
+		return nil
+	}
+	target := 0
+	for _, mig := range m.migrations {
+		if mig.Version < current {
+			target = mig.Version
+		}
+	}
+	return m.To(ctx, target)
+}
+
+// To migrates up or down until exactly the migrations with versions up to
+// and including target are applied. Target 0 reverts everything.
+func (m *Migrator) To(ctx context.Context, target int) error {
+	if target != 0 && !m.known(target) {
+		return fmt.Errorf("migrations: unknown version %d", target)
+	}
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Apply pending migrations in ascending order, including any gaps below
+	// the current version
+	for _, mig := range m.migrations {
+		if _, ok := applied[mig.Version]; !ok && mig.Version <= target {
+			if err := m.run(ctx, mig, true); err != nil {
+				return err
+			}
+		}
+	}
+	// Revert migrations above the target in descending order
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		mig := m.migrations[i]
+		if _, ok := applied[mig.Version]; ok && mig.Version > target {
+			if err := m.run(ctx, mig, false); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// run applies or reverts one migration and records the outcome. MySQL
+// commits DDL statements implicitly, so a failing migration may leave partial
+// schema changes behind; the version is only recorded once every statement
+// has succeeded.
+func (m *Migrator) run(ctx context.Context, mig Migration, up bool) error {
+	direction, step := "up", mig.Up
+	if !up {
+		direction, step = "down", mig.Down
+	}
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := step(ctx, tx); err != nil {
+		return fmt.Errorf("migration %d (%s) %s: %w", mig.Version, mig.Name, direction, err)
+	}
+	if up {
+		_, err = tx.ExecContext(ctx, "INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)", mig.Version, mig.Name, time.Now().UTC())
+	} else {
+		_, err = tx.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = ?", mig.Version)
+	}
+	if err != nil {
+		return fmt.Errorf("recording migration %d: %w", mig.Version, err)
+	}
+	return tx.Commit()
+}
+
+// applied returns the applied versions and when they were applied, creating
+// the schema_migrations table on first use
+func (m *Migrator) applied(ctx context.Context) (map[int]time.Time, error) {
+	_, err := m.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT NOT NULL PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		applied_at DATETIME(3) NOT NULL
+	)`)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := m.db.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var (
+			version int
+			at      time.Time
+		)
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		applied[version] = at
+	}
+	return applied, rows.Err()
+}
+
+func (m *Migrator) known(version int) bool {
+	for _, mig := range m.migrations {
+		if mig.Version == version {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,13 @@
+-- Books table as originally created by GORM's AutoMigrate. IF NOT EXISTS lets
+-- databases created before versioned migrations adopt this baseline.
+CREATE TABLE IF NOT EXISTS books (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    deleted_at DATETIME(3) NULL,
+    title LONGTEXT,
+    author LONGTEXT,
+    price LONGTEXT,
+    PRIMARY KEY (id),
+    INDEX idx_books_deleted_at (deleted_at)
+);
@@ -0,0 +1,21 @@
+CREATE TABLE authors (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    name VARCHAR(255) NOT NULL,
+    normalized_name VARCHAR(255) NOT NULL,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    UNIQUE INDEX idx_authors_normalized_name (normalized_name)
+);
+
+CREATE TABLE book_authors (
+    book_id BIGINT UNSIGNED NOT NULL,
+    author_id BIGINT UNSIGNED NOT NULL,
+    role VARCHAR(16) NOT NULL DEFAULT 'author',
+    position INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (book_id, author_id, role),
+    INDEX idx_book_authors_author_id (author_id),
+    CONSTRAINT fk_book_authors_book FOREIGN KEY (book_id) REFERENCES books (id) ON DELETE CASCADE,
+    CONSTRAINT fk_book_authors_author FOREIGN KEY (author_id) REFERENCES authors (id) ON DELETE RESTRICT,
+    CONSTRAINT chk_book_authors_role CHECK (role IN ('author', 'editor', 'translator'))
+);
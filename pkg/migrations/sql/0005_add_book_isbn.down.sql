@@ -0,0 +1,4 @@
+ALTER TABLE books
+    DROP INDEX idx_books_isbn_13,
+    DROP COLUMN isbn_10,
+    DROP COLUMN isbn_13;
@@ -0,0 +1,4 @@
+ALTER TABLE books
+    ADD COLUMN isbn_13 VARCHAR(13) NULL,
+    ADD COLUMN isbn_10 VARCHAR(10) NULL,
+    ADD UNIQUE INDEX idx_books_isbn_13 (isbn_13);
@@ -0,0 +1,27 @@
+CREATE TABLE stock_levels (
+    book_id BIGINT UNSIGNED NOT NULL,
+    on_hand INT NOT NULL DEFAULT 0,
+    reserved INT NOT NULL DEFAULT 0,
+    reorder_threshold INT NOT NULL DEFAULT 0,
+    updated_at DATETIME(3) NULL,
+    PRIMARY KEY (book_id),
+    CONSTRAINT fk_stock_levels_book FOREIGN KEY (book_id) REFERENCES books (id),
+    CONSTRAINT chk_stock_levels_on_hand CHECK (on_hand >= 0),
+    CONSTRAINT chk_stock_levels_reserved CHECK (reserved >= 0 AND reserved <= on_hand),
+    CONSTRAINT chk_stock_levels_threshold CHECK (reorder_threshold >= 0)
+);
+
+CREATE TABLE stock_movements (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    book_id BIGINT UNSIGNED NOT NULL,
+    type VARCHAR(16) NOT NULL,
+    quantity INT NOT NULL,
+    reason VARCHAR(255) NOT NULL DEFAULT '',
+    on_hand_after INT NOT NULL,
+    reserved_after INT NOT NULL,
+    created_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    INDEX idx_stock_movements_book_id (book_id),
+    CONSTRAINT fk_stock_movements_book FOREIGN KEY (book_id) REFERENCES books (id),
+    CONSTRAINT chk_stock_movements_type CHECK (type IN ('receive', 'sell', 'return', 'adjust', 'reserve', 'release'))
+);
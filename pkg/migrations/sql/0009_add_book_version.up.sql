@@ -0,0 +1,4 @@
+-- Every change to a book increments its version, which is served as the ETag
+-- and checked against If-Match to reject lost updates
+ALTER TABLE books
+    ADD COLUMN version INT UNSIGNED NOT NULL DEFAULT 1;
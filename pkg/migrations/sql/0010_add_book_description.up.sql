@@ -0,0 +1,3 @@
+-- Blurb of the book, filled in by hand or from the publisher's ONIX feed
+ALTER TABLE books
+    ADD COLUMN description TEXT NULL;
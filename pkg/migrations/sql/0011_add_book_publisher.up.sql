@@ -0,0 +1,3 @@
+-- Publisher of the book, exported in MARC records for library customers
+ALTER TABLE books
+    ADD COLUMN publisher VARCHAR(255) NULL;
@@ -0,0 +1,3 @@
+ALTER TABLE books
+    DROP INDEX ft_books_text,
+    DROP INDEX ft_books_title;
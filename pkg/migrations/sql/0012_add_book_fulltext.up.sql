@@ -0,0 +1,7 @@
+-- Full-text indexes behind GET /books/search; the title index lets title
+-- matches rank above matches in the author or description. InnoDB builds
+-- one FULLTEXT index per statement.
+ALTER TABLE books
+    ADD FULLTEXT INDEX ft_books_title (title);
+ALTER TABLE books
+    ADD FULLTEXT INDEX ft_books_text (title, author, description);
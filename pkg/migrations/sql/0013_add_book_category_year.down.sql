@@ -0,0 +1,5 @@
+ALTER TABLE books
+    DROP INDEX idx_books_published_year,
+    DROP INDEX idx_books_category,
+    DROP COLUMN published_year,
+    DROP COLUMN category;
@@ -0,0 +1,7 @@
+-- Category and publication year of the book, counted by the facets of the
+-- book listing
+ALTER TABLE books
+    ADD COLUMN category VARCHAR(100) NULL,
+    ADD COLUMN published_year SMALLINT NULL,
+    ADD INDEX idx_books_category (category),
+    ADD INDEX idx_books_published_year (published_year);
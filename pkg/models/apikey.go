@@ -0,0 +1,151 @@
+package models
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrAPIKeyNotFound is returned by an APIKeyRepository when no key matches
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+// Access scopes, from least to most privileged. A scope grants everything
+// the scopes below it grant.
+const (
+	// ScopeRead allows reading the catalogue
+	ScopeRead = "read"
+	// ScopeWrite allows creating and changing books, authors and stock
+	ScopeWrite = "write"
+	// ScopeAdmin allows deleting and managing API keys
+	ScopeAdmin = "admin"
+)
+
+var scopeRank = map[string]int{ScopeRead: 1, ScopeWrite: 2, ScopeAdmin: 3}
+
+// Scopes is a set of access scopes, stored as a comma separated list
+// @Description Access scopes: read, write and/or admin
+type Scopes []string
+
+// Allows reports whether the scopes grant the required scope
+func (s Scopes) Allows(required string) bool {
+	for _, scope := range s {
+		if scopeRank[scope] >= scopeRank[required] {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate reports unknown or repeated scopes
+func (s Scopes) Validate() error {
+	seen := make(map[string]bool, len(s))
+	for _, scope := range s {
+		if _, ok := scopeRank[scope]; !ok {
+			return fmt.Errorf("unknown scope %q, expected read, write or admin", scope)
+		}
+		if seen[scope] {
+			return fmt.Errorf("scope %q is repeated", scope)
+		}
+		seen[scope] = true
+	}
+	return nil
+}
+
+// Value implements driver.Valuer
+func (s Scopes) Value() (driver.Value, error) {
+	return strings.Join(s, ","), nil
+}
+
+// Scan implements sql.Scanner
+func (s *Scopes) Scan(src interface{}) error {
+	var raw string
+	switch v := src.(type) {
+	case string:
+		raw = v
+	case []byte:
+		raw = string(v)
+	case nil:
+	default:
+		return fmt.Errorf("cannot scan %T into Scopes", src)
+	}
+	*s = nil
+	if raw != "" {
+		*s = strings.Split(raw, ",")
+	}
+	return nil
+}
+
+// APIKey is a credential for machine clients. Only a hash of the secret is
+// stored; the key itself is shown once, when it is created.
+// @Description API key metadata; the secret is never returned after creation
+type APIKey struct {
+	// @Description Unique identifier for the key
+	// @Example 1
+	ID uint `json:"id" gorm:"primaryKey" example:"1"`
+
+	// @Description What the key is used for
+	// @Example "warehouse sync"
+	Name string `json:"name" gorm:"size:100;not null" example:"warehouse sync"`
+
+	// @Description Public part of the key, used to identify it in logs
+	// @Example "bks_1a2b3c4d"
+	Prefix string `json:"prefix" gorm:"size:16;not null;uniqueIndex" example:"bks_1a2b3c4d"`
+
+	// Hash is the SHA-256 of the full key, hex encoded
+	Hash string `json:"-" gorm:"size:64;not null"`
+
+	// @Description Scopes granted to the key
+	Scopes Scopes `json:"scopes" gorm:"type:varchar(64);not null" swaggertype:"array,string" example:"read,write"`
+
+	// @Description When the key was created
+	// @Example "2023-01-01T00:00:00Z"
+	CreatedAt time.Time `json:"created_at" example:"2023-01-01T00:00:00Z"`
+
+	// @Description When the key last authenticated a request, to the minute
+	// @Example "2023-01-01T00:00:00Z"
+	LastUsedAt *time.Time `json:"last_used_at" example:"2023-01-01T00:00:00Z"`
+
+	// @Description When the key was revoked
+	// @Example "2023-01-01T00:00:00Z"
+	RevokedAt *time.Time `json:"revoked_at,omitempty" example:"2023-01-01T00:00:00Z"`
+}
+
+// Revoked reports whether the key has been revoked
+func (k *APIKey) Revoked() bool {
+	return k.RevokedAt != nil
+}
+
+// APIKeyRequest represents the API key request structure for API documentation
+// @Description API key request model for API documentation
+type APIKeyRequest struct {
+	// @Description What the key is used for
+	// @Example "warehouse sync"
+	Name string `json:"name" example:"warehouse sync" binding:"required,max=100"`
+
+	// @Description Scopes to grant: read, write and/or admin
+	Scopes Scopes `json:"scopes" swaggertype:"array,string" example:"read,write" binding:"required,min=1,max=3,valid"`
+}
+
+// ToAPIKey copies the request fields into a new APIKey
+func (r *APIKeyRequest) ToAPIKey() *APIKey {
+	return &APIKey{Name: strings.TrimSpace(r.Name), Scopes: r.Scopes}
+}
+
+// APIKeyCreatedResponse is returned once, when a key is created
+// @Description Newly created API key including its secret
+type APIKeyCreatedResponse struct {
+	APIKey
+
+	// @Description The full key; store it now, it cannot be retrieved again
+	// @Example "bks_1a2b3c4d_Jz0f9..."
+	Key string `json:"key" example:"bks_1a2b3c4d_Jz0f9..."`
+}
+
+// APIKeyListResponse represents the list of API keys for API documentation
+// @Description API key list model for API documentation
+type APIKeyListResponse struct {
+	// @Description Keys, newest first, including revoked keys
+	Data []APIKey `json:"data"`
+}
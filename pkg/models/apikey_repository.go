@@ -0,0 +1,27 @@
+package models
+
+import (
+	"context"
+	"time"
+)
+
+// APIKeyRepository stores API keys. Implementations must be safe for
+// concurrent use.
+type APIKeyRepository interface {
+	// Create stores a new key
+	Create(ctx context.Context, key *APIKey) error
+	// GetByPrefix returns the key with the given prefix or ErrAPIKeyNotFound;
+	// revoked keys are returned too
+	GetByPrefix(ctx context.Context, prefix string) (*APIKey, error)
+	// List returns every key, newest first
+	List(ctx context.Context) ([]APIKey, error)
+	// Revoke marks the key with the given ID as revoked and returns it;
+	// revoking a revoked key keeps the original revocation time
+	Revoke(ctx context.Context, id uint) (*APIKey, error)
+	// Touch records that the key was used at the given time. Implementations
+	// may skip the write when the stored time is less than a minute older.
+	Touch(ctx context.Context, id uint, at time.Time) error
+}
+
+// lastUsedResolution is how stale last_used_at may get before Touch writes it
+const lastUsedResolution = time.Minute
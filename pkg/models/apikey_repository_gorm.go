@@ -0,0 +1,72 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// GormAPIKeyRepository is an APIKeyRepository backed by a GORM database
+type GormAPIKeyRepository struct {
+	db *gorm.DB
+}
+
+// NewGormAPIKeyRepository returns an APIKeyRepository that stores keys in db
+func NewGormAPIKeyRepository(db *gorm.DB) *GormAPIKeyRepository {
+	return &GormAPIKeyRepository{db: db}
+}
+
+func (r *GormAPIKeyRepository) Create(ctx context.Context, key *APIKey) error {
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+func (r *GormAPIKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*APIKey, error) {
+	var key APIKey
+	if err := r.db.WithContext(ctx).Where("prefix = ?", prefix).First(&key).Error; err != nil {
+		return nil, translateAPIKeyError(err)
+	}
+	return &key, nil
+}
+
+func (r *GormAPIKeyRepository) List(ctx context.Context) ([]APIKey, error) {
+	keys := []APIKey{}
+	if err := r.db.WithContext(ctx).Order("id DESC").Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (r *GormAPIKeyRepository) Revoke(ctx context.Context, id uint) (*APIKey, error) {
+	var key APIKey
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&key, id).Error; err != nil {
+			return err
+		}
+		if key.Revoked() {
+			return nil
+		}
+		now := time.Now()
+		key.RevokedAt = &now
+		return tx.Model(&key).Update("revoked_at", now).Error
+	})
+	if err != nil {
+		return nil, translateAPIKeyError(err)
+	}
+	return &key, nil
+}
+
+func (r *GormAPIKeyRepository) Touch(ctx context.Context, id uint, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&APIKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, at.Add(-lastUsedResolution)).
+		Update("last_used_at", at).Error
+}
+
+// translateAPIKeyError maps GORM errors to the repository's sentinel errors
+func translateAPIKeyError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrAPIKeyNotFound
+	}
+	return err
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryAPIKeyRepository is an APIKeyRepository that keeps keys in process memory
+type MemoryAPIKeyRepository struct {
+	mu     sync.RWMutex
+	keys   map[uint]APIKey
+	nextID uint
+}
+
+// NewMemoryAPIKeyRepository returns an empty in-memory APIKeyRepository
+func NewMemoryAPIKeyRepository() *MemoryAPIKeyRepository {
+	return &MemoryAPIKeyRepository{keys: make(map[uint]APIKey), nextID: 1}
+}
+
+func (r *MemoryAPIKeyRepository) Create(ctx context.Context, key *APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key.ID = r.nextID
+	r.nextID++
+	key.CreatedAt = time.Now()
+	r.keys[key.ID] = cloneAPIKey(*key)
+	return nil
+}
+
+func (r *MemoryAPIKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, key := range r.keys {
+		if key.Prefix == prefix {
+			key = cloneAPIKey(key)
+			return &key, nil
+		}
+	}
+	return nil, ErrAPIKeyNotFound
+}
+
+func (r *MemoryAPIKeyRepository) List(ctx context.Context) ([]APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	keys := make([]APIKey, 0, len(r.keys))
+	for id := r.nextID - 1; id > 0; id-- {
+		if key, ok := r.keys[id]; ok {
+			keys = append(keys, cloneAPIKey(key))
+		}
+	}
+	return keys, nil
+}
+
+func (r *MemoryAPIKeyRepository) Revoke(ctx context.Context, id uint) (*APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key, ok := r.keys[id]
+	if !ok {
+		return nil, ErrAPIKeyNotFound
+	}
+	if !key.Revoked() {
+		now := time.Now()
+		key.RevokedAt = &now
+		r.keys[id] = key
+	}
+	key = cloneAPIKey(key)
+	return &key, nil
+}
+
+func (r *MemoryAPIKeyRepository) Touch(ctx context.Context, id uint, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key, ok := r.keys[id]
+	if !ok {
+		return ErrAPIKeyNotFound
+	}
+	if key.LastUsedAt == nil || key.LastUsedAt.Before(at.Add(-lastUsedResolution)) {
+		key.LastUsedAt = &at
+		r.keys[id] = key
+	}
+	return nil
+}
+
+// cloneAPIKey returns a copy of key that shares no memory with it
+func cloneAPIKey(key APIKey) APIKey {
+	key.Scopes = append(Scopes(nil), key.Scopes...)
+	return key
+}
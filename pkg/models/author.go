@@ -0,0 +1,149 @@
+package models
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var (
+	// ErrAuthorNotFound is returned by an AuthorRepository when no author matches
+	ErrAuthorNotFound = errors.New("author not found")
+	// ErrAuthorExists is returned when an author with an equivalent name exists
+	ErrAuthorExists = errors.New("author already exists")
+	// ErrAuthorInUse is returned when deleting an author still linked to books
+	ErrAuthorInUse = errors.New("author is linked to books")
+)
+
+// Contributor roles a BookAuthor link can have
+const (
+	RoleAuthor     = "author"
+	RoleEditor     = "editor"
+	RoleTranslator = "translator"
+)
+
+// Author is a person credited on one or more books
+// @Description Author model for the bookstore API
+type Author struct {
+	// @Description Unique identifier for the author
+	// @Example 1
+	ID uint `json:"id" gorm:"primaryKey" example:"1"`
+
+	// @Description Display name of the author
+	// @Example "F. Scott Fitzgerald"
+	Name string `json:"name" gorm:"size:255;not null" example:"F. Scott Fitzgerald"`
+
+	// NormalizedName identifies spelling variants of the same name, see NormalizeAuthorName
+	NormalizedName string `json:"-" gorm:"size:255;not null;uniqueIndex"`
+
+	// @Description When the author was created
+	// @Example "2023-01-01T00:00:00Z"
+	CreatedAt time.Time `json:"created_at" example:"2023-01-01T00:00:00Z"`
+
+	// @Description When the author was last updated
+	// @Example "2023-01-01T00:00:00Z"
+	UpdatedAt time.Time `json:"updated_at" example:"2023-01-01T00:00:00Z"`
+}
+
+// BookAuthor links a book to one of its contributors
+// @Description Contributor credited on a book
+type BookAuthor struct {
+	BookID uint `json:"-" gorm:"primaryKey"`
+
+	// @Description Identifier of the author
+	// @Example 1
+	AuthorID uint `json:"author_id" gorm:"primaryKey" example:"1"`
+
+	// @Description Name of the author
+	// @Example "F. Scott Fitzgerald"
+	Name string `json:"name" gorm:"-" example:"F. Scott Fitzgerald"`
+
+	// @Description Contribution of the author: author, editor or translator
+	// @Example "author"
+	Role string `json:"role" gorm:"primaryKey;size:16" example:"author"`
+
+	// @Description Position of the contributor in the book's credits, starting at 0
+	// @Example 0
+	Position int `json:"position" gorm:"not null;default:0" example:"0"`
+}
+
+// AuthorRequest represents the author request structure for API documentation
+// @Description Author request model for API documentation
+type AuthorRequest struct {
+	// @Description Display name of the author
+	// @Example "F. Scott Fitzgerald"
+	Name string `json:"name" example:"F. Scott Fitzgerald" binding:"required,max=255"`
+}
+
+// ToAuthor copies the request fields into a new Author
+func (r *AuthorRequest) ToAuthor() *Author {
+	name := strings.Join(strings.Fields(r.Name), " ")
+	return &Author{Name: name, NormalizedName: NormalizeAuthorName(name)}
+}
+
+// BookAuthorRequest credits an existing author on a book
+// @Description Contributor of a book in requests
+type BookAuthorRequest struct {
+	// @Description Identifier of an existing author
+	// @Example 1
+	AuthorID uint `json:"author_id" example:"1" binding:"required"`
+
+	// @Description author (default), editor or translator
+	// @Example "author"
+	Role string `json:"role,omitempty" example:"author" binding:"oneof=author editor translator"`
+}
+
+// AuthorListResponse represents a page of authors for API documentation
+// @Description Paginated author list model for API documentation
+type AuthorListResponse struct {
+	// @Description Authors in the page
+	Data []Author `json:"data"`
+
+	// @Description Pagination metadata
+	Pagination Pagination `json:"pagination"`
+}
+
+var nonNameChars = regexp.MustCompile(`[^\p{L}\p{N}]+`)
+
+// NormalizeAuthorName reduces a name to a key shared by its spelling
+// variants, so that "F. Scott Fitzgerald" and "F Scott  fitzgerald" match
+func NormalizeAuthorName(name string) string {
+	key := nonNameChars.ReplaceAllString(strings.ToLower(name), " ")
+	return strings.TrimSpace(key)
+}
+
+var authorSeparators = regexp.MustCompile(`\s*(?:;|&|\band\b)\s*`)
+
+// SplitAuthorNames splits a free-form author string such as
+// "Neil Gaiman & Terry Pratchett" into individual names
+func SplitAuthorNames(authors string) []string {
+	var names []string
+	for _, name := range authorSeparators.Split(authors, -1) {
+		name = strings.Join(strings.Fields(name), " ")
+		if strings.IndexFunc(name, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) >= 0 {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// CreditLine returns the display string of a book's credits: the names of
+// its authors, or of all its contributors when it has no authors, joined
+// by " & ". The links must carry their names.
+func CreditLine(links []BookAuthor) string {
+	var names []string
+	for _, link := range links {
+		if link.Role == RoleAuthor {
+			names = append(names, link.Name)
+		}
+	}
+	// Editors and translators are only named when a book has no authors
+	if len(names) == 0 {
+		for _, link := range links {
+			names = append(names, link.Name)
+		}
+	}
+	return strings.Join(names, " & ")
+}
@@ -0,0 +1,49 @@
+package models
+
+import "context"
+
+// AuthorQuery describes which authors List should return
+type AuthorQuery struct {
+	// Name is a case-insensitive substring filter
+	Name   string
+	Limit  int
+	Offset int
+}
+
+// AuthorPage is one page of an author listing
+type AuthorPage struct {
+	Authors []Author
+	// Total is the number of authors matching the filter, ignoring pagination
+	Total int64
+}
+
+// AuthorRepository abstracts the storage of authors. Implementations must be
+// safe for concurrent use. Links between books and authors are stored by the
+// BookRepository.
+type AuthorRepository interface {
+	// Create stores a new author; ErrAuthorExists if the normalized name is taken
+	Create(ctx context.Context, author *Author) error
+	// Get returns the author with the given ID or ErrAuthorNotFound
+	Get(ctx context.Context, id uint) (*Author, error)
+	// GetMany returns the authors with the given IDs keyed by ID; unknown IDs are omitted
+	GetMany(ctx context.Context, ids []uint) (map[uint]Author, error)
+	// FindOrCreate returns the author whose normalized name matches name,
+	// creating it if there is none
+	FindOrCreate(ctx context.Context, name string) (*Author, error)
+	// List returns one page of the authors matching query ordered by name
+	List(ctx context.Context, query AuthorQuery) (*AuthorPage, error)
+	// Update renames the author with the given ID
+	Update(ctx context.Context, id uint, changes *Author) (*Author, error)
+	// Delete removes the author with the given ID and returns it
+	Delete(ctx context.Context, id uint) (*Author, error)
+}
+
+// normalize applies the listing defaults shared with BookQuery
+func (q AuthorQuery) normalize() AuthorQuery {
+	if q.Limit <= 0 {
+		q.Limit = DefaultPageSize
+	}
+	q.Limit = min(q.Limit, MaxPageSize)
+	q.Offset = max(q.Offset, 0)
+	return q
+}
@@ -0,0 +1,132 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GormAuthorRepository is an AuthorRepository backed by a GORM database
+type GormAuthorRepository struct {
+	db *gorm.DB
+}
+
+// NewGormAuthorRepository returns an AuthorRepository that stores authors in db
+func NewGormAuthorRepository(db *gorm.DB) *GormAuthorRepository {
+	return &GormAuthorRepository{db: db}
+}
+
+func (r *GormAuthorRepository) Create(ctx context.Context, author *Author) error {
+	author.NormalizedName = NormalizeAuthorName(author.Name)
+	return translateAuthorError(r.db.WithContext(ctx).Create(author).Error)
+}
+
+func (r *GormAuthorRepository) Get(ctx context.Context, id uint) (*Author, error) {
+	var author Author
+	if err := r.db.WithContext(ctx).First(&author, id).Error; err != nil {
+		return nil, translateAuthorError(err)
+	}
+	return &author, nil
+}
+
+func (r *GormAuthorRepository) GetMany(ctx context.Context, ids []uint) (map[uint]Author, error) {
+	authors := make(map[uint]Author, len(ids))
+	if len(ids) == 0 {
+		return authors, nil
+	}
+	var found []Author
+	if err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&found).Error; err != nil {
+		return nil, err
+	}
+	for _, a := range found {
+		authors[a.ID] = a
+	}
+	return authors, nil
+}
+
+func (r *GormAuthorRepository) FindOrCreate(ctx context.Context, name string) (*Author, error) {
+	author, err := findOrCreateAuthor(r.db.WithContext(ctx), name)
+	if err != nil {
+		return nil, translateAuthorError(err)
+	}
+	return author, nil
+}
+
+// findOrCreateAuthor returns the author with the normalized name of name,
+// creating it in db when missing
+func findOrCreateAuthor(db *gorm.DB, name string) (*Author, error) {
+	author := Author{Name: name, NormalizedName: NormalizeAuthorName(name)}
+	// Insert unless the normalized name exists, then read back whichever row won
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&author).Error; err != nil {
+		return nil, err
+	}
+	var existing Author
+	if err := db.Where("normalized_name = ?", author.NormalizedName).First(&existing).Error; err != nil {
+		return nil, err
+	}
+	return &existing, nil
+}
+
+func (r *GormAuthorRepository) List(ctx context.Context, query AuthorQuery) (*AuthorPage, error) {
+	q := query.normalize()
+	db := r.db.WithContext(ctx).Model(&Author{})
+	if q.Name != "" {
+		db = db.Where("name LIKE ?", "%"+escapeLike(q.Name)+"%")
+	}
+
+	page := &AuthorPage{}
+	if err := db.Session(&gorm.Session{}).Count(&page.Total).Error; err != nil {
+		return nil, err
+	}
+	err := db.Session(&gorm.Session{}).Order("name ASC, id ASC").Limit(q.Limit).Offset(q.Offset).Find(&page.Authors).Error
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+func (r *GormAuthorRepository) Update(ctx context.Context, id uint, changes *Author) (*Author, error) {
+	var author Author
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&author, id).Error; err != nil {
+			return err
+		}
+		return tx.Model(&author).Updates(Author{Name: changes.Name, NormalizedName: NormalizeAuthorName(changes.Name)}).Error
+	})
+	if err != nil {
+		return nil, translateAuthorError(err)
+	}
+	return &author, nil
+}
+
+func (r *GormAuthorRepository) Delete(ctx context.Context, id uint) (*Author, error) {
+	var author Author
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&author, id).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&author).Error
+	})
+	if err != nil {
+		return nil, translateAuthorError(err)
+	}
+	return &author, nil
+}
+
+// translateAuthorError maps GORM sentinel errors onto repository errors
+func translateAuthorError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return ErrAuthorNotFound
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return fmt.Errorf("%w: %v", ErrAuthorExists, err)
+	case errors.Is(err, gorm.ErrForeignKeyViolated):
+		return fmt.Errorf("%w: %v", ErrAuthorInUse, err)
+	}
+	return err
+}
@@ -0,0 +1,159 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryAuthorRepository is an AuthorRepository that keeps authors in process memory
+type MemoryAuthorRepository struct {
+	mu      sync.RWMutex
+	authors map[uint]Author
+	nextID  uint
+}
+
+// NewMemoryAuthorRepository returns an empty in-memory AuthorRepository
+func NewMemoryAuthorRepository() *MemoryAuthorRepository {
+	return &MemoryAuthorRepository{authors: make(map[uint]Author), nextID: 1}
+}
+
+func (r *MemoryAuthorRepository) Create(ctx context.Context, author *Author) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.create(author)
+}
+
+// create inserts author; the caller must hold the write lock
+func (r *MemoryAuthorRepository) create(author *Author) error {
+	author.NormalizedName = NormalizeAuthorName(author.Name)
+	if _, ok := r.byNormalizedName(author.NormalizedName); ok {
+		return fmt.Errorf("%w: %q", ErrAuthorExists, author.Name)
+	}
+	now := time.Now()
+	author.ID = r.nextID
+	author.CreatedAt = now
+	author.UpdatedAt = now
+	r.nextID++
+	r.authors[author.ID] = *author
+	return nil
+}
+
+func (r *MemoryAuthorRepository) Get(ctx context.Context, id uint) (*Author, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	author, ok := r.authors[id]
+	if !ok {
+		return nil, ErrAuthorNotFound
+	}
+	return &author, nil
+}
+
+func (r *MemoryAuthorRepository) GetMany(ctx context.Context, ids []uint) (map[uint]Author, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	authors := make(map[uint]Author, len(ids))
+	for _, id := range ids {
+		if author, ok := r.authors[id]; ok {
+			authors[id] = author
+		}
+	}
+	return authors, nil
+}
+
+func (r *MemoryAuthorRepository) FindOrCreate(ctx context.Context, name string) (*Author, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.findOrCreate(name)
+}
+
+// findOrCreate returns the author with the normalized name of name,
+// creating it when missing; the caller must hold the write lock
+func (r *MemoryAuthorRepository) findOrCreate(name string) (*Author, error) {
+	if author, ok := r.byNormalizedName(NormalizeAuthorName(name)); ok {
+		return &author, nil
+	}
+	author := &Author{Name: name}
+	if err := r.create(author); err != nil {
+		return nil, err
+	}
+	return author, nil
+}
+
+// rollback removes the authors created since nextID was mark; the caller
+// must hold the write lock
+func (r *MemoryAuthorRepository) rollback(mark uint) {
+	for id := mark; id < r.nextID; id++ {
+		delete(r.authors, id)
+	}
+	r.nextID = mark
+}
+
+func (r *MemoryAuthorRepository) List(ctx context.Context, query AuthorQuery) (*AuthorPage, error) {
+	q := query.normalize()
+
+	r.mu.RLock()
+	authors := make([]Author, 0, len(r.authors))
+	for _, author := range r.authors {
+		if q.Name == "" || strings.Contains(strings.ToLower(author.Name), strings.ToLower(q.Name)) {
+			authors = append(authors, author)
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(authors, func(i, j int) bool {
+		if c := compareSortKeys(authors[i].Name, authors[j].Name); c != 0 {
+			return c < 0
+		}
+		return authors[i].ID < authors[j].ID
+	})
+	start := min(q.Offset, len(authors))
+	end := min(start+q.Limit, len(authors))
+	return &AuthorPage{Authors: authors[start:end], Total: int64(len(authors))}, nil
+}
+
+func (r *MemoryAuthorRepository) Update(ctx context.Context, id uint, changes *Author) (*Author, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	author, ok := r.authors[id]
+	if !ok {
+		return nil, ErrAuthorNotFound
+	}
+	normalized := NormalizeAuthorName(changes.Name)
+	if other, ok := r.byNormalizedName(normalized); ok && other.ID != id {
+		return nil, fmt.Errorf("%w: %q", ErrAuthorExists, changes.Name)
+	}
+	author.Name = changes.Name
+	author.NormalizedName = normalized
+	author.UpdatedAt = time.Now()
+	r.authors[id] = author
+	return &author, nil
+}
+
+func (r *MemoryAuthorRepository) Delete(ctx context.Context, id uint) (*Author, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	author, ok := r.authors[id]
+	if !ok {
+		return nil, ErrAuthorNotFound
+	}
+	delete(r.authors, id)
+	return &author, nil
+}
+
+// byNormalizedName finds an author by normalized name; the caller must hold the lock
+func (r *MemoryAuthorRepository) byNormalizedName(normalized string) (Author, bool) {
+	for _, author := range r.authors {
+		if author.NormalizedName == normalized {
+			return author, true
+		}
+	}
+	return Author{}, false
+}
@@ -1,24 +1,28 @@
 package models
 
 import (
-	"fmt"
-	"go-bookstore-mysql-crud/pkg/config"
-	"log"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
 )
 
-var DB *gorm.DB
-
 // Book represents a book in the bookstore
 // @Description Book model for the bookstore API
 type Book struct {
-	gorm.Model
 	// @Description Unique identifier for the book
 	// @Example 1
 	ID uint `json:"id" gorm:"primaryKey" example:"1"`
 
+	// @Description When the book was created
+	CreatedAt time.Time `json:"created_at"`
+
+	// @Description When the book was last updated
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// @Description When the book was moved to the trash; unset for live books
+	DeletedAt gorm.DeletedAt `json:"deleted_at,omitzero" gorm:"index" swaggertype:"string"`
+
 	// @Description Title of the book
 	// @Example "The Great Gatsby"
 	Title string `json:"title" example:"The Great Gatsby"`
@@ -28,8 +32,38 @@ type Book struct {
 	Author string `json:"author" example:"F. Scott Fitzgerald"`
 
 	// @Description Price of the book
-	// @Example "$15.99"
-	Price string `json:"price" example:"$15.99"`
+	Price Money `json:"price" gorm:"embedded;embeddedPrefix:price_"`
+
+	// @Description Publisher of the book
+	// @Example "Charles Scribner's Sons"
+	Publisher string `json:"publisher,omitempty" gorm:"size:255" example:"Charles Scribner's Sons"`
+
+	// @Description Blurb of the book as plain text
+	// @Example "The story of the mysteriously wealthy Jay Gatsby and his love for Daisy Buchanan."
+	Description string `json:"description,omitempty" gorm:"type:text" example:"The story of the mysteriously wealthy Jay Gatsby and his love for Daisy Buchanan."`
+
+	// @Description Category the book is shelved under
+	// @Example "Fiction"
+	Category string `json:"category,omitempty" gorm:"size:100;index" example:"Fiction"`
+
+	// @Description Year the book was published
+	// @Example 1925
+	PublishedYear int `json:"published_year,omitempty" gorm:"index" example:"1925"`
+
+	// @Description ISBN-13 of the book without hyphens
+	// @Example "9780743273565"
+	ISBN13 *string `json:"isbn_13,omitempty" gorm:"column:isbn_13;size:13;uniqueIndex" example:"9780743273565"`
+
+	// @Description ISBN-10 of the book without hyphens, for 978-prefixed ISBNs
+	// @Example "0743273567"
+	ISBN10 *string `json:"isbn_10,omitempty" gorm:"column:isbn_10;size:10" example:"0743273567"`
+
+	// @Description Contributors credited on the book, in credit order
+	Authors []BookAuthor `json:"authors" gorm:"foreignKey:BookID"`
+
+	// @Description Revision of the book, incremented by every change and served as its ETag
+	// @Example 3
+	Version uint `json:"version" gorm:"not null;default:1" example:"3"`
 }
 
 // BookResponse represents the book response structure for API documentation
@@ -48,8 +82,38 @@ type BookResponse struct {
 	Author string `json:"author" example:"F. Scott Fitzgerald"`
 
 	// @Description Price of the book
-	// @Example "$15.99"
-	Price string `json:"price" example:"$15.99"`
+	Price Money `json:"price"`
+
+	// @Description Publisher of the book
+	// @Example "Charles Scribner's Sons"
+	Publisher string `json:"publisher,omitempty" example:"Charles Scribner's Sons"`
+
+	// @Description Blurb of the book as plain text
+	// @Example "The story of the mysteriously wealthy Jay Gatsby and his love for Daisy Buchanan."
+	Description string `json:"description,omitempty" example:"The story of the mysteriously wealthy Jay Gatsby and his love for Daisy Buchanan."`
+
+	// @Description Category the book is shelved under
+	// @Example "Fiction"
+	Category string `json:"category,omitempty" example:"Fiction"`
+
+	// @Description Year the book was published
+	// @Example 1925
+	PublishedYear int `json:"published_year,omitempty" example:"1925"`
+
+	// @Description ISBN-13 of the book without hyphens
+	// @Example "9780743273565"
+	ISBN13 string `json:"isbn_13,omitempty" example:"9780743273565"`
+
+	// @Description ISBN-10 of the book without hyphens, for 978-prefixed ISBNs
+	// @Example "0743273567"
+	ISBN10 string `json:"isbn_10,omitempty" example:"0743273567"`
+
+	// @Description Contributors credited on the book, in credit order
+	Authors []BookAuthor `json:"authors"`
+
+	// @Description Revision of the book, incremented by every change and served as its ETag
+	// @Example 3
+	Version uint `json:"version" example:"3"`
 
 	// @Description When the book was created
 	// @Example "2023-01-01T00:00:00Z"
@@ -58,6 +122,10 @@ type BookResponse struct {
 	// @Description When the book was last updated
 	// @Example "2023-01-01T00:00:00Z"
 	UpdatedAt time.Time `json:"updated_at" example:"2023-01-01T00:00:00Z"`
+
+	// @Description When the book was moved to the trash; only set in trash listings
+	// @Example "2023-02-01T00:00:00Z"
+	DeletedAt *time.Time `json:"deleted_at,omitempty" example:"2023-02-01T00:00:00Z"`
 }
 
 // BookRequest represents the book request structure for API documentation
@@ -65,97 +133,138 @@ type BookResponse struct {
 type BookRequest struct {
 	// @Description Title of the book
 	// @Example "The Great Gatsby"
-	Title string `json:"title" example:"The Great Gatsby" binding:"required"`
+	Title string `json:"title" example:"The Great Gatsby" binding:"required,max=255"`
 
-	// @Description Author of the book
+	// @Description Author of the book as free text; names separated by "&", "and" or ";" are credited individually. Required unless authors is given.
 	// @Example "F. Scott Fitzgerald"
-	Author string `json:"author" example:"F. Scott Fitzgerald" binding:"required"`
+	Author string `json:"author,omitempty" example:"F. Scott Fitzgerald" binding:"max=255"`
 
-	// @Description Price of the book
-	// @Example "$15.99"
-	Price string `json:"price" example:"$15.99" binding:"required"`
+	// @Description Existing authors to credit, in credit order; takes precedence over author
+	Authors []BookAuthorRequest `json:"authors,omitempty" binding:"max=20,dive"`
+
+	// @Description Price of the book; a legacy string such as "$15.99" is also accepted
+	Price Money `json:"price" binding:"required,valid"`
+
+	// @Description Publisher of the book
+	// @Example "Charles Scribner's Sons"
+	Publisher string `json:"publisher,omitempty" example:"Charles Scribner's Sons" binding:"max=255"`
+
+	// @Description Blurb of the book as plain text
+	// @Example "The story of the mysteriously wealthy Jay Gatsby and his love for Daisy Buchanan."
+	Description string `json:"description,omitempty" example:"The story of the mysteriously wealthy Jay Gatsby and his love for Daisy Buchanan." binding:"max=10000"`
+
+	// @Description Category the book is shelved under
+	// @Example "Fiction"
+	Category string `json:"category,omitempty" example:"Fiction" binding:"max=100"`
+
+	// @Description Year the book was published
+	// @Example 1925
+	PublishedYear int `json:"published_year,omitempty" example:"1925" binding:"min=1000,max=9999"`
+
+	// @Description ISBN-10 or ISBN-13, hyphens optional; stored as ISBN-13
+	// @Example "978-0-7432-7356-5"
+	ISBN string `json:"isbn,omitempty" example:"978-0-7432-7356-5" binding:"isbn"`
 }
 
-func init() {
-	config.ConnectDatabase()
-	DB = config.GetDatabase()
-	DB.AutoMigrate(&Book{})
+// ToBook copies the request fields into a new Book
+func (r *BookRequest) ToBook() *Book {
+	book := &Book{
+		Title:         strings.TrimSpace(r.Title),
+		Author:        strings.TrimSpace(r.Author),
+		Price:         r.Price,
+		Publisher:     strings.TrimSpace(r.Publisher),
+		Description:   strings.TrimSpace(r.Description),
+		Category:      strings.Join(strings.Fields(r.Category), " "),
+		PublishedYear: r.PublishedYear,
+	}
+	// The isbn binding rule has already verified the checksum
+	book.SetISBN(r.ISBN)
+	return book
 }
 
-func (b *Book) CreateBook() (*Book, *gorm.DB, error) {
-	// 	Before saving to the database:
-	// b.ID == 0 means the object is new and hasn't been saved yet.
-	// After saving to the database:
-	// GORM will set the ID to the value assigned by the database (usually a positive integer).
-	if b.ID != 0 {
-		// The record is not new, so you might want to handle this case
-		// For example, return nil or an error, or just skip creation
-		return nil, nil, fmt.Errorf("book already exists with ID %d", b.ID)
+// ToRequest returns the request that would recreate the book as it is; it
+// is the document PATCH requests are applied to
+func (b *Book) ToRequest() *BookRequest {
+	req := &BookRequest{
+		Title:         b.Title,
+		Author:        b.Author,
+		Price:         b.Price,
+		Publisher:     b.Publisher,
+		Description:   b.Description,
+		Category:      b.Category,
+		PublishedYear: b.PublishedYear,
+	}
+	for _, link := range b.Authors {
+		req.Authors = append(req.Authors, BookAuthorRequest{AuthorID: link.AuthorID, Role: link.Role})
 	}
-	db := DB.Create(&b) // This creates the record in the database
-	if db.Error != nil {
-		log.Println("Error creating book")
-		return nil, db, db.Error
+	if b.ISBN13 != nil {
+		req.ISBN = *b.ISBN13
 	}
-	return b, db, nil
+	return req
 }
 
-func GetAllBooks() (*gorm.DB, []Book) {
-	var Books []Book
-	// If you pass a slice of a type that is not mapped to a table in the DB (i.e., not a GORM model),
-	// GORM will not know how to map it to a table and will return an error.
-	// For example, if you define:
-	// type Foo struct { Bar string }
-	// and call DB.Find(&[]Foo{}), GORM will look for a table named "foos" and fail if it doesn't exist.
-	db := DB.Find(&Books) // This retrieves all records from the database
-
-	if db.Error == gorm.ErrRecordNotFound {
-		log.Println("No books found")
-		return nil, nil
-	}
+// Pagination describes where a page sits within a book listing
+// @Description Pagination metadata for list responses
+type Pagination struct {
+	// @Description Number of books matching the filters
+	// @Example 250
+	Total int64 `json:"total" example:"250"`
 
-	return db, Books
-}
+	// @Description Maximum number of books in the page
+	// @Example 20
+	Limit int `json:"limit" example:"20"`
 
-func GetBookById(Id int64) (*Book, *gorm.DB) {
-	var getBook Book
-	db := DB.Where("ID=?", Id).Find(&getBook)
+	// @Description Number of books skipped, when paginating by offset
+	// @Example 40
+	Offset int `json:"offset" example:"40"`
 
-	if db.Error == gorm.ErrRecordNotFound {
-		log.Println("Book not found")
-		return nil, db
-	}
+	// @Description Link to the next page
+	// @Example "/books?limit=20&offset=60"
+	Next string `json:"next,omitempty" example:"/books?limit=20&offset=60"`
 
-	return &getBook, db
+	// @Description Link to the previous page
+	// @Example "/books?limit=20&offset=20"
+	Prev string `json:"prev,omitempty" example:"/books?limit=20&offset=20"`
+
+	// @Description Opaque cursor for the next page
+	NextCursor string `json:"next_cursor,omitempty"`
+
+	// @Description Opaque cursor for the previous page
+	PrevCursor string `json:"prev_cursor,omitempty"`
 }
 
-func DeleteBook(ID int64) (*gorm.DB, Book, error) {
-	var book Book
-	db := DB.Where("ID=?", ID).Delete(book)
-	if db.Error == gorm.ErrRecordNotFound {
-		log.Println("Book not found")
-		return nil, book, db.Error
-	}
-	if db.Error != nil {
-		log.Println("Error deleting book")
-		return nil, book, db.Error
-	}
-	return db, book, nil
+// BookListResponse represents a page of books for API documentation
+// @Description Paginated book list model for API documentation
+type BookListResponse struct {
+	// @Description Books in the page
+	Data []BookResponse `json:"data"`
+
+	// @Description Pagination metadata
+	Pagination Pagination `json:"pagination"`
+
+	// @Description Counts of the books matching the filters, when facets are requested
+	Facets *BookFacets `json:"facets,omitempty"`
 }
 
-func UpdateBook(id int64, updatedData *Book) (*gorm.DB, *Book, error) {
-	var book Book
-	db := DB.First(&book, id)
+// BookSearchHit represents a book found by a search for API documentation
+// @Description Book found by a search, with its relevance and highlighted matches
+type BookSearchHit struct {
+	BookResponse
 
-	if db.Error == gorm.ErrRecordNotFound {
-		log.Println("Book not found")
-		return db, nil, db.Error
-	}
-	if db.Error != nil {
-		log.Println("Error updating book")
-		return db, nil, db.Error
-	}
+	// @Description Relevance of the book; higher is better, and scores are only comparable within one response
+	// @Example 7.25
+	Score float64 `json:"score" example:"7.25"`
+
+	// @Description The title, author and an excerpt of the description with matching words wrapped in <mark> tags, HTML-escaped, for the fields that match
+	Highlights map[string]string `json:"highlights"`
+}
+
+// BookSearchResponse represents a page of search results for API documentation
+// @Description Paginated search results model for API documentation
+type BookSearchResponse struct {
+	// @Description Books in the page, most relevant first
+	Data []BookSearchHit `json:"data"`
 
-	db = DB.Model(&book).Updates(updatedData)
-	return db, &book, nil
+	// @Description Pagination metadata
+	Pagination Pagination `json:"pagination"`
 }
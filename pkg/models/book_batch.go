@@ -0,0 +1,92 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ErrBatchAborted is the outcome of the operations of an atomic batch that
+// were rolled back, or never run, because another operation failed
+var ErrBatchAborted = errors.New("not applied because another operation of the batch failed")
+
+// BookOperationKind names the change a BookOperation makes
+type BookOperationKind string
+
+// Kinds of book operations
+const (
+	BookCreate BookOperationKind = "create"
+	BookUpdate BookOperationKind = "update"
+	BookDelete BookOperationKind = "delete"
+)
+
+// BookOperation is one change applied by BookRepository.Batch
+type BookOperation struct {
+	Kind BookOperationKind
+	// ID is the book to update or delete
+	ID uint
+	// Version, when not zero, must equal the stored version of the book to
+	// update or delete, as for Update and Delete
+	Version uint
+	// Book is the new book to create or the changes to apply on update. Its
+	// author links may name an author by Name without an AuthorID, as for
+	// Create and Update.
+	Book *Book
+}
+
+// BookOperationResult is the outcome of one BookOperation: the created,
+// updated or deleted book, or the error that made the operation fail
+type BookOperationResult struct {
+	Book *Book
+	Err  error
+}
+
+// abortBatch marks every operation of a rolled back atomic batch that had
+// succeeded as aborted
+func abortBatch(results []BookOperationResult) {
+	for i := range results {
+		if results[i].Err == nil {
+			results[i] = BookOperationResult{Err: ErrBatchAborted}
+		}
+	}
+}
+
+// batchFailed reports whether any operation of a batch has failed
+func batchFailed(results []BookOperationResult) bool {
+	for _, result := range results {
+		if result.Err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveAuthorLinks returns links with the links that name an author
+// without its ID credited to the author find returns for the name. Links
+// crediting an author again in the same role, as spelling variants of a
+// name do, are dropped and the rest renumbered. links are returned as is
+// when every link has an ID.
+func resolveAuthorLinks(links []BookAuthor, find func(name string) (*Author, error)) ([]BookAuthor, error) {
+	if !slices.ContainsFunc(links, func(link BookAuthor) bool { return link.AuthorID == 0 }) {
+		return links, nil
+	}
+	resolved := make([]BookAuthor, 0, len(links))
+	seen := make(map[string]bool)
+	for _, link := range links {
+		if link.AuthorID == 0 {
+			author, err := find(link.Name)
+			if err != nil {
+				return nil, err
+			}
+			link.AuthorID, link.Name = author.ID, author.Name
+		}
+		key := fmt.Sprintf("%d/%s", link.AuthorID, link.Role)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		link.Position = len(resolved)
+		resolved = append(resolved, link)
+	}
+	return resolved, nil
+}
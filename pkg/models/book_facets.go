@@ -0,0 +1,224 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Facets the book listing can count the matching books by
+const (
+	FacetAuthor   = "author"
+	FacetCategory = "category"
+	FacetPrice    = "price"
+	FacetYear     = "year"
+)
+
+// maxFacetValues caps the number of values the author and category facets
+// return; the values with the most books are kept
+const maxFacetValues = 20
+
+// priceBucketBounds are the boundaries of the price facet's buckets, in
+// major units of its currency
+var priceBucketBounds = []int64{10, 20, 50, 100}
+
+// BookFacets counts the books matching a listing's filters by author,
+// category, price and publication year; facets that were not requested, or
+// that no matching book has a value for, are empty
+// @Description Counts of the books matching the filters, by the requested facets
+type BookFacets struct {
+	// @Description Authors credited on the most books, most books first
+	Authors []AuthorFacet `json:"author,omitempty"`
+
+	// @Description Categories with the most books, most books first
+	Categories []CategoryFacet `json:"category,omitempty"`
+
+	// @Description Books priced in the facet currency, by price range from the cheapest
+	Prices []PriceFacet `json:"price,omitempty"`
+
+	// @Description Books by publication year, most recent first
+	Years []YearFacet `json:"year,omitempty"`
+}
+
+// AuthorFacet is the number of matching books crediting an author
+// @Description Number of matching books crediting an author
+type AuthorFacet struct {
+	// @Description Identifier of the author, for the author_id filter
+	// @Example 1
+	AuthorID uint `json:"author_id" example:"1"`
+
+	// @Description Display name of the author
+	// @Example "F. Scott Fitzgerald"
+	Name string `json:"name" example:"F. Scott Fitzgerald"`
+
+	// @Description Number of matching books
+	// @Example 4
+	Count int64 `json:"count" example:"4"`
+}
+
+// CategoryFacet is the number of matching books in a category
+// @Description Number of matching books in a category
+type CategoryFacet struct {
+	// @Description Category, for the category filter
+	// @Example "Fiction"
+	Category string `json:"category" example:"Fiction"`
+
+	// @Description Number of matching books
+	// @Example 120
+	Count int64 `json:"count" example:"120"`
+}
+
+// PriceFacet is the number of matching books in a price range
+// @Description Number of matching books priced within a range
+type PriceFacet struct {
+	// @Description Lowest price of the range, inclusive, for the min_price filter
+	Min Money `json:"min"`
+
+	// @Description Highest price of the range, inclusive, for the max_price filter; absent for the last range
+	Max *Money `json:"max,omitempty"`
+
+	// @Description Number of matching books
+	// @Example 35
+	Count int64 `json:"count" example:"35"`
+}
+
+// YearFacet is the number of matching books published in a year
+// @Description Number of matching books published in a year
+type YearFacet struct {
+	// @Description Publication year, for the year filter
+	// @Example 1925
+	Year int `json:"year" example:"1925"`
+
+	// @Description Number of matching books
+	// @Example 3
+	Count int64 `json:"count" example:"3"`
+}
+
+// ParseFacets parses a comma separated list of facet names such as
+// "author,price"
+func ParseFacets(spec string) ([]string, error) {
+	var facets []string
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.TrimSpace(name)
+		switch name {
+		case "":
+			continue
+		case FacetAuthor, FacetCategory, FacetPrice, FacetYear:
+			if !slices.Contains(facets, name) {
+				facets = append(facets, name)
+			}
+		default:
+			return nil, fmt.Errorf("%w: unknown facet %q; facets are author, category, price and year", ErrInvalidQuery, name)
+		}
+	}
+	return facets, nil
+}
+
+// facetCurrency returns the currency the price facet counts books in: that
+// of the price filters, or the facet currency of the query
+func (q BookQuery) facetCurrency() string {
+	switch {
+	case q.MinPrice != nil:
+		return q.MinPrice.Currency
+	case q.MaxPrice != nil:
+		return q.MaxPrice.Currency
+	case q.FacetCurrency != "":
+		return strings.ToUpper(q.FacetCurrency)
+	}
+	return DefaultCurrency
+}
+
+// priceBuckets returns the empty buckets of the price facet. Each bucket
+// ends one minor unit below where the next one starts, so that its bounds
+// can be used as min_price and max_price.
+func (q BookQuery) priceBuckets() []PriceFacet {
+	currency := q.facetCurrency()
+	scale := int64(math.Pow10(currencyExponents[currency]))
+	buckets := make([]PriceFacet, 0, len(priceBucketBounds)+1)
+	var lower int64
+	for _, bound := range priceBucketBounds {
+		upper := Money{Amount: bound*scale - 1, Currency: currency}
+		buckets = append(buckets, PriceFacet{Min: Money{Amount: lower, Currency: currency}, Max: &upper})
+		lower = bound * scale
+	}
+	return append(buckets, PriceFacet{Min: Money{Amount: lower, Currency: currency}})
+}
+
+// priceBucket returns the index of the price facet bucket amount falls in
+func priceBucket(buckets []PriceFacet, amount int64) int {
+	return sort.Search(len(buckets)-1, func(i int) bool { return amount <= buckets[i].Max.Amount })
+}
+
+// countFacets computes the requested facets of the matching books. It backs
+// repositories that evaluate queries in process.
+func (q BookQuery) countFacets(books []Book) *BookFacets {
+	facets := &BookFacets{}
+	for _, name := range q.Facets {
+		switch name {
+		case FacetAuthor:
+			counts := make(map[uint]int64)
+			for _, book := range books {
+				// A book crediting an author twice, e.g. as author and
+				// editor, counts once
+				seen := make(map[uint]bool)
+				for _, link := range book.Authors {
+					if !seen[link.AuthorID] {
+						seen[link.AuthorID] = true
+						counts[link.AuthorID]++
+					}
+				}
+			}
+			for id, count := range counts {
+				facets.Authors = append(facets.Authors, AuthorFacet{AuthorID: id, Count: count})
+			}
+			sort.Slice(facets.Authors, func(i, j int) bool {
+				a, b := facets.Authors[i], facets.Authors[j]
+				return a.Count > b.Count || a.Count == b.Count && a.AuthorID < b.AuthorID
+			})
+			facets.Authors = facets.Authors[:min(len(facets.Authors), maxFacetValues)]
+		case FacetCategory:
+			// Categories compare case-insensitively, like the category
+			// filter; the first spelling seen is reported
+			counts := make(map[string]*CategoryFacet)
+			for _, book := range books {
+				if book.Category == "" {
+					continue
+				}
+				key := strings.ToLower(book.Category)
+				if counts[key] == nil {
+					counts[key] = &CategoryFacet{Category: book.Category}
+				}
+				counts[key].Count++
+			}
+			for _, facet := range counts {
+				facets.Categories = append(facets.Categories, *facet)
+			}
+			sort.Slice(facets.Categories, func(i, j int) bool {
+				a, b := facets.Categories[i], facets.Categories[j]
+				return a.Count > b.Count || a.Count == b.Count && strings.ToLower(a.Category) < strings.ToLower(b.Category)
+			})
+			facets.Categories = facets.Categories[:min(len(facets.Categories), maxFacetValues)]
+		case FacetPrice:
+			facets.Prices = q.priceBuckets()
+			for _, book := range books {
+				if book.Price.Currency == q.facetCurrency() {
+					facets.Prices[priceBucket(facets.Prices, book.Price.Amount)].Count++
+				}
+			}
+		case FacetYear:
+			counts := make(map[int]int64)
+			for _, book := range books {
+				if book.PublishedYear != 0 {
+					counts[book.PublishedYear]++
+				}
+			}
+			for year, count := range counts {
+				facets.Years = append(facets.Years, YearFacet{Year: year, Count: count})
+			}
+			sort.Slice(facets.Years, func(i, j int) bool { return facets.Years[i].Year > facets.Years[j].Year })
+		}
+	}
+	return facets
+}
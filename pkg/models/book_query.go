@@ -0,0 +1,388 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultPageSize is used when a BookQuery does not specify a limit
+	DefaultPageSize = 20
+	// MaxPageSize caps the number of books returned by a single List call
+	MaxPageSize = 100
+)
+
+// ErrInvalidQuery is returned when a listing query cannot be executed as given
+var ErrInvalidQuery = errors.New("invalid query")
+
+// SortField is one term of a sort specification such as "title" or "-price"
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// BookQuery describes which books List should return and in what order
+type BookQuery struct {
+	// Title and Author are case-insensitive substring filters
+	Title  string
+	Author string
+	// AuthorID restricts the listing to books crediting that author, when non-zero
+	AuthorID uint
+	// Category is a case-insensitive exact match on the category
+	Category string
+	// Year restricts the listing to books published that year, when non-zero
+	Year int
+	// MinPrice and MaxPrice bound the price, inclusive, when non-nil. Both
+	// must use the same currency and only books priced in it are matched.
+	MinPrice *Money
+	MaxPrice *Money
+
+	Sort   []SortField
+	Limit  int
+	Offset int
+	// Cursor, when set, switches to keyset pagination and Offset is ignored
+	Cursor *Cursor
+
+	// Facets lists the facets List counts the matching books by
+	Facets []string
+	// FacetCurrency is the currency of the price facet when no price filter
+	// sets one; DefaultCurrency when empty
+	FacetCurrency string
+}
+
+// BookPage is one page of a book listing
+type BookPage struct {
+	Books []Book
+	// Total is the number of books matching the filters, ignoring pagination
+	Total int64
+	// Next and Prev point at the adjacent pages, or are nil at either end
+	Next *Cursor
+	Prev *Cursor
+	// Facets counts the books matching the filters by the facets of the
+	// query; nil when none were requested
+	Facets *BookFacets
+}
+
+// Cursor is a keyset position within an ordered book listing. It is handed
+// to clients as an opaque string produced by Encode.
+type Cursor struct {
+	// Sort is the canonical sort specification the cursor was issued for
+	Sort string `json:"s"`
+	// Keys holds the sort key values of the boundary book
+	Keys []string `json:"k"`
+	// ID breaks ties between books with equal sort keys
+	ID uint `json:"i"`
+	// Backward selects the page before the boundary book instead of after it
+	Backward bool `json:"b,omitempty"`
+}
+
+// Encode returns the opaque string form of the cursor
+func (c *Cursor) Encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeCursor parses a cursor produced by Encode
+func DecodeCursor(s string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+	var c Cursor
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+	return &c, nil
+}
+
+// bookSortKey describes a field books can be ordered by
+type bookSortKey struct {
+	// column is the SQL expression used in ORDER BY and keyset conditions
+	column string
+	// key extracts the typed sort key from a book
+	key func(b *Book) interface{}
+	// parse converts the cursor representation of a key back to its typed form
+	parse func(s string) (interface{}, error)
+}
+
+var bookSortKeys = map[string]bookSortKey{
+	"id": {
+		column: "id",
+		key:    func(b *Book) interface{} { return uint64(b.ID) },
+		parse:  func(s string) (interface{}, error) { return strconv.ParseUint(s, 10, 64) },
+	},
+	"title": {
+		column: "title",
+		key:    func(b *Book) interface{} { return b.Title },
+		parse:  func(s string) (interface{}, error) { return s, nil },
+	},
+	"author": {
+		column: "author",
+		key:    func(b *Book) interface{} { return b.Author },
+		parse:  func(s string) (interface{}, error) { return s, nil },
+	},
+	"price": {
+		column: "price_amount",
+		key:    func(b *Book) interface{} { return b.Price.Amount },
+		parse:  func(s string) (interface{}, error) { return strconv.ParseInt(s, 10, 64) },
+	},
+	"created_at": {
+		column: "created_at",
+		key:    func(b *Book) interface{} { return b.CreatedAt },
+		parse:  func(s string) (interface{}, error) { return time.Parse(time.RFC3339Nano, s) },
+	},
+}
+
+// formatSortKey renders a typed sort key for storage in a cursor
+func formatSortKey(v interface{}) string {
+	switch v := v.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// compareSortKeys orders two typed sort keys of the same field. Strings
+// compare case-insensitively to match the default MySQL collation.
+func compareSortKeys(a, b interface{}) int {
+	switch a := a.(type) {
+	case int64:
+		return compareOrdered(a, b.(int64))
+	case uint64:
+		return compareOrdered(a, b.(uint64))
+	case string:
+		return compareOrdered(strings.ToLower(a), strings.ToLower(b.(string)))
+	case time.Time:
+		return a.Compare(b.(time.Time))
+	}
+	return 0
+}
+
+func compareOrdered[T int64 | uint64 | string](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// ParseSort parses a comma separated sort specification such as "title,-price".
+// A leading "-" selects descending order.
+func ParseSort(spec string) ([]SortField, error) {
+	var fields []SortField
+	seen := make(map[string]bool)
+	for _, term := range strings.Split(spec, ",") {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+		field := SortField{Field: strings.TrimPrefix(strings.TrimPrefix(term, "-"), "+")}
+		field.Desc = strings.HasPrefix(term, "-")
+		if _, ok := bookSortKeys[field.Field]; !ok {
+			return nil, fmt.Errorf("%w: cannot sort by %q", ErrInvalidQuery, field.Field)
+		}
+		if seen[field.Field] {
+			return nil, fmt.Errorf("%w: duplicate sort field %q", ErrInvalidQuery, field.Field)
+		}
+		seen[field.Field] = true
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// normalize fills in defaults and makes the sort order total by appending id
+func (q BookQuery) normalize() (BookQuery, error) {
+	if q.Limit <= 0 {
+		q.Limit = DefaultPageSize
+	}
+	if q.Limit > MaxPageSize {
+		q.Limit = MaxPageSize
+	}
+	if q.Offset < 0 {
+		return q, fmt.Errorf("%w: offset must not be negative", ErrInvalidQuery)
+	}
+	if q.MinPrice != nil && q.MaxPrice != nil {
+		if q.MinPrice.Currency != q.MaxPrice.Currency {
+			return q, fmt.Errorf("%w: min_price and max_price use different currencies", ErrInvalidQuery)
+		}
+		if q.MinPrice.Amount > q.MaxPrice.Amount {
+			return q, fmt.Errorf("%w: min_price is greater than max_price", ErrInvalidQuery)
+		}
+	}
+
+	order := make([]SortField, 0, len(q.Sort)+1)
+	hasID := false
+	for _, f := range q.Sort {
+		if _, ok := bookSortKeys[f.Field]; !ok {
+			return q, fmt.Errorf("%w: cannot sort by %q", ErrInvalidQuery, f.Field)
+		}
+		if f.Field == "id" {
+			hasID = true
+		}
+		order = append(order, f)
+		if hasID {
+			// id is unique, later terms can never take effect
+			break
+		}
+	}
+	if !hasID {
+		order = append(order, SortField{Field: "id"})
+	}
+	q.Sort = order
+
+	if q.Cursor != nil {
+		if q.Cursor.Sort != q.sortSpec() || len(q.Cursor.Keys) != len(q.Sort)-1 {
+			return q, fmt.Errorf("%w: cursor does not match the requested sort order", ErrInvalidQuery)
+		}
+		q.Offset = 0
+	}
+	return q, nil
+}
+
+// sortSpec returns the canonical form of the query's sort order
+func (q BookQuery) sortSpec() string {
+	terms := make([]string, len(q.Sort))
+	for i, f := range q.Sort {
+		if f.Desc {
+			terms[i] = "-" + f.Field
+		} else {
+			terms[i] = f.Field
+		}
+	}
+	return strings.Join(terms, ",")
+}
+
+// cursorFor returns a cursor positioned at book. The trailing id sort term
+// is carried in Cursor.ID rather than in Keys.
+func (q BookQuery) cursorFor(book *Book, backward bool) *Cursor {
+	keys := make([]string, 0, len(q.Sort)-1)
+	for _, f := range q.Sort[:len(q.Sort)-1] {
+		keys = append(keys, formatSortKey(bookSortKeys[f.Field].key(book)))
+	}
+	return &Cursor{Sort: q.sortSpec(), Keys: keys, ID: book.ID, Backward: backward}
+}
+
+// compareBooks orders two books according to the query's sort order
+func (q BookQuery) compareBooks(a, b *Book) int {
+	for _, f := range q.Sort {
+		key := bookSortKeys[f.Field].key
+		c := compareSortKeys(key(a), key(b))
+		if f.Desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// matches reports whether book satisfies the query's filters
+func (q BookQuery) matches(book *Book) bool {
+	if q.Title != "" && !strings.Contains(strings.ToLower(book.Title), strings.ToLower(q.Title)) {
+		return false
+	}
+	if q.Author != "" && !strings.Contains(strings.ToLower(book.Author), strings.ToLower(q.Author)) {
+		return false
+	}
+	if q.AuthorID != 0 && !slices.ContainsFunc(book.Authors, func(a BookAuthor) bool { return a.AuthorID == q.AuthorID }) {
+		return false
+	}
+	if q.Category != "" && !strings.EqualFold(book.Category, q.Category) {
+		return false
+	}
+	if q.Year != 0 && book.PublishedYear != q.Year {
+		return false
+	}
+	if q.MinPrice != nil && (book.Price.Currency != q.MinPrice.Currency || book.Price.Amount < q.MinPrice.Amount) {
+		return false
+	}
+	if q.MaxPrice != nil && (book.Price.Currency != q.MaxPrice.Currency || book.Price.Amount > q.MaxPrice.Amount) {
+		return false
+	}
+	return true
+}
+
+// cursorKeys parses the cursor's key values into typed sort keys, including
+// the trailing id term
+func (q BookQuery) cursorKeys() ([]interface{}, error) {
+	keys := make([]interface{}, 0, len(q.Sort))
+	for i, f := range q.Sort[:len(q.Sort)-1] {
+		v, err := bookSortKeys[f.Field].parse(q.Cursor.Keys[i])
+		if err != nil {
+			return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+		}
+		keys = append(keys, v)
+	}
+	return append(keys, uint64(q.Cursor.ID)), nil
+}
+
+// afterCursor reports whether book lies beyond the cursor in the direction
+// the cursor points
+func (q BookQuery) afterCursor(book *Book, keys []interface{}) bool {
+	for i, f := range q.Sort {
+		c := compareSortKeys(bookSortKeys[f.Field].key(book), keys[i])
+		if f.Desc != q.Cursor.Backward {
+			c = -c
+		}
+		if c != 0 {
+			return c > 0
+		}
+	}
+	return false
+}
+
+// paginateSorted cuts one page out of the complete, filtered and sorted
+// list of matching books. It backs repositories that evaluate queries in
+// process.
+func (q BookQuery) paginateSorted(books []Book) (*BookPage, error) {
+	page := &BookPage{Total: int64(len(books))}
+	if len(q.Facets) > 0 {
+		page.Facets = q.countFacets(books)
+	}
+
+	start, end := q.Offset, q.Offset+q.Limit
+	if q.Cursor != nil {
+		keys, err := q.cursorKeys()
+		if err != nil {
+			return nil, err
+		}
+		// Books beyond the cursor in its direction form a contiguous run at
+		// one end of the sorted list; find where that run begins.
+		boundary := sort.Search(len(books), func(i int) bool {
+			return q.afterCursor(&books[i], keys) != q.Cursor.Backward
+		})
+		if q.Cursor.Backward {
+			start, end = boundary-q.Limit, boundary
+		} else {
+			start, end = boundary, boundary+q.Limit
+		}
+	}
+	start = max(0, min(start, len(books)))
+	end = max(start, min(end, len(books)))
+
+	page.Books = books[start:end]
+	if len(page.Books) > 0 {
+		if end < len(books) {
+			page.Next = q.cursorFor(&page.Books[len(page.Books)-1], false)
+		}
+		if start > 0 {
+			page.Prev = q.cursorFor(&page.Books[0], true)
+		}
+	}
+	return page, nil
+}
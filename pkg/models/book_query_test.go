@@ -0,0 +1,234 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// seedBooks stores books with the given titles and prices in USD cents, in
+// order, and returns the repository
+func seedBooks(t *testing.T, books ...Book) *MemoryBookRepository {
+	t.Helper()
+	repo := NewMemoryBookRepository(NewMemoryAuthorRepository())
+	for i := range books {
+		if books[i].Price.Currency == "" {
+			books[i].Price.Currency = "USD"
+		}
+		if err := repo.Create(context.Background(), &books[i]); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+	return repo
+}
+
+func pageIDs(books []Book) []uint {
+	ids := make([]uint, len(books))
+	for i, book := range books {
+		ids[i] = book.ID
+	}
+	return ids
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	c := &Cursor{Sort: "title,-price,id", Keys: []string{"Emma", "1599"}, ID: 7, Backward: true}
+	got, err := DecodeCursor(c.Encode())
+	if err != nil {
+		t.Fatalf("DecodeCursor: %v", err)
+	}
+	if !reflect.DeepEqual(got, c) {
+		t.Errorf("DecodeCursor(Encode()) = %+v, want %+v", got, c)
+	}
+	for _, bad := range []string{"!!!", "bm90IGpzb24"} {
+		if _, err := DecodeCursor(bad); !errors.Is(err, ErrInvalidQuery) {
+			t.Errorf("DecodeCursor(%q) = %v, want ErrInvalidQuery", bad, err)
+		}
+	}
+}
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    []SortField
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"title", []SortField{{Field: "title"}}, false},
+		{"title, -price,+id", []SortField{{Field: "title"}, {Field: "price", Desc: true}, {Field: "id"}}, false},
+		{"isbn", nil, true},
+		{"title,-title", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseSort(tt.spec)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidQuery) {
+				t.Errorf("ParseSort(%q) error = %v, want ErrInvalidQuery", tt.spec, err)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseSort(%q) = %v, %v, want %v", tt.spec, got, err, tt.want)
+		}
+	}
+}
+
+// TestKeysetPagination walks every listing forward and back by cursor and
+// checks the pages add up to the offset listing, including across books
+// with equal sort keys
+func TestKeysetPagination(t *testing.T) {
+	repo := seedBooks(t,
+		Book{Title: "Emma", Price: Money{Amount: 899}},
+		Book{Title: "dune", Price: Money{Amount: 1599}},
+		Book{Title: "Dune", Price: Money{Amount: 899}},
+		Book{Title: "Beloved", Price: Money{Amount: 1250}},
+		Book{Title: "Ulysses", Price: Money{Amount: 1599}},
+		Book{Title: "emma", Price: Money{Amount: 500}},
+		Book{Title: "Carrie", Price: Money{Amount: 899}},
+	)
+	ctx := context.Background()
+	sorts := []struct {
+		spec string
+		want []uint
+	}{
+		{"", []uint{1, 2, 3, 4, 5, 6, 7}},
+		{"-id", []uint{7, 6, 5, 4, 3, 2, 1}},
+		// Titles compare case-insensitively; ties fall back to the ID
+		{"title", []uint{4, 7, 2, 3, 1, 6, 5}},
+		{"-price,title", []uint{2, 5, 4, 7, 3, 1, 6}},
+		{"price,-title", []uint{6, 1, 3, 7, 4, 5, 2}},
+	}
+	for _, tt := range sorts {
+		sort, err := ParseSort(tt.spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, limit := range []int{1, 2, 3, 7, 10} {
+			query := BookQuery{Sort: sort, Limit: limit}
+
+			var forward []uint
+			var last *BookPage
+			for page, err := repo.List(ctx, query); ; page, err = repo.List(ctx, query) {
+				if err != nil {
+					t.Fatalf("sort %q limit %d: List: %v", tt.spec, limit, err)
+				}
+				if page.Total != int64(len(tt.want)) {
+					t.Errorf("sort %q limit %d: total %d, want %d", tt.spec, limit, page.Total, len(tt.want))
+				}
+				forward = append(forward, pageIDs(page.Books)...)
+				last = page
+				if page.Next == nil {
+					break
+				}
+				query.Cursor = page.Next
+				if len(forward) > len(tt.want) {
+					t.Fatalf("sort %q limit %d: pagination does not end", tt.spec, limit)
+				}
+			}
+			if !reflect.DeepEqual(forward, tt.want) {
+				t.Errorf("sort %q limit %d: forward pages %v, want %v", tt.spec, limit, forward, tt.want)
+			}
+
+			// Walk back from the last page, prepending each previous page
+			backward := pageIDs(last.Books)
+			for prev := last.Prev; prev != nil; {
+				page, err := repo.List(ctx, BookQuery{Sort: sort, Limit: limit, Cursor: prev})
+				if err != nil {
+					t.Fatalf("sort %q limit %d: List: %v", tt.spec, limit, err)
+				}
+				if len(page.Books) != limit {
+					t.Errorf("sort %q limit %d: backward page of %d books", tt.spec, limit, len(page.Books))
+				}
+				backward = append(pageIDs(page.Books), backward...)
+				prev = page.Prev
+				if len(backward) > len(tt.want) {
+					t.Fatalf("sort %q limit %d: backward pagination does not end", tt.spec, limit)
+				}
+			}
+			if !reflect.DeepEqual(backward, tt.want) {
+				t.Errorf("sort %q limit %d: backward pages %v, want %v", tt.spec, limit, backward, tt.want)
+			}
+		}
+	}
+}
+
+func TestKeysetPaginationSkipsChangedBooks(t *testing.T) {
+	repo := seedBooks(t, Book{Title: "A"}, Book{Title: "B"}, Book{Title: "C"}, Book{Title: "D"})
+	ctx := context.Background()
+	query := BookQuery{Sort: []SortField{{Field: "title"}}, Limit: 2}
+	first, err := repo.List(ctx, query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Deleting a book of the first page must not shift the second one, as
+	// an offset would
+	if _, err := repo.Delete(ctx, 1, 0); err != nil {
+		t.Fatal(err)
+	}
+	query.Cursor = first.Next
+	second, err := repo.List(ctx, query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := pageIDs(second.Books); !reflect.DeepEqual(got, []uint{3, 4}) {
+		t.Errorf("second page %v, want [3 4]", got)
+	}
+}
+
+func TestListRejectsInvalidQueries(t *testing.T) {
+	repo := seedBooks(t, Book{Title: "A"}, Book{Title: "B"})
+	ctx := context.Background()
+	byTitle := []SortField{{Field: "title"}}
+	page, err := repo.List(ctx, BookQuery{Sort: byTitle, Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	usd := func(amount int64) *Money { return &Money{Amount: amount, Currency: "USD"} }
+
+	tests := []struct {
+		name  string
+		query BookQuery
+	}{
+		{"cursor of another sort order", BookQuery{Sort: []SortField{{Field: "price"}}, Cursor: page.Next}},
+		{"cursor with bad keys", BookQuery{Sort: []SortField{{Field: "price"}}, Cursor: &Cursor{Sort: "price,id", Keys: []string{"cheap"}}}},
+		{"negative offset", BookQuery{Offset: -1}},
+		{"unknown sort field", BookQuery{Sort: []SortField{{Field: "isbn"}}}},
+		{"min above max", BookQuery{MinPrice: usd(500), MaxPrice: usd(100)}},
+		{"mixed currencies", BookQuery{MinPrice: usd(100), MaxPrice: &Money{Amount: 500, Currency: "EUR"}}},
+	}
+	for _, tt := range tests {
+		if _, err := repo.List(ctx, tt.query); !errors.Is(err, ErrInvalidQuery) {
+			t.Errorf("%s: List = %v, want ErrInvalidQuery", tt.name, err)
+		}
+	}
+}
+
+func TestListFilters(t *testing.T) {
+	repo := seedBooks(t,
+		Book{Title: "The Great Gatsby", Author: "F. Scott Fitzgerald", Category: "Fiction", PublishedYear: 1925, Price: Money{Amount: 1599}},
+		Book{Title: "Tender Is the Night", Author: "F. Scott Fitzgerald", Category: "fiction", PublishedYear: 1934, Price: Money{Amount: 999}},
+		Book{Title: "A Brief History of Time", Author: "Stephen Hawking", Category: "Science", PublishedYear: 1988, Price: Money{Amount: 1899}},
+		Book{Title: "Le Petit Prince", Author: "Antoine de Saint-Exupéry", Category: "Fiction", PublishedYear: 1943, Price: Money{Amount: 800, Currency: "EUR"}},
+	)
+	tests := []struct {
+		name  string
+		query BookQuery
+		want  []uint
+	}{
+		{"title", BookQuery{Title: "GREAT"}, []uint{1}},
+		{"author", BookQuery{Author: "fitzgerald"}, []uint{1, 2}},
+		{"price range", BookQuery{MinPrice: &Money{Amount: 1000, Currency: "USD"}, MaxPrice: &Money{Amount: 1599, Currency: "USD"}}, []uint{1}},
+		{"price currency", BookQuery{MinPrice: &Money{Amount: 0, Currency: "EUR"}}, []uint{4}},
+		{"category ignores case", BookQuery{Category: "FICTION"}, []uint{1, 2, 4}},
+		{"year", BookQuery{Year: 1988}, []uint{3}},
+	}
+	for _, tt := range tests {
+		page, err := repo.List(context.Background(), tt.query)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := pageIDs(page.Books); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
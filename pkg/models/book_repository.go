@@ -0,0 +1,82 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrBookNotFound is returned by a BookRepository when no book matches the given ID
+var ErrBookNotFound = errors.New("book not found")
+
+// ErrBookExists is returned by Create when the book already carries an ID
+var ErrBookExists = errors.New("book already exists")
+
+// ErrBookVersionMismatch is returned by Update and Delete when the book has
+// changed since the version the caller expected
+var ErrBookVersionMismatch = errors.New("book has been modified since it was read")
+
+// ErrBookNotDeleted is returned by Restore and Purge for a book that is not in the trash
+var ErrBookNotDeleted = errors.New("book is not in the trash")
+
+// BookRepository abstracts the storage of books so that controllers do not
+// depend on a particular database. Implementations must be safe for
+// concurrent use.
+type BookRepository interface {
+	// Create stores a new book, including its author links, and assigns its
+	// ID. An author link may name an author by Name without an AuthorID; it
+	// is matched to the author with the same normalized name, which is
+	// created when missing, as part of storing the book, so a Create that
+	// fails leaves no new authors behind.
+	Create(ctx context.Context, book *Book) error
+	// Get returns the book with the given ID or ErrBookNotFound
+	Get(ctx context.Context, id uint) (*Book, error)
+	// GetByISBN returns the book with the given normalized ISBN-13 or ErrBookNotFound
+	GetByISBN(ctx context.Context, isbn13 string) (*Book, error)
+	// List returns one page of the books matching query, with the facets it
+	// requests counted over every matching book
+	List(ctx context.Context, query BookQuery) (*BookPage, error)
+	// Each calls fn with every book matching the filters of query, in its
+	// sort order and ignoring its pagination. Books are loaded a batch at a
+	// time, so the whole catalog is never held in memory. It stops at the
+	// first error returned by fn and returns it.
+	Each(ctx context.Context, query BookQuery, fn func(*Book) error) error
+	// Search returns one page of the books matching every term of search,
+	// most relevant first; ties are broken by ID
+	Search(ctx context.Context, search BookSearch) (*SearchPage, error)
+	// Update replaces the title, author, price, publisher, description,
+	// category, publication year, ISBNs and author links of the book with the
+	// given ID by those of changes, clearing the ones changes leaves empty. A
+	// non-zero changes.Version must equal the stored version, or
+	// ErrBookVersionMismatch is returned; the stored version is incremented
+	// either way. Author links naming an author without its ID are resolved
+	// as for Create.
+	Update(ctx context.Context, id uint, changes *Book) (*Book, error)
+	// Delete moves the book with the given ID to the trash and returns it. A
+	// non-zero version must equal the stored version, or ErrBookVersionMismatch
+	// is returned. Books in the trash are invisible to every other method but
+	// ListDeleted, Restore and Purge.
+	Delete(ctx context.Context, id uint, version uint) (*Book, error)
+	// Batch applies ops in order, like the corresponding calls to Create,
+	// Update and Delete, and returns the outcome of each. When atomic is set
+	// either every operation succeeds or none is stored, and the operations
+	// that did not fail report ErrBatchAborted. Otherwise each operation
+	// succeeds or fails on its own. Authors named by the author links of the
+	// books are created along with the operations that credit them, so an
+	// operation that fails or is rolled back leaves no new authors behind.
+	// The error is only set when the outcome of the batch is
+	// unknown, e.g. because its transaction failed to commit.
+	Batch(ctx context.Context, ops []BookOperation, atomic bool) ([]BookOperationResult, error)
+	// ListDeleted returns a page of the books in the trash, most recently
+	// deleted first
+	ListDeleted(ctx context.Context, limit, offset int) (*BookPage, error)
+	// Restore takes the book with the given ID out of the trash, incrementing
+	// its version, and returns it, or ErrBookNotDeleted if it is not in the trash
+	Restore(ctx context.Context, id uint) (*Book, error)
+	// Purge permanently removes a book in the trash along with its author
+	// links and stock ledger, or returns ErrBookNotDeleted
+	Purge(ctx context.Context, id uint) (*Book, error)
+	// PurgeDeletedBefore purges every book moved to the trash before cutoff
+	// and returns how many were removed
+	PurgeDeletedBefore(ctx context.Context, cutoff time.Time) (int64, error)
+}
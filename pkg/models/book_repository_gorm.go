@@ -0,0 +1,717 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GormBookRepository is a BookRepository backed by a GORM database
+type GormBookRepository struct {
+	db *gorm.DB
+}
+
+// NewGormBookRepository returns a BookRepository that stores books in db
+func NewGormBookRepository(db *gorm.DB) *GormBookRepository {
+	return &GormBookRepository{db: db}
+}
+
+func (r *GormBookRepository) Create(ctx context.Context, book *Book) error {
+	// b.ID == 0 means the object is new and hasn't been saved yet;
+	// GORM sets the ID to the value assigned by the database.
+	if book.ID != 0 {
+		return fmt.Errorf("%w with ID %d", ErrBookExists, book.ID)
+	}
+	book.Version = 1
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		links, err := resolveAuthorLinks(book.Authors, authorFinder(tx))
+		if err != nil {
+			return translateAuthorError(err)
+		}
+		book.Authors = links
+		return tx.Create(book).Error
+	})
+	return translateGormError(err)
+}
+
+func (r *GormBookRepository) Get(ctx context.Context, id uint) (*Book, error) {
+	var book Book
+	if err := r.db.WithContext(ctx).Scopes(preloadAuthors).First(&book, id).Error; err != nil {
+		return nil, translateGormError(err)
+	}
+	return &book, nil
+}
+
+func (r *GormBookRepository) GetByISBN(ctx context.Context, isbn13 string) (*Book, error) {
+	var book Book
+	if err := r.db.WithContext(ctx).Scopes(preloadAuthors).Where("isbn_13 = ?", isbn13).First(&book).Error; err != nil {
+		return nil, translateGormError(err)
+	}
+	return &book, nil
+}
+
+func (r *GormBookRepository) List(ctx context.Context, query BookQuery) (*BookPage, error) {
+	q, err := query.normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := r.db.WithContext(ctx).Model(&Book{}).Scopes(q.filterScope)
+	page := &BookPage{}
+	if err := filtered.Session(&gorm.Session{}).Count(&page.Total).Error; err != nil {
+		return nil, err
+	}
+	if len(q.Facets) > 0 {
+		if page.Facets, err = r.countFacets(ctx, q); err != nil {
+			return nil, err
+		}
+	}
+
+	backward := q.Cursor != nil && q.Cursor.Backward
+	find := filtered.Session(&gorm.Session{}).Order(q.orderClause(backward)).Limit(q.Limit + 1)
+	if q.Cursor != nil {
+		keys, err := q.cursorKeys()
+		if err != nil {
+			return nil, err
+		}
+		cond, args := q.keysetCondition(keys)
+		find = find.Where(cond, args...)
+	} else {
+		find = find.Offset(q.Offset)
+	}
+
+	var books []Book
+	if err := find.Scopes(preloadAuthors).Find(&books).Error; err != nil {
+		return nil, err
+	}
+	// One extra row was fetched to learn whether another page follows
+	hasMore := len(books) > q.Limit
+	if hasMore {
+		books = books[:q.Limit]
+	}
+	if backward {
+		slices.Reverse(books)
+	}
+	page.Books = books
+
+	if len(books) > 0 {
+		first, last := &books[0], &books[len(books)-1]
+		switch {
+		case q.Cursor == nil:
+			if hasMore {
+				page.Next = q.cursorFor(last, false)
+			}
+			if q.Offset > 0 {
+				page.Prev = q.cursorFor(first, true)
+			}
+		case backward:
+			page.Next = q.cursorFor(last, false)
+			if hasMore {
+				page.Prev = q.cursorFor(first, true)
+			}
+		default:
+			if hasMore {
+				page.Next = q.cursorFor(last, false)
+			}
+			page.Prev = q.cursorFor(first, true)
+		}
+	}
+	return page, nil
+}
+
+// countFacets computes the requested facets of the books matching q with
+// one grouped query per facet
+func (r *GormBookRepository) countFacets(ctx context.Context, q BookQuery) (*BookFacets, error) {
+	facets := &BookFacets{}
+	for _, name := range q.Facets {
+		filtered := r.db.WithContext(ctx).Model(&Book{}).Scopes(q.filterScope)
+		var err error
+		switch name {
+		case FacetAuthor:
+			err = r.db.WithContext(ctx).Model(&BookAuthor{}).
+				Select("author_id, COUNT(DISTINCT book_id) AS count").
+				Where("book_id IN (?)", filtered.Select("id")).
+				Group("author_id").Order("count DESC, author_id ASC").Limit(maxFacetValues).
+				Scan(&facets.Authors).Error
+		case FacetCategory:
+			err = filtered.Select("MIN(category) AS category, COUNT(*) AS count").
+				Where("category <> ''").
+				Group("LOWER(category)").Order("count DESC, LOWER(category) ASC").Limit(maxFacetValues).
+				Scan(&facets.Categories).Error
+		case FacetPrice:
+			facets.Prices = q.priceBuckets()
+			// Each book is numbered with its bucket, the buckets counted
+			bucket := "CASE"
+			var args []interface{}
+			for i, b := range facets.Prices[:len(facets.Prices)-1] {
+				bucket += fmt.Sprintf(" WHEN price_amount <= ? THEN %d", i)
+				args = append(args, b.Max.Amount)
+			}
+			bucket += fmt.Sprintf(" ELSE %d END", len(facets.Prices)-1)
+			var counts []struct {
+				Bucket int
+				Count  int64
+			}
+			err = filtered.Select(bucket+" AS bucket, COUNT(*) AS count", args...).
+				Where("price_currency = ?", q.facetCurrency()).
+				Group("bucket").Scan(&counts).Error
+			for _, c := range counts {
+				facets.Prices[c.Bucket].Count = c.Count
+			}
+		case FacetYear:
+			err = filtered.Select("published_year AS year, COUNT(*) AS count").
+				Where("published_year > 0").
+				Group("published_year").Order("published_year DESC").
+				Scan(&facets.Years).Error
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return facets, nil
+}
+
+// eachBatchSize is the number of books Each loads per query
+const eachBatchSize = 500
+
+func (r *GormBookRepository) Each(ctx context.Context, query BookQuery, fn func(*Book) error) error {
+	q, err := query.normalize()
+	if err != nil {
+		return err
+	}
+	// Batches follow each other by keyset, which stays fast deep into the
+	// catalog where an offset would not
+	q.Cursor = nil
+	for {
+		find := r.db.WithContext(ctx).Model(&Book{}).Scopes(q.filterScope).
+			Order(q.orderClause(false)).Limit(eachBatchSize)
+		if q.Cursor != nil {
+			keys, err := q.cursorKeys()
+			if err != nil {
+				return err
+			}
+			cond, args := q.keysetCondition(keys)
+			find = find.Where(cond, args...)
+		}
+		var books []Book
+		if err := find.Scopes(preloadAuthors).Find(&books).Error; err != nil {
+			return err
+		}
+		for i := range books {
+			if err := fn(&books[i]); err != nil {
+				return err
+			}
+		}
+		if len(books) < eachBatchSize {
+			return nil
+		}
+		q.Cursor = q.cursorFor(&books[len(books)-1], false)
+	}
+}
+
+// searchScore is the relevance of a book in MySQL full-text searches;
+// title matches weigh more, as they do in the in-process index
+const searchScore = "3 * MATCH(title) AGAINST (? IN BOOLEAN MODE) + MATCH(title, author, description) AGAINST (? IN BOOLEAN MODE)"
+
+// Search uses the FULLTEXT indexes of MySQL. Other databases have none, so
+// the live books are indexed in process for each search, which only suits
+// the small catalogs of development setups.
+func (r *GormBookRepository) Search(ctx context.Context, search BookSearch) (*SearchPage, error) {
+	s, err := search.normalize()
+	if err != nil {
+		return nil, err
+	}
+	if r.db.Dialector.Name() != "mysql" {
+		return r.searchInProcess(ctx, s)
+	}
+
+	terms := make([]string, len(s.Terms))
+	for i, term := range s.Terms {
+		terms[i] = term.booleanMode()
+	}
+	against := strings.Join(terms, " ")
+	matching := r.db.WithContext(ctx).Model(&Book{}).
+		Where("MATCH(title, author, description) AGAINST (? IN BOOLEAN MODE)", against)
+	page := &SearchPage{}
+	if err := matching.Session(&gorm.Session{}).Count(&page.Total).Error; err != nil {
+		return nil, err
+	}
+
+	// The page is ranked first, then its books are loaded with their authors
+	var ranked []struct {
+		ID    uint
+		Score float64
+	}
+	err = matching.Session(&gorm.Session{}).Select("id, "+searchScore+" AS score", against, against).
+		Order("score DESC, id ASC").Limit(s.Limit).Offset(s.Offset).Scan(&ranked).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(ranked) == 0 {
+		return page, nil
+	}
+	ids := make([]uint, len(ranked))
+	for i, hit := range ranked {
+		ids[i] = hit.ID
+	}
+	var books []Book
+	if err := r.db.WithContext(ctx).Scopes(preloadAuthors).Where("id IN ?", ids).Find(&books).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]Book, len(books))
+	for _, book := range books {
+		byID[book.ID] = book
+	}
+	for _, hit := range ranked {
+		// A book deleted between both queries is left out
+		if book, ok := byID[hit.ID]; ok {
+			page.Hits = append(page.Hits, BookHit{Book: book, Score: hit.Score})
+		}
+	}
+	return page, nil
+}
+
+// searchInProcess runs a search over an in-process index of every live book
+func (r *GormBookRepository) searchInProcess(ctx context.Context, s BookSearch) (*SearchPage, error) {
+	index := newSearchIndex()
+	books := make(map[uint]Book)
+	err := r.Each(ctx, BookQuery{}, func(book *Book) error {
+		index.add(book)
+		books[book.ID] = *book
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	page := s.paginate(index.search(s.Terms))
+	for i := range page.Hits {
+		page.Hits[i].Book = books[page.Hits[i].Book.ID]
+	}
+	return page, nil
+}
+
+func (r *GormBookRepository) Update(ctx context.Context, id uint, changes *Book) (*Book, error) {
+	var book Book
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return updateBook(tx, &book, id, changes)
+	})
+	if err != nil {
+		return nil, translateGormError(err)
+	}
+	return &book, nil
+}
+
+func (r *GormBookRepository) Delete(ctx context.Context, id uint, version uint) (*Book, error) {
+	var book Book
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return deleteBook(tx, &book, id, version)
+	})
+	if err != nil {
+		return nil, translateGormError(err)
+	}
+	return &book, nil
+}
+
+// batchInsertSize bounds the number of books inserted per statement by Batch
+const batchInsertSize = 100
+
+func (r *GormBookRepository) Batch(ctx context.Context, ops []BookOperation, atomic bool) ([]BookOperationResult, error) {
+	results := make([]BookOperationResult, len(ops))
+	db := r.db.WithContext(ctx)
+	if !atomic {
+		// Every step commits on its own, so a failure only affects its operations
+		runBookOperations(ops, results, false, func(step func(tx *gorm.DB) error) error {
+			return db.Transaction(step)
+		})
+		return results, nil
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		ok := runBookOperations(ops, results, true, func(step func(tx *gorm.DB) error) error {
+			return step(tx)
+		})
+		if !ok {
+			return ErrBatchAborted
+		}
+		return nil
+	})
+	if err != nil {
+		abortBatch(results)
+		if !errors.Is(err, ErrBatchAborted) {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+func (r *GormBookRepository) ListDeleted(ctx context.Context, limit, offset int) (*BookPage, error) {
+	deleted := r.db.WithContext(ctx).Unscoped().Model(&Book{}).Where("deleted_at IS NOT NULL")
+	page := &BookPage{}
+	if err := deleted.Session(&gorm.Session{}).Count(&page.Total).Error; err != nil {
+		return nil, err
+	}
+	err := deleted.Session(&gorm.Session{}).Scopes(preloadAuthors).
+		Order("deleted_at DESC, id DESC").Limit(limit).Offset(offset).Find(&page.Books).Error
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+func (r *GormBookRepository) Restore(ctx context.Context, id uint) (*Book, error) {
+	var book Book
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Scopes(preloadAuthors).First(&book, id).Error; err != nil {
+			return err
+		}
+		if !book.DeletedAt.Valid {
+			return ErrBookNotDeleted
+		}
+		book.DeletedAt = gorm.DeletedAt{}
+		book.Version++
+		return tx.Unscoped().Model(&book).Updates(map[string]interface{}{"deleted_at": nil, "version": book.Version}).Error
+	})
+	if err != nil {
+		return nil, translateGormError(err)
+	}
+	return &book, nil
+}
+
+func (r *GormBookRepository) Purge(ctx context.Context, id uint) (*Book, error) {
+	var book Book
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Scopes(preloadAuthors).First(&book, id).Error; err != nil {
+			return err
+		}
+		if !book.DeletedAt.Valid {
+			return ErrBookNotDeleted
+		}
+		return purgeBooks(tx, []uint{id})
+	})
+	if err != nil {
+		return nil, translateGormError(err)
+	}
+	return &book, nil
+}
+
+// purgeBatchSize bounds the number of books removed per transaction by
+// PurgeDeletedBefore, keeping row locks short
+const purgeBatchSize = 500
+
+func (r *GormBookRepository) PurgeDeletedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	var purged int64
+	for {
+		var ids []uint
+		err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			err := tx.Unscoped().Model(&Book{}).Where("deleted_at < ?", cutoff).
+				Order("id ASC").Limit(purgeBatchSize).Pluck("id", &ids).Error
+			if err != nil || len(ids) == 0 {
+				return err
+			}
+			return purgeBooks(tx, ids)
+		})
+		if err != nil {
+			return purged, err
+		}
+		purged += int64(len(ids))
+		if len(ids) < purgeBatchSize {
+			return purged, nil
+		}
+	}
+}
+
+// purgeBooks permanently deletes books with their stock ledger; author
+// links are removed by the database's ON DELETE CASCADE
+func purgeBooks(tx *gorm.DB, ids []uint) error {
+	if err := tx.Where("book_id IN ?", ids).Delete(&StockMovement{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("book_id IN ?", ids).Delete(&StockLevel{}).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Delete(&Book{}, ids).Error
+}
+
+// replacedBookColumns are the columns Update overwrites
+var replacedBookColumns = []string{"title", "author", "price_amount", "price_currency", "publisher", "description", "category", "published_year", "isbn_13", "isbn_10", "version"}
+
+// updateBook replaces the book with the given ID by changes, as described
+// for Update, and loads the result into book
+func updateBook(tx *gorm.DB, book *Book, id uint, changes *Book) error {
+	if err := lockBook(tx, book, id, changes.Version); err != nil {
+		return err
+	}
+	links, err := resolveAuthorLinks(changes.Authors, authorFinder(tx))
+	if err != nil {
+		return translateAuthorError(err)
+	}
+	updates := *changes
+	updates.Version = book.Version + 1
+	// Select writes the zero values Updates would otherwise skip
+	err = tx.Model(book).Select(replacedBookColumns).Omit(clause.Associations).Updates(&updates).Error
+	if err != nil {
+		return err
+	}
+	if err := tx.Where("book_id = ?", id).Delete(&BookAuthor{}).Error; err != nil {
+		return err
+	}
+	book.Authors = linkAuthors(id, links)
+	if len(book.Authors) == 0 {
+		return nil
+	}
+	return tx.Create(&book.Authors).Error
+}
+
+// deleteBook moves the book with the given ID to the trash and loads it into book
+func deleteBook(tx *gorm.DB, book *Book, id uint, version uint) error {
+	if err := lockBook(tx, book, id, version); err != nil {
+		return err
+	}
+	// Author links are kept so that the book can be restored
+	return tx.Delete(book).Error
+}
+
+// runBookOperations applies ops in steps executed by run and records the
+// outcome of each operation in results. Consecutive creates are inserted
+// together, up to batchInsertSize per step; every update and delete is a
+// step of its own. With stop set it stops at the first failed step. It
+// reports whether every operation succeeded.
+func runBookOperations(ops []BookOperation, results []BookOperationResult, stop bool, run func(step func(tx *gorm.DB) error) error) bool {
+	for start := 0; start < len(ops); {
+		end := start + 1
+		if ops[start].Kind == BookCreate {
+			for end < len(ops) && end-start < batchInsertSize && ops[end].Kind == BookCreate {
+				end++
+			}
+		}
+		stepOps, stepResults := ops[start:end], results[start:end]
+		if err := run(func(tx *gorm.DB) error { return applyBookStep(tx, stepOps, stepResults, stop) }); err != nil {
+			// A failure no single operation owns fails them all. When the step
+			// stopped at an operation of its own, the rest are aborted instead.
+			owned := batchFailed(stepResults)
+			for i := range stepResults {
+				if stepResults[i].Err == nil && !(stop && owned) {
+					stepResults[i] = BookOperationResult{Err: translateGormError(err)}
+				}
+			}
+		}
+		if stop && batchFailed(stepResults) {
+			return false
+		}
+		start = end
+	}
+	return !batchFailed(results)
+}
+
+// applyBookStep applies one step of runBookOperations: a single update or
+// delete, or a run of creates
+func applyBookStep(tx *gorm.DB, ops []BookOperation, results []BookOperationResult, stop bool) error {
+	op := ops[0]
+	switch op.Kind {
+	case BookCreate:
+		return createBooks(tx, ops, results, stop)
+	case BookUpdate:
+		changes := *op.Book
+		changes.Version = op.Version
+		var book Book
+		if err := updateBook(tx, &book, op.ID, &changes); err != nil {
+			return err
+		}
+		results[0].Book = &book
+	case BookDelete:
+		var book Book
+		if err := deleteBook(tx, &book, op.ID, op.Version); err != nil {
+			return err
+		}
+		results[0].Book = &book
+	default:
+		return fmt.Errorf("unknown book operation %q", op.Kind)
+	}
+	return nil
+}
+
+// createBooks inserts the books of a run of creates with as few statements
+// as possible. A book whose ISBN is taken, by a stored book or an earlier
+// book of the batch, fails on its own; with stop set it fails the step.
+func createBooks(tx *gorm.DB, ops []BookOperation, results []BookOperationResult, stop bool) error {
+	taken, err := takenISBNs(tx, ops)
+	if err != nil {
+		return err
+	}
+	books := make([]*Book, 0, len(ops))
+	for i, op := range ops {
+		book := op.Book
+		var err error
+		switch {
+		case book.ID != 0:
+			err = fmt.Errorf("%w with ID %d", ErrBookExists, book.ID)
+		case book.ISBN13 != nil:
+			// Books of the batch claim their ISBN with ID zero
+			if id, ok := taken[*book.ISBN13]; !ok {
+				taken[*book.ISBN13] = 0
+			} else if id != 0 {
+				err = fmt.Errorf("%w: ISBN %s is used by book %d", ErrBookExists, *book.ISBN13, id)
+			} else {
+				err = fmt.Errorf("%w: ISBN %s is used by another book of the batch", ErrBookExists, *book.ISBN13)
+			}
+		}
+		if err != nil {
+			results[i].Err = err
+			if stop {
+				return err
+			}
+			continue
+		}
+		links, err := resolveAuthorLinks(book.Authors, authorFinder(tx))
+		if err != nil {
+			return translateAuthorError(err)
+		}
+		book.Authors = links
+		book.Version = 1
+		books = append(books, book)
+	}
+	if len(books) == 0 {
+		return nil
+	}
+	if err := tx.CreateInBatches(books, batchInsertSize).Error; err != nil {
+		return err
+	}
+	for i, op := range ops {
+		if results[i].Err == nil {
+			results[i].Book = op.Book
+		}
+	}
+	return nil
+}
+
+// authorFinder finds or creates the authors named by author links in tx
+func authorFinder(tx *gorm.DB) func(name string) (*Author, error) {
+	return func(name string) (*Author, error) { return findOrCreateAuthor(tx, name) }
+}
+
+// takenISBNs returns the IDs of the stored books, including those in the
+// trash, that hold the ISBN-13 of one of the books to create, by ISBN
+func takenISBNs(tx *gorm.DB, ops []BookOperation) (map[string]uint, error) {
+	taken := make(map[string]uint)
+	var isbns []string
+	for _, op := range ops {
+		if op.Book.ISBN13 != nil {
+			isbns = append(isbns, *op.Book.ISBN13)
+		}
+	}
+	if len(isbns) == 0 {
+		return taken, nil
+	}
+	var books []Book
+	if err := tx.Unscoped().Select("id", "isbn_13").Where("isbn_13 IN ?", isbns).Find(&books).Error; err != nil {
+		return nil, err
+	}
+	for _, book := range books {
+		taken[*book.ISBN13] = book.ID
+	}
+	return taken, nil
+}
+
+// lockBook loads the live book with the given ID into book and locks its row
+// until the transaction ends. A non-zero version must match the stored one.
+func lockBook(tx *gorm.DB, book *Book, id uint, version uint) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Scopes(preloadAuthors).First(book, id).Error; err != nil {
+		return err
+	}
+	if version != 0 && version != book.Version {
+		return ErrBookVersionMismatch
+	}
+	return nil
+}
+
+// translateGormError maps GORM sentinel errors onto repository errors
+func translateGormError(err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return ErrBookNotFound
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return fmt.Errorf("%w: %v", ErrBookExists, err)
+	}
+	return err
+}
+
+// preloadAuthors loads the author links of books in credit order
+func preloadAuthors(db *gorm.DB) *gorm.DB {
+	return db.Preload("Authors", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position ASC")
+	})
+}
+
+// filterScope applies the query's filters to a GORM statement
+func (q BookQuery) filterScope(db *gorm.DB) *gorm.DB {
+	if q.Title != "" {
+		db = db.Where("title LIKE ?", "%"+escapeLike(q.Title)+"%")
+	}
+	if q.Author != "" {
+		db = db.Where("author LIKE ?", "%"+escapeLike(q.Author)+"%")
+	}
+	if q.AuthorID != 0 {
+		db = db.Where("id IN (?)", db.Session(&gorm.Session{NewDB: true}).Model(&BookAuthor{}).Select("book_id").Where("author_id = ?", q.AuthorID))
+	}
+	if q.Category != "" {
+		db = db.Where("category = ?", q.Category)
+	}
+	if q.Year != 0 {
+		db = db.Where("published_year = ?", q.Year)
+	}
+	if q.MinPrice != nil {
+		db = db.Where("price_currency = ? AND price_amount >= ?", q.MinPrice.Currency, q.MinPrice.Amount)
+	}
+	if q.MaxPrice != nil {
+		db = db.Where("price_currency = ? AND price_amount <= ?", q.MaxPrice.Currency, q.MaxPrice.Amount)
+	}
+	return db
+}
+
+// orderClause renders the query's sort order as SQL, reversed when walking
+// backwards from a cursor
+func (q BookQuery) orderClause(reverse bool) string {
+	terms := make([]string, len(q.Sort))
+	for i, f := range q.Sort {
+		dir := "ASC"
+		if f.Desc != reverse {
+			dir = "DESC"
+		}
+		terms[i] = bookSortKeys[f.Field].column + " " + dir
+	}
+	return strings.Join(terms, ", ")
+}
+
+// keysetCondition renders the condition selecting rows beyond the cursor,
+// e.g. (title > ?) OR (title = ? AND id > ?)
+func (q BookQuery) keysetCondition(keys []interface{}) (string, []interface{}) {
+	var (
+		alternatives []string
+		args         []interface{}
+	)
+	for i, f := range q.Sort {
+		terms := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			terms = append(terms, bookSortKeys[q.Sort[j].Field].column+" = ?")
+			args = append(args, keys[j])
+		}
+		op := ">"
+		if f.Desc != q.Cursor.Backward {
+			op = "<"
+		}
+		terms = append(terms, bookSortKeys[f.Field].column+" "+op+" ?")
+		args = append(args, keys[i])
+		alternatives = append(alternatives, "("+strings.Join(terms, " AND ")+")")
+	}
+	return "(" + strings.Join(alternatives, " OR ") + ")", args
+}
+
+// escapeLike escapes the LIKE wildcards in a user supplied search term
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
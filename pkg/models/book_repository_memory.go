@@ -0,0 +1,392 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MemoryBookRepository is a BookRepository that keeps books in process memory.
+// It is intended for tests and for embedding the bookstore without a database.
+// Books in the trash stay in the map with DeletedAt set.
+type MemoryBookRepository struct {
+	mu     sync.RWMutex
+	books  map[uint]Book
+	nextID uint
+	// index covers the live books for Search
+	index *searchIndex
+	// authors are the authors Batch credits by name
+	authors *MemoryAuthorRepository
+}
+
+// NewMemoryBookRepository returns an empty in-memory BookRepository whose
+// batches credit the authors of authors
+func NewMemoryBookRepository(authors *MemoryAuthorRepository) *MemoryBookRepository {
+	return &MemoryBookRepository{books: make(map[uint]Book), nextID: 1, index: newSearchIndex(), authors: authors}
+}
+
+func (r *MemoryBookRepository) Create(ctx context.Context, book *Book) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.authors.mu.Lock()
+	defer r.authors.mu.Unlock()
+	_, err := r.apply(BookOperation{Kind: BookCreate, Book: book})
+	return err
+}
+
+func (r *MemoryBookRepository) Get(ctx context.Context, id uint) (*Book, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	book, ok := r.live(id)
+	if !ok {
+		return nil, ErrBookNotFound
+	}
+	return cloneBook(book), nil
+}
+
+func (r *MemoryBookRepository) GetByISBN(ctx context.Context, isbn13 string) (*Book, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, book := range r.books {
+		if !book.DeletedAt.Valid && book.ISBN13 != nil && *book.ISBN13 == isbn13 {
+			return cloneBook(book), nil
+		}
+	}
+	return nil, ErrBookNotFound
+}
+
+func (r *MemoryBookRepository) List(ctx context.Context, query BookQuery) (*BookPage, error) {
+	q, err := query.normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	books := make([]Book, 0, len(r.books))
+	for _, book := range r.books {
+		if !book.DeletedAt.Valid && q.matches(&book) {
+			books = append(books, *cloneBook(book))
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(books, func(i, j int) bool { return q.compareBooks(&books[i], &books[j]) < 0 })
+	return q.paginateSorted(books)
+}
+
+func (r *MemoryBookRepository) Each(ctx context.Context, query BookQuery, fn func(*Book) error) error {
+	q, err := query.normalize()
+	if err != nil {
+		return err
+	}
+
+	// The books are copied so that fn may call back into the repository
+	r.mu.RLock()
+	books := make([]Book, 0, len(r.books))
+	for _, book := range r.books {
+		if !book.DeletedAt.Valid && q.matches(&book) {
+			books = append(books, *cloneBook(book))
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(books, func(i, j int) bool { return q.compareBooks(&books[i], &books[j]) < 0 })
+	for i := range books {
+		if err := fn(&books[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *MemoryBookRepository) Search(ctx context.Context, search BookSearch) (*SearchPage, error) {
+	s, err := search.normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	// Searching may cache the vocabulary of the index, so it takes the
+	// write lock
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	page := s.paginate(r.index.search(s.Terms))
+	for i := range page.Hits {
+		page.Hits[i].Book = *cloneBook(r.books[page.Hits[i].Book.ID])
+	}
+	return page, nil
+}
+
+func (r *MemoryBookRepository) Update(ctx context.Context, id uint, changes *Book) (*Book, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.authors.mu.Lock()
+	defer r.authors.mu.Unlock()
+	return r.apply(BookOperation{Kind: BookUpdate, ID: id, Version: changes.Version, Book: changes})
+}
+
+func (r *MemoryBookRepository) Delete(ctx context.Context, id uint, version uint) (*Book, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.delete(id, version)
+}
+
+func (r *MemoryBookRepository) Batch(ctx context.Context, ops []BookOperation, atomic bool) ([]BookOperationResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.authors.mu.Lock()
+	defer r.authors.mu.Unlock()
+
+	// An atomic batch is rolled back by restoring the books as they were
+	// and removing the authors it created
+	var (
+		saved      map[uint]Book
+		nextID     = r.nextID
+		authorMark = r.authors.nextID
+	)
+	if atomic {
+		saved = maps.Clone(r.books)
+	}
+	results := make([]BookOperationResult, len(ops))
+	for i, op := range ops {
+		results[i].Book, results[i].Err = r.apply(op)
+		if atomic && results[i].Err != nil {
+			r.books, r.nextID = saved, nextID
+			r.reindex()
+			r.authors.rollback(authorMark)
+			abortBatch(results)
+			break
+		}
+	}
+	return results, nil
+}
+
+func (r *MemoryBookRepository) ListDeleted(ctx context.Context, limit, offset int) (*BookPage, error) {
+	r.mu.RLock()
+	var books []Book
+	for _, book := range r.books {
+		if book.DeletedAt.Valid {
+			books = append(books, *cloneBook(book))
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(books, func(i, j int) bool {
+		if c := books[i].DeletedAt.Time.Compare(books[j].DeletedAt.Time); c != 0 {
+			return c > 0
+		}
+		return books[i].ID > books[j].ID
+	})
+	page := &BookPage{Total: int64(len(books))}
+	start := min(offset, len(books))
+	page.Books = books[start:min(start+limit, len(books))]
+	return page, nil
+}
+
+func (r *MemoryBookRepository) Restore(ctx context.Context, id uint) (*Book, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	book, ok := r.books[id]
+	if !ok {
+		return nil, ErrBookNotFound
+	}
+	if !book.DeletedAt.Valid {
+		return nil, ErrBookNotDeleted
+	}
+	book.DeletedAt = gorm.DeletedAt{}
+	book.Version++
+	book.UpdatedAt = time.Now()
+	r.books[id] = book
+	r.index.add(&book)
+	return cloneBook(book), nil
+}
+
+// Purge removes the book only; the in-memory stock repository is not linked
+// to this one and keeps the ledger of purged books
+func (r *MemoryBookRepository) Purge(ctx context.Context, id uint) (*Book, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	book, ok := r.books[id]
+	if !ok {
+		return nil, ErrBookNotFound
+	}
+	if !book.DeletedAt.Valid {
+		return nil, ErrBookNotDeleted
+	}
+	delete(r.books, id)
+	return cloneBook(book), nil
+}
+
+func (r *MemoryBookRepository) PurgeDeletedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var purged int64
+	for id, book := range r.books {
+		if book.DeletedAt.Valid && book.DeletedAt.Time.Before(cutoff) {
+			delete(r.books, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// create stores a new book; the caller must hold the lock
+func (r *MemoryBookRepository) create(book *Book) error {
+	if book.ID != 0 {
+		return fmt.Errorf("%w with ID %d", ErrBookExists, book.ID)
+	}
+	if err := r.checkISBN(0, book.ISBN13); err != nil {
+		return err
+	}
+	now := time.Now()
+	book.ID = r.nextID
+	book.Version = 1
+	book.CreatedAt = now
+	book.UpdatedAt = now
+	book.Authors = linkAuthors(book.ID, book.Authors)
+	r.nextID++
+	r.books[book.ID] = *book
+	r.index.add(book)
+	return nil
+}
+
+// update replaces a book by changes; the caller must hold the lock
+func (r *MemoryBookRepository) update(id uint, changes *Book) (*Book, error) {
+	book, ok := r.live(id)
+	if !ok {
+		return nil, ErrBookNotFound
+	}
+	if changes.Version != 0 && changes.Version != book.Version {
+		return nil, ErrBookVersionMismatch
+	}
+	if err := r.checkISBN(id, changes.ISBN13); err != nil {
+		return nil, err
+	}
+	book.Title, book.Author, book.Price = changes.Title, changes.Author, changes.Price
+	book.Publisher, book.Description = changes.Publisher, changes.Description
+	book.Category, book.PublishedYear = changes.Category, changes.PublishedYear
+	book.ISBN13, book.ISBN10 = changes.ISBN13, changes.ISBN10
+	book.Authors = linkAuthors(id, changes.Authors)
+	book.Version++
+	book.UpdatedAt = time.Now()
+	r.books[id] = book
+	r.index.add(&book)
+	return cloneBook(book), nil
+}
+
+// delete moves a book to the trash; the caller must hold the lock
+func (r *MemoryBookRepository) delete(id uint, version uint) (*Book, error) {
+	book, ok := r.live(id)
+	if !ok {
+		return nil, ErrBookNotFound
+	}
+	if version != 0 && version != book.Version {
+		return nil, ErrBookVersionMismatch
+	}
+	book.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	r.books[id] = book
+	r.index.remove(id)
+	return cloneBook(book), nil
+}
+
+// apply performs one operation, of a batch or of Create or Update, removing
+// the authors it created when it fails; the caller must hold the locks of
+// the books and authors
+func (r *MemoryBookRepository) apply(op BookOperation) (*Book, error) {
+	mark := r.authors.nextID
+	book, err := r.applyOperation(op)
+	if err != nil {
+		r.authors.rollback(mark)
+	}
+	return book, err
+}
+
+// applyOperation performs one operation; the caller must hold the locks of
+// the books and authors
+func (r *MemoryBookRepository) applyOperation(op BookOperation) (*Book, error) {
+	switch op.Kind {
+	case BookCreate:
+		links, err := resolveAuthorLinks(op.Book.Authors, r.authors.findOrCreate)
+		if err != nil {
+			return nil, err
+		}
+		op.Book.Authors = links
+		if err := r.create(op.Book); err != nil {
+			return nil, err
+		}
+		return op.Book, nil
+	case BookUpdate:
+		changes := *op.Book
+		changes.Version = op.Version
+		links, err := resolveAuthorLinks(changes.Authors, r.authors.findOrCreate)
+		if err != nil {
+			return nil, err
+		}
+		changes.Authors = links
+		return r.update(op.ID, &changes)
+	case BookDelete:
+		return r.delete(op.ID, op.Version)
+	}
+	return nil, fmt.Errorf("unknown book operation %q", op.Kind)
+}
+
+// reindex rebuilds the search index from the stored books; the caller must
+// hold the lock
+func (r *MemoryBookRepository) reindex() {
+	r.index = newSearchIndex()
+	for _, book := range r.books {
+		if !book.DeletedAt.Valid {
+			r.index.add(&book)
+		}
+	}
+}
+
+// live returns the book with the given ID unless it is missing or in the
+// trash; the caller must hold the lock
+func (r *MemoryBookRepository) live(id uint) (Book, bool) {
+	book, ok := r.books[id]
+	return book, ok && !book.DeletedAt.Valid
+}
+
+// linkAuthors returns a copy of links pointing at bookID, so stored books
+// never share a slice with callers
+func linkAuthors(bookID uint, links []BookAuthor) []BookAuthor {
+	linked := make([]BookAuthor, len(links))
+	for i, link := range links {
+		link.BookID = bookID
+		link.Name = ""
+		linked[i] = link
+	}
+	return linked
+}
+
+// checkISBN enforces the unique ISBN-13 index for every book but exceptID,
+// including books in the trash as the database does; the caller must hold
+// the lock
+func (r *MemoryBookRepository) checkISBN(exceptID uint, isbn13 *string) error {
+	if isbn13 == nil {
+		return nil
+	}
+	for id, book := range r.books {
+		if id != exceptID && book.ISBN13 != nil && *book.ISBN13 == *isbn13 {
+			return fmt.Errorf("%w: ISBN %s is used by book %d", ErrBookExists, *isbn13, id)
+		}
+	}
+	return nil
+}
+
+// cloneBook copies a stored book so callers cannot modify the store
+func cloneBook(book Book) *Book {
+	book.Authors = slices.Clone(book.Authors)
+	return &book
+}
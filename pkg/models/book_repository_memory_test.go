@@ -0,0 +1,357 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func isbn13(s string) *string { return &s }
+
+func TestMemoryBookRepositoryCRUD(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryBookRepository(NewMemoryAuthorRepository())
+
+	book := &Book{
+		Title:   "The Great Gatsby",
+		Author:  "F. Scott Fitzgerald",
+		Price:   Money{Amount: 1599, Currency: "USD"},
+		ISBN13:  isbn13("9780743273565"),
+		Authors: []BookAuthor{{AuthorID: 1, Name: "F. Scott Fitzgerald", Role: RoleAuthor}},
+	}
+	if err := repo.Create(ctx, book); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if book.ID != 1 || book.Version != 1 || book.CreatedAt.IsZero() {
+		t.Fatalf("Create assigned ID %d, version %d, created at %v", book.ID, book.Version, book.CreatedAt)
+	}
+	if err := repo.Create(ctx, book); !errors.Is(err, ErrBookExists) {
+		t.Errorf("Create with an ID = %v, want ErrBookExists", err)
+	}
+	if err := repo.Create(ctx, &Book{Title: "Copy", ISBN13: isbn13("9780743273565")}); !errors.Is(err, ErrBookExists) {
+		t.Errorf("Create with a taken ISBN = %v, want ErrBookExists", err)
+	}
+	if err := repo.Create(ctx, &Book{Title: "Tender Is the Night"}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	got, err := repo.Get(ctx, book.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Title != book.Title || got.Price != book.Price || got.Authors[0].BookID != book.ID || got.Authors[0].Name != "" {
+		t.Errorf("Get = %+v; want the stored book with unnamed links", got)
+	}
+	// Callers cannot change the stored book through the returned copy
+	got.Title = "Changed"
+	got.Authors[0].AuthorID = 99
+	if again, _ := repo.Get(ctx, book.ID); again.Title != book.Title || again.Authors[0].AuthorID != 1 {
+		t.Errorf("changing a returned book changed the stored one")
+	}
+	if byISBN, err := repo.GetByISBN(ctx, "9780743273565"); err != nil || byISBN.ID != book.ID {
+		t.Errorf("GetByISBN = %v, %v", byISBN, err)
+	}
+	if _, err := repo.Get(ctx, 42); !errors.Is(err, ErrBookNotFound) {
+		t.Errorf("Get(42) = %v, want ErrBookNotFound", err)
+	}
+	if page, err := repo.List(ctx, BookQuery{}); err != nil || page.Total != 2 || page.Books[0].ID != 1 || page.Books[1].ID != 2 {
+		t.Errorf("List = %+v, %v; want books 1 and 2 in order", page, err)
+	}
+
+	changes := &Book{Title: "Gatsby", Author: "Fitzgerald", Price: Money{Amount: 999, Currency: "EUR"}, Category: "Fiction", PublishedYear: 1925, Version: 1}
+	updated, err := repo.Update(ctx, book.ID, changes)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if updated.Version != 2 || updated.Title != "Gatsby" || updated.ISBN13 != nil || len(updated.Authors) != 0 || updated.Category != "Fiction" || updated.PublishedYear != 1925 {
+		t.Errorf("Update = %+v; want version 2, the new fields and the ISBN and links cleared", updated)
+	}
+	if _, err := repo.Update(ctx, book.ID, changes); !errors.Is(err, ErrBookVersionMismatch) {
+		t.Errorf("Update at a stale version = %v, want ErrBookVersionMismatch", err)
+	}
+	changes.Version = 0
+	if updated, err := repo.Update(ctx, book.ID, changes); err != nil || updated.Version != 3 {
+		t.Errorf("Update at any version = %v, %v; want version 3", updated, err)
+	}
+	if _, err := repo.Update(ctx, 2, &Book{Title: "Copy", ISBN13: isbn13("9780743273565")}); err != nil {
+		t.Errorf("Update to the ISBN the other book gave up = %v", err)
+	}
+	if _, err := repo.Update(ctx, book.ID, &Book{Title: "Gatsby", ISBN13: isbn13("9780743273565")}); !errors.Is(err, ErrBookExists) {
+		t.Errorf("Update to a taken ISBN = %v, want ErrBookExists", err)
+	}
+	if _, err := repo.Update(ctx, 42, changes); !errors.Is(err, ErrBookNotFound) {
+		t.Errorf("Update(42) = %v, want ErrBookNotFound", err)
+	}
+
+	deleted, err := repo.Delete(ctx, book.ID, 0)
+	if err != nil || deleted.Title != "Gatsby" {
+		t.Fatalf("Delete = %+v, %v", deleted, err)
+	}
+	if _, err := repo.Get(ctx, book.ID); !errors.Is(err, ErrBookNotFound) {
+		t.Errorf("Get of a deleted book = %v, want ErrBookNotFound", err)
+	}
+	if _, err := repo.Delete(ctx, book.ID, 0); !errors.Is(err, ErrBookNotFound) {
+		t.Errorf("Delete of a deleted book = %v, want ErrBookNotFound", err)
+	}
+}
+
+func TestMemoryBookRepositoryTrash(t *testing.T) {
+	ctx := context.Background()
+	repo := seedBooks(t, Book{Title: "Emma", ISBN13: isbn13("9780141439587")}, Book{Title: "Dune"})
+
+	if _, err := repo.Delete(ctx, 1, 7); !errors.Is(err, ErrBookVersionMismatch) {
+		t.Errorf("Delete at a stale version = %v, want ErrBookVersionMismatch", err)
+	}
+	if _, err := repo.Delete(ctx, 1, 1); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := repo.Get(ctx, 1); !errors.Is(err, ErrBookNotFound) {
+		t.Errorf("Get of a deleted book = %v, want ErrBookNotFound", err)
+	}
+	if page, _ := repo.List(ctx, BookQuery{}); page.Total != 1 {
+		t.Errorf("List counts %d books, want the live one only", page.Total)
+	}
+	if _, err := repo.Delete(ctx, 1, 0); !errors.Is(err, ErrBookNotFound) {
+		t.Errorf("Delete of a deleted book = %v, want ErrBookNotFound", err)
+	}
+	// The ISBN stays taken while the book is in the trash
+	if err := repo.Create(ctx, &Book{Title: "Emma", ISBN13: isbn13("9780141439587")}); !errors.Is(err, ErrBookExists) {
+		t.Errorf("Create with the ISBN of a deleted book = %v, want ErrBookExists", err)
+	}
+	if trash, _ := repo.ListDeleted(ctx, 10, 0); trash.Total != 1 || trash.Books[0].ID != 1 {
+		t.Errorf("ListDeleted = %+v, want book 1", trash)
+	}
+
+	if _, err := repo.Restore(ctx, 2); !errors.Is(err, ErrBookNotDeleted) {
+		t.Errorf("Restore of a live book = %v, want ErrBookNotDeleted", err)
+	}
+	restored, err := repo.Restore(ctx, 1)
+	if err != nil || restored.Version != 2 || restored.DeletedAt.Valid {
+		t.Fatalf("Restore = %+v, %v; want a live book at version 2", restored, err)
+	}
+
+	if _, err := repo.Purge(ctx, 1); !errors.Is(err, ErrBookNotDeleted) {
+		t.Errorf("Purge of a live book = %v, want ErrBookNotDeleted", err)
+	}
+	if _, err := repo.Delete(ctx, 1, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Purge(ctx, 1); err != nil {
+		t.Errorf("Purge: %v", err)
+	}
+	if _, err := repo.Restore(ctx, 1); !errors.Is(err, ErrBookNotFound) {
+		t.Errorf("Restore of a purged book = %v, want ErrBookNotFound", err)
+	}
+
+	if _, err := repo.Delete(ctx, 2, 0); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := repo.PurgeDeletedBefore(ctx, time.Now().Add(-time.Hour)); err != nil || n != 0 {
+		t.Errorf("PurgeDeletedBefore an hour ago = %d, %v; want 0", n, err)
+	}
+	if n, err := repo.PurgeDeletedBefore(ctx, time.Now().Add(time.Second)); err != nil || n != 1 {
+		t.Errorf("PurgeDeletedBefore now = %d, %v; want 1", n, err)
+	}
+}
+
+func TestMemoryBookRepositoryBatch(t *testing.T) {
+	ctx := context.Background()
+	errs := func(results []BookOperationResult) []error {
+		out := make([]error, len(results))
+		for i, result := range results {
+			out[i] = result.Err
+		}
+		return out
+	}
+	ops := func() []BookOperation {
+		return []BookOperation{
+			{Kind: BookCreate, Book: &Book{Title: "New"}},
+			{Kind: BookUpdate, ID: 1, Version: 1, Book: &Book{Title: "Emma (Revised)"}},
+			{Kind: BookDelete, ID: 2},
+			// Book 1 is at version 2 once the update above is applied
+			{Kind: BookUpdate, ID: 1, Version: 1, Book: &Book{Title: "Emma (Again)"}},
+		}
+	}
+
+	t.Run("atomic", func(t *testing.T) {
+		repo := seedBooks(t, Book{Title: "Emma"}, Book{Title: "Dune"})
+		results, err := repo.Batch(ctx, ops(), true)
+		if err != nil {
+			t.Fatalf("Batch: %v", err)
+		}
+		want := []error{ErrBatchAborted, ErrBatchAborted, ErrBatchAborted, ErrBookVersionMismatch}
+		for i, err := range errs(results) {
+			if !errors.Is(err, want[i]) {
+				t.Errorf("operation %d: %v, want %v", i, err, want[i])
+			}
+		}
+		// Nothing was stored, not even the first operations
+		page, _ := repo.List(ctx, BookQuery{})
+		if got := pageIDs(page.Books); !reflect.DeepEqual(got, []uint{1, 2}) {
+			t.Errorf("books after a rolled back batch: %v, want [1 2]", got)
+		}
+		if book, _ := repo.Get(ctx, 1); book.Title != "Emma" || book.Version != 1 {
+			t.Errorf("book 1 after a rolled back batch: %q version %d", book.Title, book.Version)
+		}
+		// The rolled back book is not found by search, and IDs are reused
+		if hits, _ := repo.Search(ctx, BookSearch{Terms: []SearchTerm{{Words: []string{"revised"}}}}); hits.Total != 0 {
+			t.Errorf("search finds %d rolled back books", hits.Total)
+		}
+		created := &Book{Title: "After"}
+		if err := repo.Create(ctx, created); err != nil || created.ID != 3 {
+			t.Errorf("Create after a rolled back batch got ID %d, %v; want 3", created.ID, err)
+		}
+	})
+
+	t.Run("best effort", func(t *testing.T) {
+		repo := seedBooks(t, Book{Title: "Emma"}, Book{Title: "Dune"})
+		results, err := repo.Batch(ctx, ops(), false)
+		if err != nil {
+			t.Fatalf("Batch: %v", err)
+		}
+		want := []error{nil, nil, nil, ErrBookVersionMismatch}
+		for i, err := range errs(results) {
+			if !errors.Is(err, want[i]) {
+				t.Errorf("operation %d: %v, want %v", i, err, want[i])
+			}
+		}
+		if results[0].Book == nil || results[0].Book.ID != 3 {
+			t.Errorf("created book: %+v, want ID 3", results[0].Book)
+		}
+		page, _ := repo.List(ctx, BookQuery{})
+		if got := pageIDs(page.Books); !reflect.DeepEqual(got, []uint{1, 3}) {
+			t.Errorf("books after the batch: %v, want [1 3]", got)
+		}
+		if book, _ := repo.Get(ctx, 1); book.Title != "Emma (Revised)" || book.Version != 2 {
+			t.Errorf("book 1 after the batch: %q version %d", book.Title, book.Version)
+		}
+	})
+
+	t.Run("atomic success", func(t *testing.T) {
+		repo := seedBooks(t, Book{Title: "Emma"}, Book{Title: "Dune"})
+		results, err := repo.Batch(ctx, ops()[:3], true)
+		if err != nil {
+			t.Fatalf("Batch: %v", err)
+		}
+		for i, err := range errs(results) {
+			if err != nil {
+				t.Errorf("operation %d: %v", i, err)
+			}
+		}
+		page, _ := repo.List(ctx, BookQuery{})
+		if got := pageIDs(page.Books); !reflect.DeepEqual(got, []uint{1, 3}) {
+			t.Errorf("books after the batch: %v, want [1 3]", got)
+		}
+	})
+
+	t.Run("authors named by links", func(t *testing.T) {
+		authors := NewMemoryAuthorRepository()
+		austen, _ := authors.FindOrCreate(ctx, "Jane Austen")
+		repo := NewMemoryBookRepository(authors)
+		named := func(names ...string) *Book {
+			book := &Book{Title: "Good Omens"}
+			for i, name := range names {
+				book.Authors = append(book.Authors, BookAuthor{Name: name, Role: RoleAuthor, Position: i})
+			}
+			return book
+		}
+		countAuthors := func() int64 {
+			page, _ := authors.List(ctx, AuthorQuery{})
+			return page.Total
+		}
+
+		// A rolled back batch leaves none of the authors it created
+		results, _ := repo.Batch(ctx, []BookOperation{
+			{Kind: BookCreate, Book: named("Terry Pratchett")},
+			{Kind: BookUpdate, ID: 7, Book: named("Neil Gaiman")},
+		}, true)
+		if !errors.Is(results[0].Err, ErrBatchAborted) || !errors.Is(results[1].Err, ErrBookNotFound) {
+			t.Errorf("atomic batch: %v, %v", results[0].Err, results[1].Err)
+		}
+		if n := countAuthors(); n != 1 {
+			t.Errorf("%d authors after a rolled back batch, want 1", n)
+		}
+
+		// Nor does a failed operation of a best effort batch
+		results, _ = repo.Batch(ctx, []BookOperation{
+			{Kind: BookCreate, Book: named("Terry Pratchett", "neil gaiman", "Neil  Gaiman", "jane austen")},
+			{Kind: BookUpdate, ID: 7, Book: named("Douglas Adams")},
+		}, false)
+		if results[0].Err != nil || !errors.Is(results[1].Err, ErrBookNotFound) {
+			t.Fatalf("best effort batch: %v, %v", results[0].Err, results[1].Err)
+		}
+		if n := countAuthors(); n != 3 {
+			t.Errorf("%d authors after the batch, want 3", n)
+		}
+		// Spelling variants of a name credit the same author once
+		links := results[0].Book.Authors
+		if len(links) != 3 || links[0].AuthorID != 2 || links[1].AuthorID != 3 || links[2].AuthorID != austen.ID || links[2].Position != 2 {
+			t.Errorf("created book credits %+v", links)
+		}
+	})
+}
+
+func TestMemoryBookRepositorySearch(t *testing.T) {
+	ctx := context.Background()
+	repo := seedBooks(t,
+		Book{Title: "The Great Gatsby", Author: "F. Scott Fitzgerald"},
+		Book{Title: "Great Expectations", Author: "Charles Dickens", Description: "An orphan named Pip."},
+		Book{Title: "Gatsby's Girl", Author: "Caroline Preston", Description: "Inspired by the great love of Fitzgerald."},
+	)
+	search := func(q string) []uint {
+		t.Helper()
+		terms, err := ParseSearch(q)
+		if err != nil {
+			t.Fatalf("ParseSearch(%q): %v", q, err)
+		}
+		page, err := repo.Search(ctx, BookSearch{Terms: terms})
+		if err != nil {
+			t.Fatalf("Search(%q): %v", q, err)
+		}
+		ids := make([]uint, len(page.Hits))
+		for i, hit := range page.Hits {
+			ids[i] = hit.Book.ID
+		}
+		return ids
+	}
+
+	tests := []struct {
+		q    string
+		want []uint
+	}{
+		// A title match outweighs a description match
+		{"fitzgerald", []uint{1, 3}},
+		{"great gatsby", []uint{1, 3}},
+		{`"great gatsby"`, []uint{1}},
+		{"expect*", []uint{2}},
+		{"the orphan", []uint{2}},
+		{"tolstoy", []uint{}},
+	}
+	for _, tt := range tests {
+		if got := search(tt.q); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("search %q = %v, want %v", tt.q, got, tt.want)
+		}
+	}
+
+	// The index follows changes to the books
+	if _, err := repo.Update(ctx, 2, &Book{Title: "Bleak House", Author: "Charles Dickens"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Delete(ctx, 1, 0); err != nil {
+		t.Fatal(err)
+	}
+	if got := search("expect*"); len(got) != 0 {
+		t.Errorf("search finds the old title of an updated book: %v", got)
+	}
+	if got := search("fitzgerald"); !reflect.DeepEqual(got, []uint{3}) {
+		t.Errorf("search after a delete = %v, want [3]", got)
+	}
+	if _, err := repo.Restore(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if got := search("fitzgerald"); !reflect.DeepEqual(got, []uint{1, 3}) {
+		t.Errorf("search after a restore = %v, want [1 3]", got)
+	}
+}
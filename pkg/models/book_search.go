@@ -0,0 +1,309 @@
+package models
+
+import (
+	"fmt"
+	"html"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// minSearchWordLength is the length below which words outside phrases
+	// are ignored, like MySQL does with its default innodb_ft_min_token_size
+	minSearchWordLength = 3
+	// minSearchPrefixLength is the shortest prefix a query may search for
+	minSearchPrefixLength = 2
+	// maxSearchTerms caps the number of terms of a search query
+	maxSearchTerms = 16
+	// maxSearchLength caps the length of a search query in bytes
+	maxSearchLength = 256
+)
+
+// searchStopwords are the words InnoDB leaves out of its full-text indexes
+// by default; they are ignored outside phrases so that both search backends
+// agree
+var searchStopwords = map[string]bool{
+	"a": true, "about": true, "an": true, "are": true, "as": true, "at": true,
+	"be": true, "by": true, "com": true, "de": true, "en": true, "for": true,
+	"from": true, "how": true, "i": true, "in": true, "is": true, "it": true,
+	"la": true, "of": true, "on": true, "or": true, "that": true, "the": true,
+	"this": true, "to": true, "was": true, "what": true, "when": true,
+	"where": true, "who": true, "will": true, "with": true, "und": true,
+	"www": true,
+}
+
+// SearchTerm is one term of a search query; a book matches the query when
+// it matches every term
+type SearchTerm struct {
+	// Words are lower case; a phrase has several, which must follow each
+	// other in the same field
+	Words []string
+	// Prefix matches any word starting with the single word of the term
+	Prefix bool
+}
+
+// BookSearch describes a full-text search of the books
+type BookSearch struct {
+	Terms  []SearchTerm
+	Limit  int
+	Offset int
+}
+
+// BookHit is a book found by a search with its relevance; scores are only
+// comparable within one search
+type BookHit struct {
+	Book  Book
+	Score float64
+}
+
+// SearchPage is one page of search results, best matches first
+type SearchPage struct {
+	Hits []BookHit
+	// Total is the number of books matching the search, ignoring pagination
+	Total int64
+}
+
+// ParseSearch parses a search query: words, "quoted phrases" and prefixes
+// such as gats*. Words shorter than three characters and common words such
+// as "the" are ignored outside phrases. Words joined by punctuation, as in
+// sci-fi, are searched as a phrase.
+func ParseSearch(q string) ([]SearchTerm, error) {
+	if len(q) > maxSearchLength {
+		return nil, fmt.Errorf("%w: q must not be longer than %d bytes", ErrInvalidQuery, maxSearchLength)
+	}
+	var terms []SearchTerm
+	add := func(text string, quoted bool) {
+		words := searchWords(text)
+		term := SearchTerm{Words: words}
+		switch {
+		case len(words) == 0:
+			return
+		case len(words) == 1 && !quoted && strings.HasSuffix(text, "*"):
+			if utf8.RuneCountInString(words[0]) < minSearchPrefixLength {
+				return
+			}
+			term.Prefix = true
+		case len(words) == 1:
+			if utf8.RuneCountInString(words[0]) < minSearchWordLength || searchStopwords[words[0]] {
+				return
+			}
+		}
+		if !slices.ContainsFunc(terms, term.equal) {
+			terms = append(terms, term)
+		}
+	}
+
+	for i, part := range strings.Split(q, `"`) {
+		// Parts at odd positions are between quotes; an unclosed quote
+		// runs to the end of the query
+		if i%2 == 1 {
+			add(part, true)
+			continue
+		}
+		for _, field := range strings.Fields(part) {
+			add(field, false)
+		}
+	}
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("%w: q has no words to search for; words shorter than %d characters and common words such as \"the\" are ignored", ErrInvalidQuery, minSearchWordLength)
+	}
+	if len(terms) > maxSearchTerms {
+		return nil, fmt.Errorf("%w: q must not have more than %d terms", ErrInvalidQuery, maxSearchTerms)
+	}
+	return terms, nil
+}
+
+func (t SearchTerm) equal(other SearchTerm) bool {
+	return t.Prefix == other.Prefix && slices.Equal(t.Words, other.Words)
+}
+
+// matchAt returns the number of words of the term matched by words at
+// position i, or 0 when the term does not start there
+func (t SearchTerm) matchAt(words []string, i int) int {
+	if i+len(t.Words) > len(words) {
+		return 0
+	}
+	if t.Prefix {
+		if strings.HasPrefix(words[i], t.Words[0]) {
+			return 1
+		}
+		return 0
+	}
+	for j, word := range t.Words {
+		if words[i+j] != word {
+			return 0
+		}
+	}
+	return len(t.Words)
+}
+
+// occurrences counts the matches of the term in words
+func (t SearchTerm) occurrences(words []string) int {
+	n := 0
+	for i := range words {
+		if t.matchAt(words, i) > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// booleanMode renders the term in the syntax of MySQL's boolean full-text
+// search. Words only hold letters and digits, so they need no escaping.
+func (t SearchTerm) booleanMode() string {
+	switch {
+	case t.Prefix:
+		return "+" + t.Words[0] + "*"
+	case len(t.Words) > 1:
+		return `+"` + strings.Join(t.Words, " ") + `"`
+	}
+	return "+" + t.Words[0]
+}
+
+// normalize fills in defaults and checks the search can be run
+func (s BookSearch) normalize() (BookSearch, error) {
+	if len(s.Terms) == 0 {
+		return s, fmt.Errorf("%w: the search has no terms", ErrInvalidQuery)
+	}
+	if s.Limit <= 0 {
+		s.Limit = DefaultPageSize
+	}
+	if s.Limit > MaxPageSize {
+		s.Limit = MaxPageSize
+	}
+	if s.Offset < 0 {
+		return s, fmt.Errorf("%w: offset must not be negative", ErrInvalidQuery)
+	}
+	return s, nil
+}
+
+// searchToken is a word of a text with its position in the text
+type searchToken struct {
+	word       string
+	start, end int
+}
+
+// searchTokens splits text into lower case words of letters and digits
+func searchTokens(text string) []searchToken {
+	var tokens []searchToken
+	start := -1
+	for i, r := range text {
+		inWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		switch {
+		case inWord && start < 0:
+			start = i
+		case !inWord && start >= 0:
+			tokens = append(tokens, searchToken{word: strings.ToLower(text[start:i]), start: start, end: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, searchToken{word: strings.ToLower(text[start:]), start: start, end: len(text)})
+	}
+	return tokens
+}
+
+// searchWords returns the words of text, as they are indexed
+func searchWords(text string) []string {
+	tokens := searchTokens(text)
+	words := make([]string, len(tokens))
+	for i, token := range tokens {
+		words[i] = token.word
+	}
+	return words
+}
+
+// snippetBytes is the length of the description excerpt HighlightBook returns
+const snippetBytes = 200
+
+// HighlightBook returns the title, author and an excerpt of the description
+// of book with the words matching terms wrapped in <mark> tags, keyed by
+// field. Fields without a match are left out. The text is HTML-escaped.
+func HighlightBook(terms []SearchTerm, book *Book) map[string]string {
+	highlights := make(map[string]string)
+	for _, field := range []struct {
+		name, text string
+		excerpt    bool
+	}{
+		{"title", book.Title, false},
+		{"author", book.Author, false},
+		{"description", book.Description, true},
+	} {
+		if marked, ok := highlight(terms, field.text, field.excerpt); ok {
+			highlights[field.name] = marked
+		}
+	}
+	return highlights
+}
+
+// highlight marks the matches of terms in text. With excerpt set only the
+// part of text around the first match is returned. It reports whether
+// anything matched.
+func highlight(terms []SearchTerm, text string, excerpt bool) (string, bool) {
+	tokens := searchTokens(text)
+	words := make([]string, len(tokens))
+	for i, token := range tokens {
+		words[i] = token.word
+	}
+	// Byte ranges of the matches, in order and without overlaps
+	var spans [][2]int
+	for i := 0; i < len(tokens); {
+		n := 0
+		for _, term := range terms {
+			n = max(n, term.matchAt(words, i))
+		}
+		if n == 0 {
+			i++
+			continue
+		}
+		spans = append(spans, [2]int{tokens[i].start, tokens[i+n-1].end})
+		i += n
+	}
+	if len(spans) == 0 {
+		return "", false
+	}
+
+	from, to := 0, len(text)
+	if excerpt && len(text) > snippetBytes {
+		// Start a little before the first match, at a word boundary
+		from = spans[0][0] - snippetBytes/4
+		to = from + snippetBytes
+		for _, token := range tokens {
+			if token.start >= from {
+				from = token.start
+				break
+			}
+		}
+		from = max(0, min(from, spans[0][0]))
+		to = min(len(text), max(to, spans[0][1]))
+		for i := len(tokens) - 1; i >= 0; i-- {
+			if tokens[i].end <= to {
+				to = max(tokens[i].end, spans[0][1])
+				break
+			}
+		}
+	}
+
+	var b strings.Builder
+	if from > 0 {
+		b.WriteString("…")
+	}
+	pos := from
+	for _, span := range spans {
+		if span[0] < from || span[1] > to {
+			continue
+		}
+		b.WriteString(html.EscapeString(text[pos:span[0]]))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(text[span[0]:span[1]]))
+		b.WriteString("</mark>")
+		pos = span[1]
+	}
+	b.WriteString(html.EscapeString(text[pos:to]))
+	if to < len(text) {
+		b.WriteString("…")
+	}
+	return b.String(), true
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"go-bookstore-mysql-crud/pkg/validation"
+)
+
+// ErrInvalidISBN is returned for strings that are not a valid ISBN-10 or ISBN-13
+var ErrInvalidISBN = errors.New("invalid ISBN")
+
+// NormalizeISBN validates an ISBN-10 or ISBN-13, ignoring hyphens and spaces,
+// and returns it as a bare 13 digit ISBN. ISBN-10s are converted by adding
+// the 978 prefix and recomputing the check digit.
+func NormalizeISBN(isbn string) (string, error) {
+	digits := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(isbn)))
+	if !validation.ValidISBN(digits) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidISBN, isbn)
+	}
+	if len(digits) == 13 {
+		return digits, nil
+	}
+	body := "978" + digits[:9]
+	return body + isbn13CheckDigit(body), nil
+}
+
+// ISBN10 returns the ISBN-10 form of a normalized ISBN-13. Only ISBNs with
+// the 978 prefix have one.
+func ISBN10(isbn13 string) (string, bool) {
+	if len(isbn13) != 13 || !strings.HasPrefix(isbn13, "978") {
+		return "", false
+	}
+	body := isbn13[3:12]
+	sum := 0
+	for i := 0; i < 9; i++ {
+		sum += int(body[i]-'0') * (10 - i)
+	}
+	check := (11 - sum%11) % 11
+	if check == 10 {
+		return body + "X", true
+	}
+	return body + string(rune('0'+check)), true
+}
+
+// isbn13CheckDigit computes the check digit for the first 12 digits of an ISBN-13
+func isbn13CheckDigit(body string) string {
+	sum := 0
+	for i := 0; i < 12; i++ {
+		weight := 1
+		if i%2 == 1 {
+			weight = 3
+		}
+		sum += int(body[i]-'0') * weight
+	}
+	return string(rune('0' + (10-sum%10)%10))
+}
+
+// SetISBN stores isbn on the book in both its ISBN-13 and, where one exists,
+// ISBN-10 form. An empty isbn clears both.
+func (b *Book) SetISBN(isbn string) error {
+	if strings.TrimSpace(isbn) == "" {
+		b.ISBN13, b.ISBN10 = nil, nil
+		return nil
+	}
+	isbn13, err := NormalizeISBN(isbn)
+	if err != nil {
+		return err
+	}
+	b.ISBN13, b.ISBN10 = &isbn13, nil
+	if isbn10, ok := ISBN10(isbn13); ok {
+		b.ISBN10 = &isbn10
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalizeISBN(t *testing.T) {
+	tests := []struct {
+		isbn    string
+		want    string
+		wantErr bool
+	}{
+		{"9780743273565", "9780743273565", false},
+		{"978-0-7432-7356-5", "9780743273565", false},
+		{" 978 0 7432 7356 5 ", "9780743273565", false},
+		{"0743273567", "9780743273565", false},
+		{"0-8044-2957-X", "9780804429573", false},
+		{"080442957x", "9780804429573", false},
+		{"9791032305690", "9791032305690", false},
+		{"9780743273566", "", true},
+		{"0743273568", "", true},
+		{"X804429570", "", true},
+		{"97807432735", "", true},
+		{"978074327356A", "", true},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeISBN(tt.isbn)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidISBN) {
+				t.Errorf("NormalizeISBN(%q) = %q, %v, want ErrInvalidISBN", tt.isbn, got, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("NormalizeISBN(%q) = %q, %v, want %q", tt.isbn, got, err, tt.want)
+		}
+	}
+}
+
+func TestISBN10(t *testing.T) {
+	tests := []struct {
+		isbn13 string
+		want   string
+		ok     bool
+	}{
+		{"9780743273565", "0743273567", true},
+		{"9780804429573", "080442957X", true},
+		{"9791032305690", "", false},
+		{"978074327356", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := ISBN10(tt.isbn13)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ISBN10(%q) = %q, %v, want %q, %v", tt.isbn13, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestSetISBN(t *testing.T) {
+	var book Book
+	if err := book.SetISBN("0-7432-7356-7"); err != nil {
+		t.Fatalf("SetISBN: %v", err)
+	}
+	if book.ISBN13 == nil || *book.ISBN13 != "9780743273565" || book.ISBN10 == nil || *book.ISBN10 != "0743273567" {
+		t.Errorf("SetISBN stored %v, %v", book.ISBN13, book.ISBN10)
+	}
+
+	if err := book.SetISBN("979-10-323-0569-0"); err != nil {
+		t.Fatalf("SetISBN: %v", err)
+	}
+	if book.ISBN13 == nil || *book.ISBN13 != "9791032305690" || book.ISBN10 != nil {
+		t.Errorf("a 979 ISBN stored %v, %v; want no ISBN-10", book.ISBN13, book.ISBN10)
+	}
+
+	if err := book.SetISBN("123"); !errors.Is(err, ErrInvalidISBN) {
+		t.Errorf("SetISBN(123) = %v, want ErrInvalidISBN", err)
+	}
+	if *book.ISBN13 != "9791032305690" {
+		t.Errorf("an invalid ISBN changed the book to %s", *book.ISBN13)
+	}
+
+	if err := book.SetISBN(" "); err != nil || book.ISBN13 != nil || book.ISBN10 != nil {
+		t.Errorf("SetISBN(blank) = %v, left %v, %v", err, book.ISBN13, book.ISBN10)
+	}
+}
@@ -0,0 +1,191 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is assumed for prices that do not name a currency
+const DefaultCurrency = "USD"
+
+// ErrInvalidMoney is returned for malformed, negative or unsupported amounts
+var ErrInvalidMoney = errors.New("invalid money amount")
+
+// currencyExponents lists the supported ISO-4217 currencies and the number
+// of minor units (decimal places) each one uses
+var currencyExponents = map[string]int{
+	"AUD": 2, "BRL": 2, "CAD": 2, "CHF": 2, "CNY": 2, "CZK": 2, "DKK": 2,
+	"EUR": 2, "GBP": 2, "HKD": 2, "INR": 2, "MXN": 2, "NOK": 2, "NZD": 2,
+	"PLN": 2, "SEK": 2, "SGD": 2, "USD": 2, "ZAR": 2,
+	"JPY": 0, "KRW": 0,
+	"BHD": 3, "KWD": 3, "OMR": 3,
+}
+
+// currencySymbols maps the symbols found in legacy price strings to currencies
+var currencySymbols = map[string]string{
+	"$": "USD",
+	"€": "EUR",
+	"£": "GBP",
+	"¥": "JPY",
+	"₹": "INR",
+}
+
+// Money is an amount of a currency stored as an integer number of minor
+// units (e.g. cents) to avoid floating point rounding
+// @Description Monetary amount in minor units with an ISO-4217 currency code
+type Money struct {
+	// @Description Amount in minor units of the currency, e.g. cents
+	// @Example 1599
+	Amount int64 `json:"amount" gorm:"not null;default:0" example:"1599"`
+
+	// @Description ISO-4217 currency code
+	// @Example "USD"
+	Currency string `json:"currency" gorm:"type:char(3);not null;default:''" example:"USD"`
+}
+
+// NewMoney returns a validated Money value
+func NewMoney(amount int64, currency string) (Money, error) {
+	m := Money{Amount: amount, Currency: strings.ToUpper(currency)}
+	return m, m.Validate()
+}
+
+// IsZero reports whether m is the zero value, i.e. no price was given
+func (m Money) IsZero() bool {
+	return m == Money{}
+}
+
+// Validate checks that the amount is not negative and the currency is supported
+func (m Money) Validate() error {
+	if _, ok := currencyExponents[m.Currency]; !ok {
+		return fmt.Errorf("%w: unsupported currency %q", ErrInvalidMoney, m.Currency)
+	}
+	if m.Amount < 0 {
+		return fmt.Errorf("%w: amount must not be negative", ErrInvalidMoney)
+	}
+	return nil
+}
+
+// Decimal renders the amount in major units, e.g. "15.99"
+func (m Money) Decimal() string {
+	exp := currencyExponents[m.Currency]
+	if exp == 0 {
+		return strconv.FormatInt(m.Amount, 10)
+	}
+	scale := int64(math.Pow10(exp))
+	sign := ""
+	amount := m.Amount
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	return fmt.Sprintf("%s%d.%0*d", sign, amount/scale, exp, amount%scale)
+}
+
+// String renders the amount with its currency code, e.g. "15.99 USD"
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+// ParseDecimal converts a decimal amount in major units such as "15.99"
+// into Money of the given currency. More decimal places than the currency
+// supports are rejected rather than rounded.
+func ParseDecimal(amount, currency string) (Money, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	exp, ok := currencyExponents[currency]
+	if !ok {
+		return Money{}, fmt.Errorf("%w: unsupported currency %q", ErrInvalidMoney, currency)
+	}
+
+	amount = strings.TrimSpace(amount)
+	if strings.HasPrefix(amount, "-") {
+		return Money{}, fmt.Errorf("%w: amount must not be negative", ErrInvalidMoney)
+	}
+	whole, frac, hasFrac := strings.Cut(amount, ".")
+	if whole == "" || !isDigits(whole) || (hasFrac && (frac == "" || !isDigits(frac))) {
+		return Money{}, fmt.Errorf("%w: %q is not a decimal amount", ErrInvalidMoney, amount)
+	}
+	if len(frac) > exp {
+		return Money{}, fmt.Errorf("%w: %s allows at most %d decimal places", ErrInvalidMoney, currency, exp)
+	}
+
+	units, err := strconv.ParseInt(whole+frac+strings.Repeat("0", exp-len(frac)), 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: %q is out of range", ErrInvalidMoney, amount)
+	}
+	return Money{Amount: units, Currency: currency}, nil
+}
+
+// ParseLegacyPrice converts a free-form price string as stored before prices
+// had a currency, such as "$15.99", "15.99 EUR", "GBP 4.50" or "1,299.00",
+// into Money. Strings without a currency are taken to be DefaultCurrency.
+func ParseLegacyPrice(price string) (Money, error) {
+	s := strings.TrimSpace(price)
+	currency := ""
+
+	for symbol, code := range currencySymbols {
+		if strings.HasPrefix(s, symbol) || strings.HasSuffix(s, symbol) {
+			currency = code
+			s = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(s, symbol), symbol))
+			break
+		}
+	}
+	if currency == "" {
+		if fields := strings.Fields(s); len(fields) == 2 {
+			if _, ok := currencyExponents[strings.ToUpper(fields[0])]; ok {
+				currency, s = fields[0], fields[1]
+			} else if _, ok := currencyExponents[strings.ToUpper(fields[1])]; ok {
+				currency, s = fields[1], fields[0]
+			}
+		}
+	}
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+
+	// Drop thousands separators
+	s = strings.ReplaceAll(s, ",", "")
+	m, err := ParseDecimal(s, currency)
+	if err != nil {
+		return Money{}, fmt.Errorf("price %q: %w", price, err)
+	}
+	return m, nil
+}
+
+// UnmarshalJSON accepts either the object form {"amount": 1599, "currency": "USD"}
+// or a legacy price string such as "$15.99"
+func (m *Money) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		var legacy string
+		if err := json.Unmarshal(data, &legacy); err != nil {
+			return err
+		}
+		parsed, err := ParseLegacyPrice(legacy)
+		if err != nil {
+			return err
+		}
+		*m = parsed
+		return nil
+	}
+
+	// Use a distinct type so that json does not recurse into this method
+	type money Money
+	var raw money
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*m = Money(raw)
+	m.Currency = strings.ToUpper(m.Currency)
+	return nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,139 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		amount, currency string
+		want             Money
+		wantErr          bool
+	}{
+		{"15.99", "USD", Money{1599, "USD"}, false},
+		{"15.9", "usd", Money{1590, "USD"}, false},
+		{"15", "USD", Money{1500, "USD"}, false},
+		{" 0.01 ", " eur ", Money{1, "EUR"}, false},
+		{"0", "USD", Money{0, "USD"}, false},
+		{"1000", "JPY", Money{1000, "JPY"}, false},
+		{"1.234", "KWD", Money{1234, "KWD"}, false},
+		{"1.5", "JPY", Money{}, true},
+		{"15.999", "USD", Money{}, true},
+		{"-1", "USD", Money{}, true},
+		{"1.", "USD", Money{}, true},
+		{".5", "USD", Money{}, true},
+		{"1e3", "USD", Money{}, true},
+		{"1,000", "USD", Money{}, true},
+		{"", "USD", Money{}, true},
+		{"1", "XXX", Money{}, true},
+		{"99999999999999999999", "USD", Money{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseDecimal(tt.amount, tt.currency)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidMoney) {
+				t.Errorf("ParseDecimal(%q, %q) error = %v, want ErrInvalidMoney", tt.amount, tt.currency, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseDecimal(%q, %q) = %v, %v, want %v", tt.amount, tt.currency, got, err, tt.want)
+		}
+	}
+}
+
+func TestMoneyDecimal(t *testing.T) {
+	tests := []struct {
+		money Money
+		want  string
+	}{
+		{Money{1599, "USD"}, "15.99"},
+		{Money{5, "USD"}, "0.05"},
+		{Money{1000, "JPY"}, "1000"},
+		{Money{1234, "KWD"}, "1.234"},
+		{Money{-150, "EUR"}, "-1.50"},
+	}
+	for _, tt := range tests {
+		if got := tt.money.Decimal(); got != tt.want {
+			t.Errorf("%#v.Decimal() = %q, want %q", tt.money, got, tt.want)
+		}
+		if tt.money.Amount < 0 {
+			continue
+		}
+		// Decimal and ParseDecimal are inverses
+		if back, err := ParseDecimal(tt.money.Decimal(), tt.money.Currency); err != nil || back != tt.money {
+			t.Errorf("ParseDecimal(%q) = %v, %v, want %v", tt.money.Decimal(), back, err, tt.money)
+		}
+	}
+}
+
+func TestParseLegacyPrice(t *testing.T) {
+	tests := []struct {
+		price   string
+		want    Money
+		wantErr bool
+	}{
+		{"$15.99", Money{1599, "USD"}, false},
+		{"15.99", Money{1599, "USD"}, false},
+		{"15.99 EUR", Money{1599, "EUR"}, false},
+		{"GBP 4.50", Money{450, "GBP"}, false},
+		{"€ 9", Money{900, "EUR"}, false},
+		{"¥1200", Money{1200, "JPY"}, false},
+		{"1,299.00", Money{129900, "USD"}, false},
+		{"free", Money{}, true},
+		{"$-3", Money{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseLegacyPrice(tt.price)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidMoney) {
+				t.Errorf("ParseLegacyPrice(%q) error = %v, want ErrInvalidMoney", tt.price, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseLegacyPrice(%q) = %v, %v, want %v", tt.price, got, err, tt.want)
+		}
+	}
+}
+
+func TestMoneyUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		json    string
+		want    Money
+		wantErr bool
+	}{
+		{`{"amount": 1599, "currency": "usd"}`, Money{1599, "USD"}, false},
+		{`"$15.99"`, Money{1599, "USD"}, false},
+		{`"4.50 GBP"`, Money{450, "GBP"}, false},
+		{`"cheap"`, Money{}, true},
+		{`{"amount": "1599"}`, Money{}, true},
+	}
+	for _, tt := range tests {
+		var got Money
+		err := json.Unmarshal([]byte(tt.json), &got)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("Unmarshal(%s) = %v, %v, want %v (error: %v)", tt.json, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestMoneyValidate(t *testing.T) {
+	tests := []struct {
+		money Money
+		valid bool
+	}{
+		{Money{1599, "USD"}, true},
+		{Money{0, "JPY"}, true},
+		{Money{-1, "USD"}, false},
+		{Money{100, "usd"}, false},
+		{Money{100, ""}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.money.Validate(); (err == nil) != tt.valid {
+			t.Errorf("%#v.Validate() = %v, want valid %v", tt.money, err, tt.valid)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package models
+
+import "gorm.io/gorm"
+
+// Repositories groups the storage backends the API is served from
+type Repositories struct {
+	Books   BookRepository
+	Authors AuthorRepository
+	Stock   StockRepository
+	APIKeys APIKeyRepository
+	Users   UserRepository
+}
+
+// NewGormRepositories returns repositories that store everything in db
+func NewGormRepositories(db *gorm.DB) Repositories {
+	return Repositories{
+		Books:   NewGormBookRepository(db),
+		Authors: NewGormAuthorRepository(db),
+		Stock:   NewGormStockRepository(db),
+		APIKeys: NewGormAPIKeyRepository(db),
+		Users:   NewGormUserRepository(db),
+	}
+}
+
+// NewMemoryRepositories returns empty in-memory repositories
+func NewMemoryRepositories() Repositories {
+	authors := NewMemoryAuthorRepository()
+	return Repositories{
+		Books:   NewMemoryBookRepository(authors),
+		Authors: authors,
+		Stock:   NewMemoryStockRepository(),
+		APIKeys: NewMemoryAPIKeyRepository(),
+		Users:   NewMemoryUserRepository(),
+	}
+}
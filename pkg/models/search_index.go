@@ -0,0 +1,186 @@
+package models
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// searchFields are the fields of a book the search index covers, with the
+// weight a match in each carries
+var searchFields = []struct {
+	text   func(b *Book) string
+	weight float64
+}{
+	{func(b *Book) string { return b.Title }, 3},
+	{func(b *Book) string { return b.Author }, 2},
+	{func(b *Book) string { return b.Description }, 1},
+}
+
+// BM25 parameters: how quickly repeated matches stop adding to the score,
+// and how much long fields are penalized
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// searchIndex is an in-process inverted index of the searchable fields of
+// books. It backs searches where the database has no full-text index. It
+// is not safe for concurrent use.
+type searchIndex struct {
+	// docs holds the words of each field of every indexed book
+	docs map[uint][][]string
+	// postings lists the books containing each word
+	postings map[string]map[uint]struct{}
+	// words is the sorted vocabulary, for prefix lookups; nil when stale
+	words []string
+	// fieldWords is the total number of words of each field
+	fieldWords []int
+}
+
+func newSearchIndex() *searchIndex {
+	return &searchIndex{
+		docs:       make(map[uint][][]string),
+		postings:   make(map[string]map[uint]struct{}),
+		fieldWords: make([]int, len(searchFields)),
+	}
+}
+
+// add indexes book, replacing any earlier version of it
+func (idx *searchIndex) add(book *Book) {
+	idx.remove(book.ID)
+	fields := make([][]string, len(searchFields))
+	for i, field := range searchFields {
+		fields[i] = searchWords(field.text(book))
+		idx.fieldWords[i] += len(fields[i])
+		for _, word := range fields[i] {
+			books, ok := idx.postings[word]
+			if !ok {
+				books = make(map[uint]struct{})
+				idx.postings[word] = books
+				idx.words = nil
+			}
+			books[book.ID] = struct{}{}
+		}
+	}
+	idx.docs[book.ID] = fields
+}
+
+// remove takes the book with the given ID out of the index
+func (idx *searchIndex) remove(id uint) {
+	fields, ok := idx.docs[id]
+	if !ok {
+		return
+	}
+	for i, words := range fields {
+		idx.fieldWords[i] -= len(words)
+		for _, word := range words {
+			delete(idx.postings[word], id)
+			if len(idx.postings[word]) == 0 {
+				delete(idx.postings, word)
+				idx.words = nil
+			}
+		}
+	}
+	delete(idx.docs, id)
+}
+
+// candidates returns the books that may match term: those containing its
+// words, or for a prefix a word starting with it
+func (idx *searchIndex) candidates(term SearchTerm) map[uint]struct{} {
+	if !term.Prefix {
+		// The rarest word of a phrase narrows it down the most
+		var found map[uint]struct{}
+		for _, word := range term.Words {
+			books, ok := idx.postings[word]
+			if !ok {
+				return nil
+			}
+			if found == nil || len(books) < len(found) {
+				found = books
+			}
+		}
+		return found
+	}
+	if idx.words == nil {
+		idx.words = make([]string, 0, len(idx.postings))
+		for word := range idx.postings {
+			idx.words = append(idx.words, word)
+		}
+		sort.Strings(idx.words)
+	}
+	found := make(map[uint]struct{})
+	prefix := term.Words[0]
+	for i := sort.SearchStrings(idx.words, prefix); i < len(idx.words) && strings.HasPrefix(idx.words[i], prefix); i++ {
+		for id := range idx.postings[idx.words[i]] {
+			found[id] = struct{}{}
+		}
+	}
+	return found
+}
+
+// search returns the books matching every term with their BM25 score,
+// summed over the terms and weighted by field, best first
+func (idx *searchIndex) search(terms []SearchTerm) []BookHit {
+	// Occurrences of each term in each field of the matching books
+	type match struct {
+		term   int
+		counts []int
+	}
+	matches := make(map[uint][]match)
+	docFreq := make([]int, len(terms))
+	for t, term := range terms {
+		for id := range idx.candidates(term) {
+			counts := make([]int, len(searchFields))
+			total := 0
+			for f, words := range idx.docs[id] {
+				counts[f] = term.occurrences(words)
+				total += counts[f]
+			}
+			if total == 0 {
+				continue
+			}
+			docFreq[t]++
+			// Only books matching the earlier terms can match them all
+			if len(matches[id]) == t {
+				matches[id] = append(matches[id], match{term: t, counts: counts})
+			}
+		}
+	}
+
+	n := float64(len(idx.docs))
+	var hits []BookHit
+	for id, found := range matches {
+		if len(found) != len(terms) {
+			continue
+		}
+		score := 0.0
+		for _, m := range found {
+			df := float64(docFreq[m.term])
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			for f, count := range m.counts {
+				if count == 0 {
+					continue
+				}
+				tf := float64(count)
+				avg := float64(idx.fieldWords[f]) / n
+				norm := 1 - bm25B + bm25B*float64(len(idx.docs[id][f]))/avg
+				score += searchFields[f].weight * idf * tf * (bm25K1 + 1) / (tf + bm25K1*norm)
+			}
+		}
+		hits = append(hits, BookHit{Book: Book{ID: id}, Score: score})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Book.ID < hits[j].Book.ID
+	})
+	return hits
+}
+
+// paginate cuts one page out of the complete list of hits
+func (s BookSearch) paginate(hits []BookHit) *SearchPage {
+	start := min(s.Offset, len(hits))
+	return &SearchPage{Hits: hits[start:min(start+s.Limit, len(hits))], Total: int64(len(hits))}
+}
@@ -0,0 +1,194 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrInsufficientStock is returned when a movement would drive on-hand or
+	// available stock below zero
+	ErrInsufficientStock = errors.New("insufficient stock")
+	// ErrInvalidMovement is returned for movements with an unknown type or bad quantity
+	ErrInvalidMovement = errors.New("invalid stock movement")
+)
+
+// Stock movement types
+const (
+	// MovementReceive adds delivered copies to on-hand stock
+	MovementReceive = "receive"
+	// MovementSell removes sold copies from on-hand stock
+	MovementSell = "sell"
+	// MovementReturn adds copies returned by customers to on-hand stock
+	MovementReturn = "return"
+	// MovementAdjust corrects on-hand stock by a signed quantity, e.g. after a count
+	MovementAdjust = "adjust"
+	// MovementReserve sets copies aside for pending orders
+	MovementReserve = "reserve"
+	// MovementRelease returns reserved copies to available stock
+	MovementRelease = "release"
+)
+
+// StockLevel is the current inventory of a book
+// @Description Current inventory of a book
+type StockLevel struct {
+	// @Description Identifier of the book
+	// @Example 1
+	BookID uint `json:"book_id" gorm:"primaryKey;autoIncrement:false" example:"1"`
+
+	// @Description Copies physically in stock
+	// @Example 12
+	OnHand int `json:"on_hand" gorm:"not null;default:0" example:"12"`
+
+	// @Description Copies set aside for pending orders
+	// @Example 2
+	Reserved int `json:"reserved" gorm:"not null;default:0" example:"2"`
+
+	// @Description Copies that can still be sold (on_hand - reserved)
+	// @Example 10
+	Available int `json:"available" gorm:"-" example:"10"`
+
+	// @Description Available stock at or below which the book needs reordering
+	// @Example 5
+	ReorderThreshold int `json:"reorder_threshold" gorm:"not null;default:0" example:"5"`
+
+	// @Description Whether available stock is at or below the reorder threshold
+	// @Example false
+	NeedsReorder bool `json:"needs_reorder" gorm:"-" example:"false"`
+
+	// @Description When the level last changed
+	// @Example "2023-01-01T00:00:00Z"
+	UpdatedAt time.Time `json:"updated_at" example:"2023-01-01T00:00:00Z"`
+}
+
+// StockMovement is an entry in the append-only stock ledger
+// @Description Entry in the append-only stock ledger of a book
+type StockMovement struct {
+	// @Description Unique identifier for the movement
+	// @Example 1
+	ID uint `json:"id" gorm:"primaryKey" example:"1"`
+
+	// @Description Identifier of the book
+	// @Example 1
+	BookID uint `json:"book_id" gorm:"not null;index" example:"1"`
+
+	// @Description receive, sell, return, adjust, reserve or release
+	// @Example "sell"
+	Type string `json:"type" gorm:"size:16;not null" example:"sell"`
+
+	// @Description Number of copies; signed only for adjust
+	// @Example 1
+	Quantity int `json:"quantity" gorm:"not null" example:"1"`
+
+	// @Description Why the movement happened
+	// @Example "order #1042"
+	Reason string `json:"reason" gorm:"size:255" example:"order #1042"`
+
+	// @Description On-hand stock after the movement
+	// @Example 11
+	OnHandAfter int `json:"on_hand_after" gorm:"not null" example:"11"`
+
+	// @Description Reserved stock after the movement
+	// @Example 2
+	ReservedAfter int `json:"reserved_after" gorm:"not null" example:"2"`
+
+	// @Description When the movement was recorded
+	// @Example "2023-01-01T00:00:00Z"
+	CreatedAt time.Time `json:"created_at" example:"2023-01-01T00:00:00Z"`
+}
+
+// StockMovementRequest represents the movement request structure for API documentation
+// @Description Stock movement request model for API documentation
+type StockMovementRequest struct {
+	// @Description receive, sell, return, adjust, reserve or release
+	// @Example "sell"
+	Type string `json:"type" example:"sell" binding:"required,oneof=receive sell return adjust reserve release"`
+
+	// @Description Number of copies; must be positive except for adjust, where it is a signed correction
+	// @Example 1
+	Quantity int `json:"quantity" example:"1" binding:"required"`
+
+	// @Description Why the movement happened
+	// @Example "order #1042"
+	Reason string `json:"reason" example:"order #1042" binding:"max=255"`
+}
+
+// StockSettingsRequest represents the stock settings request structure for API documentation
+// @Description Stock settings request model for API documentation
+type StockSettingsRequest struct {
+	// @Description Available stock at or below which the book needs reordering
+	// @Example 5
+	ReorderThreshold int `json:"reorder_threshold" example:"5" binding:"min=0"`
+}
+
+// StockMovementListResponse represents a page of stock movements for API documentation
+// @Description Paginated stock movement list model for API documentation
+type StockMovementListResponse struct {
+	// @Description Movements in the page, newest first
+	Data []StockMovement `json:"data"`
+
+	// @Description Pagination metadata
+	Pagination Pagination `json:"pagination"`
+}
+
+// ToMovement copies the request fields into a new StockMovement for bookID
+func (r *StockMovementRequest) ToMovement(bookID uint) *StockMovement {
+	return &StockMovement{BookID: bookID, Type: r.Type, Quantity: r.Quantity, Reason: r.Reason}
+}
+
+// Apply returns the level after applying movement m, or an error if the
+// movement is invalid or would leave on-hand stock below zero or below the
+// reserved quantity. The level itself is not modified.
+func (l StockLevel) Apply(m *StockMovement) (StockLevel, error) {
+	if m.Type != MovementAdjust && m.Quantity <= 0 {
+		return l, fmt.Errorf("%w: quantity must be positive for %s", ErrInvalidMovement, m.Type)
+	}
+	switch m.Type {
+	case MovementReceive, MovementReturn:
+		l.OnHand += m.Quantity
+	case MovementSell:
+		if m.Quantity > l.OnHand-l.Reserved {
+			return l, fmt.Errorf("%w: cannot sell %d, %d available", ErrInsufficientStock, m.Quantity, l.OnHand-l.Reserved)
+		}
+		l.OnHand -= m.Quantity
+	case MovementAdjust:
+		if m.Quantity == 0 {
+			return l, fmt.Errorf("%w: adjustment must not be zero", ErrInvalidMovement)
+		}
+		if l.OnHand+m.Quantity < l.Reserved {
+			return l, fmt.Errorf("%w: adjustment would leave %d on hand with %d reserved", ErrInsufficientStock, l.OnHand+m.Quantity, l.Reserved)
+		}
+		l.OnHand += m.Quantity
+	case MovementReserve:
+		if m.Quantity > l.OnHand-l.Reserved {
+			return l, fmt.Errorf("%w: cannot reserve %d, %d available", ErrInsufficientStock, m.Quantity, l.OnHand-l.Reserved)
+		}
+		l.Reserved += m.Quantity
+	case MovementRelease:
+		if m.Quantity > l.Reserved {
+			return l, fmt.Errorf("%w: cannot release %d, %d reserved", ErrInvalidMovement, m.Quantity, l.Reserved)
+		}
+		l.Reserved -= m.Quantity
+	default:
+		return l, fmt.Errorf("%w: unknown type %q", ErrInvalidMovement, m.Type)
+	}
+	return l.withDerived(), nil
+}
+
+// withDerived fills in the computed fields of the level
+func (l StockLevel) withDerived() StockLevel {
+	l.Available = l.OnHand - l.Reserved
+	l.NeedsReorder = l.Available <= l.ReorderThreshold
+	return l
+}
+
+// StockMovementResponse is the result of posting a stock movement
+// @Description Recorded stock movement and the resulting stock level
+type StockMovementResponse struct {
+	// @Description The recorded movement
+	Movement StockMovement `json:"movement"`
+
+	// @Description Stock level after the movement
+	Level StockLevel `json:"level"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import "context"
+
+// StockMovementPage is one page of a book's stock ledger
+type StockMovementPage struct {
+	Movements []StockMovement
+	// Total is the number of movements recorded for the book
+	Total int64
+}
+
+// StockRepository stores stock levels and the movement ledger. Movements
+// must be applied atomically so that concurrent sales cannot drive stock
+// negative. Implementations must be safe for concurrent use.
+type StockRepository interface {
+	// Level returns the current stock of a book; books without any movement
+	// have an all-zero level
+	Level(ctx context.Context, bookID uint) (*StockLevel, error)
+	// ApplyMovement records movement and updates the level in one
+	// transaction, returning the new level. The movement's ID, CreatedAt and
+	// resulting quantities are filled in.
+	ApplyMovement(ctx context.Context, movement *StockMovement) (*StockLevel, error)
+	// Movements returns a page of the book's movements, newest first
+	Movements(ctx context.Context, bookID uint, limit, offset int) (*StockMovementPage, error)
+	// SetReorderThreshold changes the reorder threshold of a book
+	SetReorderThreshold(ctx context.Context, bookID uint, threshold int) (*StockLevel, error)
+}
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GormStockRepository is a StockRepository backed by a GORM database
+type GormStockRepository struct {
+	db *gorm.DB
+}
+
+// NewGormStockRepository returns a StockRepository that stores stock in db
+func NewGormStockRepository(db *gorm.DB) *GormStockRepository {
+	return &GormStockRepository{db: db}
+}
+
+func (r *GormStockRepository) Level(ctx context.Context, bookID uint) (*StockLevel, error) {
+	level := StockLevel{BookID: bookID}
+	err := r.db.WithContext(ctx).First(&level, "book_id = ?", bookID).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	level = level.withDerived()
+	return &level, nil
+}
+
+func (r *GormStockRepository) ApplyMovement(ctx context.Context, movement *StockMovement) (*StockLevel, error) {
+	var level StockLevel
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		locked, err := lockStockLevel(tx, movement.BookID)
+		if err != nil {
+			return err
+		}
+		if level, err = locked.Apply(movement); err != nil {
+			return err
+		}
+		movement.OnHandAfter, movement.ReservedAfter = level.OnHand, level.Reserved
+		err = tx.Model(&level).Select("on_hand", "reserved", "updated_at").Updates(&level).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(movement).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &level, nil
+}
+
+func (r *GormStockRepository) Movements(ctx context.Context, bookID uint, limit, offset int) (*StockMovementPage, error) {
+	page := &StockMovementPage{}
+	db := r.db.WithContext(ctx).Model(&StockMovement{}).Where("book_id = ?", bookID)
+	if err := db.Session(&gorm.Session{}).Count(&page.Total).Error; err != nil {
+		return nil, err
+	}
+	err := db.Session(&gorm.Session{}).Order("id DESC").Limit(limit).Offset(offset).Find(&page.Movements).Error
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+func (r *GormStockRepository) SetReorderThreshold(ctx context.Context, bookID uint, threshold int) (*StockLevel, error) {
+	var level StockLevel
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		locked, err := lockStockLevel(tx, bookID)
+		if err != nil {
+			return err
+		}
+		level = *locked
+		level.ReorderThreshold = threshold
+		return tx.Model(&level).Select("reorder_threshold", "updated_at").Updates(&level).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	level = level.withDerived()
+	return &level, nil
+}
+
+// lockStockLevel creates the book's level row if needed and locks it for the
+// rest of the transaction, serializing concurrent movements on the book
+func lockStockLevel(tx *gorm.DB, bookID uint) (*StockLevel, error) {
+	err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&StockLevel{BookID: bookID}).Error
+	if err != nil {
+		return nil, err
+	}
+	var level StockLevel
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&level, "book_id = ?", bookID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &level, nil
+}
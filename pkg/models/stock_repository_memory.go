@@ -0,0 +1,83 @@
+package models
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStockRepository is a StockRepository that keeps stock in process memory
+type MemoryStockRepository struct {
+	mu        sync.Mutex
+	levels    map[uint]StockLevel
+	movements []StockMovement
+}
+
+// NewMemoryStockRepository returns an empty in-memory StockRepository
+func NewMemoryStockRepository() *MemoryStockRepository {
+	return &MemoryStockRepository{levels: make(map[uint]StockLevel)}
+}
+
+func (r *MemoryStockRepository) Level(ctx context.Context, bookID uint) (*StockLevel, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	level := r.level(bookID).withDerived()
+	return &level, nil
+}
+
+func (r *MemoryStockRepository) ApplyMovement(ctx context.Context, movement *StockMovement) (*StockLevel, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	level, err := r.level(movement.BookID).Apply(movement)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	level.UpdatedAt = now
+	r.levels[movement.BookID] = level
+
+	movement.ID = uint(len(r.movements) + 1)
+	movement.CreatedAt = now
+	movement.OnHandAfter, movement.ReservedAfter = level.OnHand, level.Reserved
+	r.movements = append(r.movements, *movement)
+	return &level, nil
+}
+
+func (r *MemoryStockRepository) Movements(ctx context.Context, bookID uint, limit, offset int) (*StockMovementPage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	page := &StockMovementPage{}
+	for i := len(r.movements) - 1; i >= 0; i-- {
+		if r.movements[i].BookID != bookID {
+			continue
+		}
+		if page.Total >= int64(offset) && len(page.Movements) < limit {
+			page.Movements = append(page.Movements, r.movements[i])
+		}
+		page.Total++
+	}
+	return page, nil
+}
+
+func (r *MemoryStockRepository) SetReorderThreshold(ctx context.Context, bookID uint, threshold int) (*StockLevel, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	level := r.level(bookID)
+	level.ReorderThreshold = threshold
+	level.UpdatedAt = time.Now()
+	r.levels[bookID] = level
+	level = level.withDerived()
+	return &level, nil
+}
+
+// level returns the stored level of a book; the caller must hold the lock
+func (r *MemoryStockRepository) level(bookID uint) StockLevel {
+	if level, ok := r.levels[bookID]; ok {
+		return level
+	}
+	return StockLevel{BookID: bookID}
+}
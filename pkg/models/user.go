@@ -0,0 +1,91 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrUserNotFound is returned by a UserRepository when no user matches
+	ErrUserNotFound = errors.New("user not found")
+	// ErrUserExists is returned when a user with the same email exists
+	ErrUserExists = errors.New("user already exists")
+)
+
+// Staff roles. Each role maps onto the API key scope of the same rank, so
+// routes are guarded the same way for users and keys.
+const (
+	// UserRoleViewer can read the catalogue
+	UserRoleViewer = "viewer"
+	// UserRoleEditor can also create and change books, authors and stock
+	UserRoleEditor = "editor"
+	// UserRoleAdmin can also delete and manage API keys
+	UserRoleAdmin = "admin"
+)
+
+var roleScope = map[string]string{
+	UserRoleViewer: ScopeRead,
+	UserRoleEditor: ScopeWrite,
+	UserRoleAdmin:  ScopeAdmin,
+}
+
+// RoleScopes returns the scopes granted to a role, or nil for unknown roles
+func RoleScopes(role string) Scopes {
+	scope, ok := roleScope[role]
+	if !ok {
+		return nil
+	}
+	return Scopes{scope}
+}
+
+// User is a staff member who logs in with an email and password
+// @Description Staff user
+type User struct {
+	// @Description Unique identifier for the user
+	// @Example 1
+	ID uint `json:"id" gorm:"primaryKey" example:"1"`
+
+	// @Description Login email, stored lower case
+	// @Example "jo@example.com"
+	Email string `json:"email" gorm:"size:255;not null;uniqueIndex" example:"jo@example.com"`
+
+	// PasswordHash is produced by auth.HashPassword
+	PasswordHash string `json:"-" gorm:"size:255;not null"`
+
+	// @Description viewer, editor or admin
+	// @Example "editor"
+	Role string `json:"role" gorm:"size:16;not null" example:"editor"`
+
+	// @Description When the user was created
+	// @Example "2023-01-01T00:00:00Z"
+	CreatedAt time.Time `json:"created_at" example:"2023-01-01T00:00:00Z"`
+
+	// @Description When the user was last updated
+	// @Example "2023-01-01T00:00:00Z"
+	UpdatedAt time.Time `json:"updated_at" example:"2023-01-01T00:00:00Z"`
+}
+
+// NormalizeEmail returns the form emails are stored and looked up in
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// LoginRequest represents the login request structure for API documentation
+// @Description Login request model for API documentation
+type LoginRequest struct {
+	// @Description Login email
+	// @Example "jo@example.com"
+	Email string `json:"email" example:"jo@example.com" binding:"required,max=255"`
+
+	// @Description Password
+	// @Example "correct horse battery staple"
+	Password string `json:"password" example:"correct horse battery staple" binding:"required,max=1024"`
+}
+
+// RefreshRequest represents the token refresh request structure for API documentation
+// @Description Token refresh request model for API documentation
+type RefreshRequest struct {
+	// @Description Refresh token from a previous login or refresh
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import "context"
+
+// UserRepository stores staff users. Implementations must be safe for
+// concurrent use.
+type UserRepository interface {
+	// Create stores a new user; ErrUserExists if the email is taken
+	Create(ctx context.Context, user *User) error
+	// Get returns the user with the given ID or ErrUserNotFound
+	Get(ctx context.Context, id uint) (*User, error)
+	// GetByEmail returns the user with the given email or ErrUserNotFound
+	GetByEmail(ctx context.Context, email string) (*User, error)
+}
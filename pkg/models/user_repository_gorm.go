@@ -0,0 +1,50 @@
+package models
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// GormUserRepository is a UserRepository backed by a GORM database
+type GormUserRepository struct {
+	db *gorm.DB
+}
+
+// NewGormUserRepository returns a UserRepository that stores users in db
+func NewGormUserRepository(db *gorm.DB) *GormUserRepository {
+	return &GormUserRepository{db: db}
+}
+
+func (r *GormUserRepository) Create(ctx context.Context, user *User) error {
+	user.Email = NormalizeEmail(user.Email)
+	return translateUserError(r.db.WithContext(ctx).Create(user).Error)
+}
+
+func (r *GormUserRepository) Get(ctx context.Context, id uint) (*User, error) {
+	var user User
+	if err := r.db.WithContext(ctx).First(&user, id).Error; err != nil {
+		return nil, translateUserError(err)
+	}
+	return &user, nil
+}
+
+func (r *GormUserRepository) GetByEmail(ctx context.Context, email string) (*User, error) {
+	var user User
+	if err := r.db.WithContext(ctx).Where("email = ?", NormalizeEmail(email)).First(&user).Error; err != nil {
+		return nil, translateUserError(err)
+	}
+	return &user, nil
+}
+
+// translateUserError maps GORM errors to the repository's sentinel errors
+func translateUserError(err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return ErrUserNotFound
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return ErrUserExists
+	}
+	return err
+}
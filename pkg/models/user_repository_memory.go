@@ -0,0 +1,61 @@
+package models
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryUserRepository is a UserRepository that keeps users in process memory
+type MemoryUserRepository struct {
+	mu     sync.RWMutex
+	users  map[uint]User
+	nextID uint
+}
+
+// NewMemoryUserRepository returns an empty in-memory UserRepository
+func NewMemoryUserRepository() *MemoryUserRepository {
+	return &MemoryUserRepository{users: make(map[uint]User), nextID: 1}
+}
+
+func (r *MemoryUserRepository) Create(ctx context.Context, user *User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user.Email = NormalizeEmail(user.Email)
+	for _, existing := range r.users {
+		if existing.Email == user.Email {
+			return ErrUserExists
+		}
+	}
+	now := time.Now()
+	user.ID = r.nextID
+	r.nextID++
+	user.CreatedAt, user.UpdatedAt = now, now
+	r.users[user.ID] = *user
+	return nil
+}
+
+func (r *MemoryUserRepository) Get(ctx context.Context, id uint) (*User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	return &user, nil
+}
+
+func (r *MemoryUserRepository) GetByEmail(ctx context.Context, email string) (*User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	email = NormalizeEmail(email)
+	for _, user := range r.users {
+		if user.Email == email {
+			return &user, nil
+		}
+	}
+	return nil, ErrUserNotFound
+}
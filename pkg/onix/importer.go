@@ -0,0 +1,311 @@
+package onix
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-bookstore-mysql-crud/pkg/models"
+	"go-bookstore-mysql-crud/pkg/validation"
+	"io"
+	"slices"
+)
+
+// importBatchSize is the number of products stored per repository batch
+const importBatchSize = 500
+
+// Status is the outcome of importing a product that was not stored
+type Status string
+
+// Outcomes of importing a product that was not stored
+const (
+	StatusSkipped Status = "skipped"
+	StatusFailed  Status = "failed"
+)
+
+// Reasons for skipping a product
+const (
+	reasonDeleted   = "deletion notices are not applied; move the book to the trash instead"
+	reasonNoISBN    = "the product has no ISBN"
+	reasonUnchanged = "the book is up to date"
+)
+
+// Importer stores the products of ONIX messages as books. Products are
+// matched to books by ISBN: a product whose ISBN belongs to a book updates
+// it, keeping the fields the product does not provide, and any other
+// product creates a book.
+type Importer struct {
+	Books   models.BookRepository
+	Authors models.AuthorRepository
+	// Currency selects the prices to import; when empty the price in any
+	// supported currency is taken
+	Currency string
+	// DryRun reports what the import would do without storing anything
+	DryRun bool
+}
+
+// Report is the outcome of an import
+type Report struct {
+	DryRun   bool
+	Products int
+	Created  int
+	Updated  int
+	Skipped  int
+	Failed   int
+	// Results holds the outcome of each skipped or failed product in message
+	// order; created and updated products are only counted, so that the
+	// report stays small whatever the size of the message
+	Results []ProductResult
+	// Error is set when the message became unreadable part way through; the
+	// products before it were imported
+	Error error
+}
+
+// ProductResult is the outcome of importing a product that was skipped or
+// failed
+type ProductResult struct {
+	// Line is the line of the message the product starts on
+	Line            int
+	RecordReference string
+	// ISBN is the ISBN of the product as written in the message
+	ISBN   string
+	Status Status
+	// BookID is the book the product was matched to, if any
+	BookID uint
+	// Reason explains why the product was skipped
+	Reason string
+	// Err explains why the product failed
+	Err error
+}
+
+// Import reads the message in r and stores its products. Invalid products
+// are reported and do not stop the import. It fails with ErrInvalidMessage
+// when r is not an ONIX 3.0 message, and with the underlying error when
+// reading r or storing books fails; the products before the failure may
+// have been stored.
+func (imp *Importer) Import(ctx context.Context, r io.Reader) (*Report, error) {
+	run := &importRun{imp: imp, seen: make(map[string]int), report: &Report{DryRun: imp.DryRun, Results: []ProductResult{}}}
+	reader := NewReader(r)
+	for {
+		product, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if errors.Is(err, ErrInvalidMessage) && run.report.Products > 0 {
+			run.report.Error = err
+			break
+		}
+		if err != nil {
+			return run.report, err
+		}
+		if err := run.product(ctx, reader.Header(), product); err != nil {
+			return run.report, err
+		}
+		if len(run.pending) >= importBatchSize {
+			if err := run.flush(ctx); err != nil {
+				return run.report, err
+			}
+		}
+	}
+	if err := run.flush(ctx); err != nil {
+		return run.report, err
+	}
+	return run.report, nil
+}
+
+// fail records a product that failed
+func (run *importRun) fail(result ProductResult, err error) {
+	result.Status, result.Err = StatusFailed, err
+	run.report.Failed++
+	// Products that fail when their batch is stored are reported after the
+	// products read since, so they are put back in line order
+	run.report.Results = insertByLine(run.report.Results, result)
+}
+
+// insertByLine inserts result into results, which are ordered by line
+func insertByLine(results []ProductResult, result ProductResult) []ProductResult {
+	i := len(results)
+	for i > 0 && results[i-1].Line > result.Line {
+		i--
+	}
+	return slices.Insert(results, i, result)
+}
+
+// importRun holds the state of one import
+type importRun struct {
+	imp *Importer
+	// seen holds the line each ISBN-13 was first read on
+	seen map[string]int
+	// pending holds the operations not stored yet and pendingResults the
+	// results of their products should they fail
+	pending        []models.BookOperation
+	pendingResults []ProductResult
+	report         *Report
+}
+
+// product imports one product, queueing the change it makes
+func (run *importRun) product(ctx context.Context, header Header, product *Product) error {
+	run.report.Products++
+	result := ProductResult{Line: product.Line, RecordReference: product.RecordReference, ISBN: product.ISBN()}
+	op, reason, err := run.prepare(ctx, header, product)
+	switch {
+	case err != nil && !isProductError(err):
+		return err
+	case err != nil:
+		run.fail(result, err)
+	case reason != "":
+		result.Status, result.Reason, result.BookID = StatusSkipped, reason, op.ID
+		run.report.Skipped++
+		run.report.Results = append(run.report.Results, result)
+	case run.imp.DryRun:
+		run.succeed(op)
+	default:
+		result.BookID = op.ID
+		run.pending = append(run.pending, op)
+		run.pendingResults = append(run.pendingResults, result)
+	}
+	return nil
+}
+
+// isProductError reports whether err is a problem with the product rather
+// than a failure of the repositories
+func isProductError(err error) bool {
+	var fields validation.Errors
+	return errors.As(err, &fields) || errors.Is(err, models.ErrBookExists)
+}
+
+// prepare turns a product into the operation that stores it, or into the
+// reason it is skipped
+func (run *importRun) prepare(ctx context.Context, header Header, product *Product) (models.BookOperation, string, error) {
+	op := models.BookOperation{Kind: models.BookCreate}
+	if product.Deleted() {
+		return op, reasonDeleted, nil
+	}
+	book, err := product.Book(header, run.imp.Currency)
+	if err != nil {
+		return op, "", err
+	}
+	if book.ISBN13 == nil {
+		return op, reasonNoISBN, nil
+	}
+	isbn := *book.ISBN13
+	if first, ok := run.seen[isbn]; ok {
+		return op, "", fmt.Errorf("%w: ISBN %s is also on the product of line %d", models.ErrBookExists, isbn, first)
+	}
+	run.seen[isbn] = product.Line
+
+	current, err := run.imp.Books.GetByISBN(ctx, isbn)
+	switch {
+	case err == nil:
+		op = models.BookOperation{Kind: models.BookUpdate, ID: current.ID, Version: current.Version}
+		unchanged, err := run.merge(ctx, book, current)
+		if err != nil {
+			return op, "", err
+		}
+		if unchanged {
+			return op, reasonUnchanged, nil
+		}
+	case !errors.Is(err, models.ErrBookNotFound):
+		return op, "", err
+	}
+	if err := validate(book); err != nil {
+		return op, "", err
+	}
+
+	// The credits are left as names for Batch to match to author records,
+	// so that the authors a failed product names are not created
+	op.Book = book
+	return op, "", nil
+}
+
+// merge fills in the fields of book the product did not provide from
+// current, the stored book with the same ISBN, and reports whether book
+// is the same as current
+func (run *importRun) merge(ctx context.Context, book, current *models.Book) (bool, error) {
+	if book.Title == "" {
+		book.Title = current.Title
+	}
+	if book.Publisher == "" {
+		book.Publisher = current.Publisher
+	}
+	if book.Description == "" {
+		book.Description = current.Description
+	}
+	if book.Category == "" {
+		book.Category = current.Category
+	}
+	if book.PublishedYear == 0 {
+		book.PublishedYear = current.PublishedYear
+	}
+	if book.Price.IsZero() {
+		book.Price = current.Price
+	}
+	if len(book.Authors) == 0 {
+		book.Authors, book.Author = current.Authors, current.Author
+		return sameDetails(book, current), nil
+	}
+
+	ids := make([]uint, len(current.Authors))
+	for i, link := range current.Authors {
+		ids[i] = link.AuthorID
+	}
+	authors, err := run.imp.Authors.GetMany(ctx, ids)
+	if err != nil {
+		return false, err
+	}
+	sameCredits := slices.EqualFunc(book.Authors, current.Authors, func(a, b models.BookAuthor) bool {
+		return a.Role == b.Role && models.NormalizeAuthorName(a.Name) == authors[b.AuthorID].NormalizedName
+	})
+	if sameCredits {
+		// Keeps the stored author records and their spelling
+		book.Authors, book.Author = current.Authors, current.Author
+	}
+	return sameCredits && sameDetails(book, current), nil
+}
+
+// sameDetails reports whether the fields of a and b an ONIX product
+// provides, other than the ISBN and credits, are the same
+func sameDetails(a, b *models.Book) bool {
+	return a.Title == b.Title && a.Price == b.Price && a.Publisher == b.Publisher && a.Description == b.Description &&
+		a.Category == b.Category && a.PublishedYear == b.PublishedYear
+}
+
+// validate checks book against the rules books sent to the API follow
+func validate(book *models.Book) error {
+	if len(book.Authors) == 0 {
+		return validation.Errors{{Field: "contributors", Code: "required", Message: "contributors must include an author, editor or translator"}}
+	}
+	req := book.ToRequest()
+	// The links are checked above and have no author IDs yet
+	req.Authors = nil
+	return validation.Struct(req)
+}
+
+// flush stores the pending operations, each on its own
+func (run *importRun) flush(ctx context.Context) error {
+	if len(run.pending) == 0 {
+		return nil
+	}
+	results, err := run.imp.Books.Batch(ctx, run.pending, false)
+	if err != nil {
+		return err
+	}
+	for i, outcome := range results {
+		if outcome.Err != nil {
+			run.fail(run.pendingResults[i], outcome.Err)
+			continue
+		}
+		run.succeed(run.pending[i])
+	}
+	run.pending, run.pendingResults = run.pending[:0], run.pendingResults[:0]
+	return nil
+}
+
+// succeed counts the operation of a product that was stored, or would be
+// in a dry run
+func (run *importRun) succeed(op models.BookOperation) {
+	if op.Kind == models.BookCreate {
+		run.report.Created++
+	} else {
+		run.report.Updated++
+	}
+}
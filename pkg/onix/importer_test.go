@@ -0,0 +1,232 @@
+package onix
+
+import (
+	"context"
+	"fmt"
+	"go-bookstore-mysql-crud/pkg/models"
+	"strings"
+	"testing"
+)
+
+// message wraps products in an ONIX 3.0 message priced in GBP
+func message(products ...string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<ONIXMessage release="3.0">
+  <Header><DefaultCurrencyCode>GBP</DefaultCurrencyCode></Header>
+  ` + strings.Join(products, "\n  ") + `
+</ONIXMessage>`
+}
+
+// product is a product with the given ISBN, title and extra descriptive
+// detail and publishing detail
+func product(isbn, title, descriptive, publishing string) string {
+	return fmt.Sprintf(`<Product>
+    <RecordReference>com.example.%[1]s</RecordReference>
+    <NotificationType>03</NotificationType>
+    <ProductIdentifier><ProductIDType>15</ProductIDType><IDValue>%[1]s</IDValue></ProductIdentifier>
+    <DescriptiveDetail>
+      <TitleDetail><TitleType>01</TitleType><TitleElement><TitleElementLevel>01</TitleElementLevel><TitleText>%[2]s</TitleText></TitleElement></TitleDetail>
+      <Contributor><SequenceNumber>1</SequenceNumber><ContributorRole>A01</ContributorRole><PersonName>Jane Austen</PersonName></Contributor>
+      %[3]s
+    </DescriptiveDetail>
+    <PublishingDetail>
+      <Publisher><PublishingRole>01</PublishingRole><PublisherName>Penguin</PublisherName></Publisher>
+      %[4]s
+    </PublishingDetail>
+    <ProductSupply><SupplyDetail><Price><PriceType>02</PriceType><PriceAmount>7.99</PriceAmount></Price></SupplyDetail></ProductSupply>
+  </Product>`, isbn, title, descriptive, publishing)
+}
+
+func TestProductCategoryAndPublishedYear(t *testing.T) {
+	tests := []struct {
+		name        string
+		descriptive string
+		publishing  string
+		category    string
+		year        int
+	}{
+		{
+			name:        "main subject and publication date",
+			descriptive: `<Subject><SubjectSchemeIdentifier>10</SubjectSchemeIdentifier><SubjectCode>FIC000000</SubjectCode><SubjectHeadingText>Fiction / General</SubjectHeadingText></Subject><Subject><MainSubject/><SubjectSchemeIdentifier>93</SubjectSchemeIdentifier><SubjectCode>FBC</SubjectCode><SubjectHeadingText>Classic fiction</SubjectHeadingText></Subject>`,
+			publishing:  `<PublishingDate><PublishingDateRole>19</PublishingDateRole><Date>20240101</Date></PublishingDate><PublishingDate><PublishingDateRole>01</PublishingDateRole><Date dateformat="00">20030130</Date></PublishingDate>`,
+			category:    "Classic fiction",
+			year:        2003,
+		},
+		{
+			name:        "first subject with a heading",
+			descriptive: `<Subject><SubjectSchemeIdentifier>20</SubjectSchemeIdentifier><SubjectHeadingText>regency; romance</SubjectHeadingText></Subject><Subject><SubjectSchemeIdentifier>10</SubjectSchemeIdentifier><SubjectCode>FIC004000</SubjectCode></Subject><Subject><SubjectSchemeIdentifier>10</SubjectSchemeIdentifier><SubjectHeadingText>Fiction /  Classics</SubjectHeadingText></Subject>`,
+			publishing:  `<PublishingDate><PublishingDateRole>01</PublishingDateRole><Date dateformat="05">1813</Date></PublishingDate>`,
+			category:    "Fiction / Classics",
+			year:        1813,
+		},
+		{
+			name:        "heading too long and a malformed date",
+			descriptive: `<Subject><MainSubject/><SubjectSchemeIdentifier>10</SubjectSchemeIdentifier><SubjectHeadingText>` + strings.Repeat("a", 101) + `</SubjectHeadingText></Subject>`,
+			publishing:  `<PublishingDate><PublishingDateRole>01</PublishingDateRole><Date>n.d.</Date></PublishingDate>`,
+		},
+	}
+	for _, tt := range tests {
+		reader := NewReader(strings.NewReader(message(product("9780141439518", "Pride and Prejudice", tt.descriptive, tt.publishing))))
+		p, err := reader.Next()
+		if err != nil {
+			t.Fatalf("%s: Next: %v", tt.name, err)
+		}
+		book, err := p.Book(reader.Header(), "")
+		if err != nil {
+			t.Fatalf("%s: Book: %v", tt.name, err)
+		}
+		if book.Category != tt.category || book.PublishedYear != tt.year {
+			t.Errorf("%s: category %q, year %d; want %q, %d", tt.name, book.Category, book.PublishedYear, tt.category, tt.year)
+		}
+	}
+}
+
+func TestProductShortTags(t *testing.T) {
+	feed := `<ONIXmessage release="3.0"><header><x312>GBP</x312></header><product>
+  <a001>com.example.9780141439518</a001><a002>03</a002>
+  <productidentifier><b221>15</b221><b244>9780141439518</b244></productidentifier>
+  <descriptivedetail><subject><x425/><b067>93</b067><b069>FBC</b069><b070>Classic fiction</b070></subject></descriptivedetail>
+  <publishingdetail><publishingdate><x448>01</x448><b306>20030130</b306></publishingdate></publishingdetail>
+</product></ONIXmessage>`
+	p, err := NewReader(strings.NewReader(feed)).Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := p.Category(); got != "Classic fiction" {
+		t.Errorf("Category = %q, want Classic fiction", got)
+	}
+	if got := p.PublishedYear(); got != 2003 {
+		t.Errorf("PublishedYear = %d, want 2003", got)
+	}
+}
+
+func TestImportKeepsFieldsTheProductLeavesOut(t *testing.T) {
+	ctx := context.Background()
+	repos := models.NewMemoryRepositories()
+	importer := &Importer{Books: repos.Books, Authors: repos.Authors}
+	run := func(feed string) *Report {
+		t.Helper()
+		report, err := importer.Import(ctx, strings.NewReader(feed))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return report
+	}
+
+	subject := `<Subject><MainSubject/><SubjectSchemeIdentifier>93</SubjectSchemeIdentifier><SubjectHeadingText>Classic fiction</SubjectHeadingText></Subject>`
+	date := `<PublishingDate><PublishingDateRole>01</PublishingDateRole><Date>20030130</Date></PublishingDate>`
+	if report := run(message(product("9780141439518", "Pride and Prejudice", subject, date))); report.Created != 1 {
+		t.Fatalf("first import created %d books, want 1", report.Created)
+	}
+	book, err := repos.Books.GetByISBN(ctx, "9780141439518")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if book.Category != "Classic fiction" || book.PublishedYear != 2003 {
+		t.Fatalf("imported category %q and year %d", book.Category, book.PublishedYear)
+	}
+
+	// A feed without subjects or dates keeps the stored ones
+	report := run(message(product("9780141439518", "Pride and Prejudice", "", "")))
+	if report.Skipped != 1 || report.Results[0].Reason != reasonUnchanged {
+		t.Errorf("re-import without category and year: %+v", report.Results)
+	}
+
+	// A new publication date updates the book and keeps its category
+	later := `<PublishingDate><PublishingDateRole>01</PublishingDateRole><Date>2014</Date></PublishingDate>`
+	if report := run(message(product("9780141439518", "Pride and Prejudice", "", later))); report.Updated != 1 {
+		t.Fatalf("re-import with a new year: %+v", report.Results)
+	}
+	book, err = repos.Books.GetByISBN(ctx, "9780141439518")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if book.Category != "Classic fiction" || book.PublishedYear != 2014 {
+		t.Errorf("updated category %q and year %d, want Classic fiction and 2014", book.Category, book.PublishedYear)
+	}
+}
+
+func TestImportReportsSkippedAndFailedProducts(t *testing.T) {
+	ctx := context.Background()
+	repos := models.NewMemoryRepositories()
+	// A book in the trash keeps its ISBN, so storing the first product fails
+	trashed := &models.Book{Title: "Emma"}
+	trashed.SetISBN("9780141439587")
+	if err := repos.Books.Create(ctx, trashed); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repos.Books.Delete(ctx, trashed.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	feed := message(
+		product("9780141439587", "Emma", "", ""),
+		product("9780141439518", "Pride and Prejudice", "", ""),
+		`<Product><RecordReference>no-isbn</RecordReference></Product>`,
+		product("9780141439662", "Sense and Sensibility", "", ""),
+	)
+	report, err := (&Importer{Books: repos.Books, Authors: repos.Authors}).Import(ctx, strings.NewReader(feed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Products != 4 || report.Created != 2 || report.Skipped != 1 || report.Failed != 1 {
+		t.Errorf("report counts %+v", report)
+	}
+	// Created books are only counted, and the product that failed when it
+	// was stored is still listed in message order
+	if len(report.Results) != 2 || report.Results[0].Status != StatusFailed || report.Results[0].ISBN != "9780141439587" ||
+		report.Results[1].Status != StatusSkipped || report.Results[1].Reason != reasonNoISBN {
+		t.Errorf("results %+v", report.Results)
+	}
+}
+
+func TestImportLeavesNoAuthorsOfUnstoredProducts(t *testing.T) {
+	ctx := context.Background()
+	repos := models.NewMemoryRepositories()
+	trashed := &models.Book{Title: "Jane Eyre"}
+	trashed.SetISBN("9780141441146")
+	if err := repos.Books.Create(ctx, trashed); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repos.Books.Delete(ctx, trashed.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	authors := func() []string {
+		t.Helper()
+		page, err := repos.Authors.List(ctx, models.AuthorQuery{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		names := make([]string, len(page.Authors))
+		for i, author := range page.Authors {
+			names[i] = author.Name
+		}
+		return names
+	}
+
+	// The product whose ISBN a book in the trash holds fails when it is
+	// stored, after its author would have been looked up
+	feed := message(
+		strings.Replace(product("9780141441146", "Jane Eyre", "", ""), "Jane Austen", "Charlotte Brontë", 1),
+		product("9780141439518", "Pride and Prejudice", "", ""),
+	)
+	report, err := (&Importer{Books: repos.Books, Authors: repos.Authors}).Import(ctx, strings.NewReader(feed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Created != 1 || report.Failed != 1 {
+		t.Fatalf("report counts %+v", report)
+	}
+	if got := authors(); len(got) != 1 || got[0] != "Jane Austen" {
+		t.Errorf("authors after the import: %q, want only Jane Austen", got)
+	}
+
+	// Nor does a dry run create the authors it would credit
+	feed = message(strings.Replace(product("9780141439662", "Sense and Sensibility", "", ""), "Jane Austen", "Elinor Dashwood", 1))
+	if _, err := (&Importer{Books: repos.Books, Authors: repos.Authors, DryRun: true}).Import(ctx, strings.NewReader(feed)); err != nil {
+		t.Fatal(err)
+	}
+	if got := authors(); len(got) != 1 {
+		t.Errorf("authors after a dry run: %q, want only Jane Austen", got)
+	}
+}
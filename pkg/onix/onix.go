@@ -0,0 +1,244 @@
+// Package onix reads ONIX 3.0 product metadata, the XML format publishers
+// send catalog feeds in, and imports it as books. Messages are read one
+// product at a time, and an import only keeps the ISBNs it has seen and the
+// products it skipped or rejected, so files of any size can be processed.
+// Both reference tags (<Product>) and short tags (<product>) are understood.
+package onix
+
+import (
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrInvalidMessage is returned for documents that are not well formed
+// ONIX 3.0 messages
+var ErrInvalidMessage = errors.New("invalid ONIX message")
+
+// Header is the part of the message header that products inherit defaults from
+type Header struct {
+	// DefaultCurrencyCode applies to prices without a CurrencyCode
+	DefaultCurrencyCode string `xml:"DefaultCurrencyCode"`
+	// DefaultPriceType applies to prices without a PriceType
+	DefaultPriceType string `xml:"DefaultPriceType"`
+}
+
+// Reader reads the products of an ONIX 3.0 message in document order
+type Reader struct {
+	raw     *xml.Decoder
+	dec     *xml.Decoder
+	header  Header
+	started bool
+}
+
+// NewReader returns a Reader decoding the message in r
+func NewReader(r io.Reader) *Reader {
+	raw := xml.NewDecoder(r)
+	// Feeds generated from HTML often use entities such as &nbsp; and many
+	// older ones are encoded in ISO-8859-1
+	raw.Entity = xml.HTMLEntity
+	raw.CharsetReader = charsetReader
+	return &Reader{raw: raw, dec: xml.NewTokenDecoder(referenceNames{raw})}
+}
+
+// Header returns the header of the message, once Next has read past it
+func (r *Reader) Header() Header {
+	return r.header
+}
+
+// Next returns the next product of the message, or io.EOF after the last
+// one. Malformed documents are reported with ErrInvalidMessage.
+func (r *Reader) Next() (*Product, error) {
+	for {
+		token, err := r.dec.Token()
+		if errors.Is(err, io.EOF) {
+			if !r.started {
+				return nil, fmt.Errorf("%w: the document is empty", ErrInvalidMessage)
+			}
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, invalidMessage(err)
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if !r.started {
+			if err := checkRoot(start); err != nil {
+				return nil, err
+			}
+			r.started = true
+			continue
+		}
+
+		switch start.Name.Local {
+		case "Header":
+			if err := r.dec.DecodeElement(&r.header, &start); err != nil {
+				return nil, invalidMessage(err)
+			}
+		case "Product":
+			line, _ := r.raw.InputPos()
+			product := &Product{Line: line}
+			if err := r.dec.DecodeElement(product, &start); err != nil {
+				return nil, invalidMessage(err)
+			}
+			return product, nil
+		}
+	}
+}
+
+// checkRoot accepts ONIX 3 messages only; ONIX 2.1 messages have no
+// release attribute
+func checkRoot(root xml.StartElement) error {
+	if root.Name.Local != "ONIXMessage" {
+		return fmt.Errorf("%w: the root element is %s, not ONIXMessage", ErrInvalidMessage, root.Name.Local)
+	}
+	release := ""
+	for _, attr := range root.Attr {
+		if attr.Name.Local == "release" {
+			release = attr.Value
+		}
+	}
+	if !strings.HasPrefix(release, "3.") {
+		return fmt.Errorf("%w: only ONIX 3 messages are supported, the release is %q", ErrInvalidMessage, release)
+	}
+	return nil
+}
+
+// invalidMessage wraps XML errors in ErrInvalidMessage; read errors, such
+// as a body exceeding its size limit, are returned as is
+func invalidMessage(err error) error {
+	var (
+		syntaxErr    *xml.SyntaxError
+		unmarshalErr xml.UnmarshalError
+	)
+	// Unsupported encodings are reported as plain errors prefixed with "xml:"
+	if errors.As(err, &syntaxErr) || errors.As(err, &unmarshalErr) || strings.HasPrefix(err.Error(), "xml: ") {
+		return fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+	return err
+}
+
+// referenceNames renames the short tags of a message to their reference
+// names, so that products are decoded the same way whichever tags they use
+type referenceNames struct {
+	raw *xml.Decoder
+}
+
+func (n referenceNames) Token() (xml.Token, error) {
+	token, err := n.raw.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := token.(type) {
+	case xml.StartElement:
+		if name, ok := shortTags[t.Name.Local]; ok {
+			t.Name.Local = name
+		}
+		return t.Copy(), nil
+	case xml.EndElement:
+		if name, ok := shortTags[t.Name.Local]; ok {
+			t.Name.Local = name
+		}
+		return t, nil
+	}
+	return xml.CopyToken(token), nil
+}
+
+// shortTags maps the short tags of the elements this package reads to
+// their reference names
+var shortTags = map[string]string{
+	"ONIXmessage":       "ONIXMessage",
+	"header":            "Header",
+	"x311":              "DefaultPriceType",
+	"x312":              "DefaultCurrencyCode",
+	"product":           "Product",
+	"a001":              "RecordReference",
+	"a002":              "NotificationType",
+	"productidentifier": "ProductIdentifier",
+	"b221":              "ProductIDType",
+	"b244":              "IDValue",
+	"descriptivedetail": "DescriptiveDetail",
+	"titledetail":       "TitleDetail",
+	"b202":              "TitleType",
+	"titleelement":      "TitleElement",
+	"x409":              "TitleElementLevel",
+	"b203":              "TitleText",
+	"b030":              "TitlePrefix",
+	"b031":              "TitleWithoutPrefix",
+	"contributor":       "Contributor",
+	"b034":              "SequenceNumber",
+	"b035":              "ContributorRole",
+	"b036":              "PersonName",
+	"b037":              "PersonNameInverted",
+	"b039":              "NamesBeforeKey",
+	"b040":              "KeyNames",
+	"b047":              "CorporateName",
+	"subject":           "Subject",
+	"x425":              "MainSubject",
+	"b067":              "SubjectSchemeIdentifier",
+	"b069":              "SubjectCode",
+	"b070":              "SubjectHeadingText",
+	"publishingdetail":  "PublishingDetail",
+	"publisher":         "Publisher",
+	"b291":              "PublishingRole",
+	"b081":              "PublisherName",
+	"publishingdate":    "PublishingDate",
+	"x448":              "PublishingDateRole",
+	"b306":              "Date",
+	"collateraldetail":  "CollateralDetail",
+	"textcontent":       "TextContent",
+	"x426":              "TextType",
+	"d104":              "Text",
+	"productsupply":     "ProductSupply",
+	"supplydetail":      "SupplyDetail",
+	"j192":              "UnpricedItemType",
+	"price":             "Price",
+	"x462":              "PriceType",
+	"j151":              "PriceAmount",
+	"j152":              "CurrencyCode",
+}
+
+// charsetReader decodes the encodings other than UTF-8 that feeds are
+// still found in
+func charsetReader(label string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(label) {
+	case "iso-8859-1", "iso8859-1", "latin1", "latin-1":
+		return latin1Reader{bufio.NewReader(input)}, nil
+	}
+	return nil, fmt.Errorf("unsupported encoding %q", label)
+}
+
+// latin1Reader converts ISO-8859-1 to UTF-8; every byte is the code point
+// of the same value
+type latin1Reader struct {
+	r *bufio.Reader
+}
+
+func (l latin1Reader) Read(p []byte) (int, error) {
+	n := 0
+	for n+2 <= len(p) {
+		b, err := l.r.ReadByte()
+		if err != nil {
+			if n > 0 {
+				return n, nil
+			}
+			return 0, err
+		}
+		if b < 0x80 {
+			p[n] = b
+			n++
+		} else {
+			p[n], p[n+1] = 0xC0|b>>6, 0x80|b&0x3F
+			n += 2
+		}
+		if l.r.Buffered() == 0 {
+			break
+		}
+	}
+	return n, nil
+}
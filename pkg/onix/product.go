@@ -0,0 +1,461 @@
+package onix
+
+import (
+	"encoding/xml"
+	"fmt"
+	"go-bookstore-mysql-crud/pkg/models"
+	"go-bookstore-mysql-crud/pkg/validation"
+	"html"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxCategoryLength is the longest category a book takes
+const maxCategoryLength = 100
+
+// Codes of the ONIX code lists this package reads
+const (
+	notificationDelete   = "05" // List 1: delete
+	productIDISBN10      = "02" // List 5: ISBN-10
+	productIDGTIN13      = "03" // List 5: GTIN-13
+	productIDISBN13      = "15" // List 5: ISBN-13
+	publisherMain        = "01" // List 45: publisher
+	titleDistinctive     = "01" // List 15: distinctive title
+	titleLevelProduct    = "01" // List 149: product level title
+	textShortDescription = "02" // List 153: short description
+	textDescription      = "03" // List 153: description
+	textFormatHTML       = "02" // List 34: HTML
+	dateRolePublication  = "01" // List 163: publication date
+	subjectKeywords      = "20" // List 27: keywords
+)
+
+// contributorRoles maps List 17 contributor roles to the roles a book
+// credits; other contributions, such as illustrations, are not credited
+var contributorRoles = map[string]string{
+	"A01": models.RoleAuthor,
+	"B01": models.RoleEditor,
+	"B06": models.RoleTranslator,
+}
+
+// priceTypes are the List 58 price types in order of preference:
+// recommended retail prices including tax, then excluding tax, then fixed
+// retail prices
+var priceTypes = []string{"02", "01", "04", "03"}
+
+// Product is an ONIX product record, reduced to what a book is made of
+type Product struct {
+	// Line is the line of the message the product starts on
+	Line              int                 `xml:"-"`
+	RecordReference   string              `xml:"RecordReference"`
+	NotificationType  string              `xml:"NotificationType"`
+	Identifiers       []ProductIdentifier `xml:"ProductIdentifier"`
+	DescriptiveDetail DescriptiveDetail   `xml:"DescriptiveDetail"`
+	CollateralDetail  CollateralDetail    `xml:"CollateralDetail"`
+	PublishingDetail  PublishingDetail    `xml:"PublishingDetail"`
+	ProductSupply     []ProductSupply     `xml:"ProductSupply"`
+}
+
+// ProductIdentifier is an identifier of a product, such as its ISBN
+type ProductIdentifier struct {
+	ProductIDType string `xml:"ProductIDType"`
+	IDValue       string `xml:"IDValue"`
+}
+
+// DescriptiveDetail holds the titles, contributors and subjects of a product
+type DescriptiveDetail struct {
+	TitleDetails []TitleDetail `xml:"TitleDetail"`
+	Contributors []Contributor `xml:"Contributor"`
+	Subjects     []Subject     `xml:"Subject"`
+}
+
+// TitleDetail is one title of a product
+type TitleDetail struct {
+	TitleType     string         `xml:"TitleType"`
+	TitleElements []TitleElement `xml:"TitleElement"`
+}
+
+// TitleElement is the title of a product, or of the collection it is part of
+type TitleElement struct {
+	TitleElementLevel  string `xml:"TitleElementLevel"`
+	TitleText          string `xml:"TitleText"`
+	TitlePrefix        string `xml:"TitlePrefix"`
+	TitleWithoutPrefix string `xml:"TitleWithoutPrefix"`
+}
+
+// Contributor is a person or organisation credited on a product
+type Contributor struct {
+	SequenceNumber     int      `xml:"SequenceNumber"`
+	ContributorRoles   []string `xml:"ContributorRole"`
+	PersonName         string   `xml:"PersonName"`
+	PersonNameInverted string   `xml:"PersonNameInverted"`
+	NamesBeforeKey     string   `xml:"NamesBeforeKey"`
+	KeyNames           string   `xml:"KeyNames"`
+	CorporateName      string   `xml:"CorporateName"`
+}
+
+// Subject is a subject category or keywords of a product
+type Subject struct {
+	// MainSubject is set when the subject is the main one of its scheme
+	MainSubject             *struct{} `xml:"MainSubject"`
+	SubjectSchemeIdentifier string    `xml:"SubjectSchemeIdentifier"`
+	SubjectCode             string    `xml:"SubjectCode"`
+	SubjectHeadingText      string    `xml:"SubjectHeadingText"`
+}
+
+// CollateralDetail holds the descriptive texts of a product
+type CollateralDetail struct {
+	TextContents []TextContent `xml:"TextContent"`
+}
+
+// TextContent is a descriptive text of a product, possibly in several languages
+type TextContent struct {
+	TextType string `xml:"TextType"`
+	Texts    []Text `xml:"Text"`
+}
+
+// Text is a descriptive text reduced to plain text. Texts may be plain,
+// XHTML elements or escaped HTML.
+type Text string
+
+// PublishingDetail holds the publishers and publishing dates of a product
+type PublishingDetail struct {
+	Publishers      []Publisher      `xml:"Publisher"`
+	PublishingDates []PublishingDate `xml:"PublishingDate"`
+}
+
+// PublishingDate is a date in the publishing of a product. Dates start
+// with the year whatever their format.
+type PublishingDate struct {
+	PublishingDateRole string `xml:"PublishingDateRole"`
+	Date               string `xml:"Date"`
+}
+
+// Publisher is an organisation involved in publishing a product
+type Publisher struct {
+	PublishingRole string `xml:"PublishingRole"`
+	PublisherName  string `xml:"PublisherName"`
+}
+
+// ProductSupply holds how a product is sold in one market
+type ProductSupply struct {
+	SupplyDetails []SupplyDetail `xml:"SupplyDetail"`
+}
+
+// SupplyDetail holds the prices of a product from one supplier
+type SupplyDetail struct {
+	UnpricedItemType string  `xml:"UnpricedItemType"`
+	Prices           []Price `xml:"Price"`
+}
+
+// Price is a price of a product
+type Price struct {
+	PriceType    string `xml:"PriceType"`
+	PriceAmount  string `xml:"PriceAmount"`
+	CurrencyCode string `xml:"CurrencyCode"`
+}
+
+// Deleted reports whether the product is a notice that the record was withdrawn
+func (p *Product) Deleted() bool {
+	return strings.TrimSpace(p.NotificationType) == notificationDelete
+}
+
+// ISBN returns the ISBN of the product as written in the message, preferring
+// ISBN-13 to GTIN-13 to ISBN-10, or "" if it has none
+func (p *Product) ISBN() string {
+	for _, idType := range []string{productIDISBN13, productIDGTIN13, productIDISBN10} {
+		for _, id := range p.Identifiers {
+			if strings.TrimSpace(id.ProductIDType) != idType {
+				continue
+			}
+			value := strings.TrimSpace(id.IDValue)
+			// GTIN-13s are only ISBNs in the Bookland ranges
+			if idType == productIDGTIN13 && !strings.HasPrefix(value, "978") && !strings.HasPrefix(value, "979") {
+				continue
+			}
+			return value
+		}
+	}
+	return ""
+}
+
+// Title returns the distinctive title of the product
+func (p *Product) Title() string {
+	details := p.DescriptiveDetail.TitleDetails
+	i := slices.IndexFunc(details, func(d TitleDetail) bool { return strings.TrimSpace(d.TitleType) == titleDistinctive })
+	if i < 0 {
+		return ""
+	}
+	elements := details[i].TitleElements
+	j := slices.IndexFunc(elements, func(e TitleElement) bool { return strings.TrimSpace(e.TitleElementLevel) == titleLevelProduct })
+	if j < 0 {
+		return ""
+	}
+	e := elements[j]
+	if title := collapseSpaces(e.TitleText); title != "" {
+		return title
+	}
+	return collapseSpaces(e.TitlePrefix + " " + e.TitleWithoutPrefix)
+}
+
+// Name returns the display name of the contributor
+func (c *Contributor) Name() string {
+	if name := collapseSpaces(c.PersonName); name != "" {
+		return name
+	}
+	if name := collapseSpaces(c.NamesBeforeKey + " " + c.KeyNames); name != "" {
+		return name
+	}
+	if key, before, ok := strings.Cut(c.PersonNameInverted, ","); ok {
+		return collapseSpaces(before + " " + key)
+	}
+	if name := collapseSpaces(c.PersonNameInverted); name != "" {
+		return name
+	}
+	return collapseSpaces(c.CorporateName)
+}
+
+// Credits returns the authors, editors and translators of the product as
+// author links in credit order. The links carry names but no author IDs;
+// a contributor listed twice in the same role is credited once.
+func (p *Product) Credits() []models.BookAuthor {
+	contributors := slices.Clone(p.DescriptiveDetail.Contributors)
+	slices.SortStableFunc(contributors, func(a, b Contributor) int { return a.SequenceNumber - b.SequenceNumber })
+
+	links := []models.BookAuthor{}
+	seen := make(map[string]bool)
+	for _, c := range contributors {
+		name := c.Name()
+		if name == "" {
+			continue
+		}
+		for _, code := range c.ContributorRoles {
+			role, ok := contributorRoles[strings.TrimSpace(code)]
+			if !ok {
+				continue
+			}
+			key := models.NormalizeAuthorName(name) + "/" + role
+			if !seen[key] {
+				seen[key] = true
+				links = append(links, models.BookAuthor{Name: name, Role: role, Position: len(links)})
+			}
+			break
+		}
+	}
+	return links
+}
+
+// Description returns the description of the product, or its short
+// description when it has no long one
+func (p *Product) Description() string {
+	for _, textType := range []string{textDescription, textShortDescription} {
+		for _, content := range p.CollateralDetail.TextContents {
+			if strings.TrimSpace(content.TextType) != textType {
+				continue
+			}
+			for _, text := range content.Texts {
+				if text != "" {
+					return string(text)
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// Publisher returns the name of the publisher of the product, or of its
+// first publishing organisation when none has the publisher role
+func (p *Product) Publisher() string {
+	publishers := p.PublishingDetail.Publishers
+	i := slices.IndexFunc(publishers, func(pub Publisher) bool { return strings.TrimSpace(pub.PublishingRole) == publisherMain })
+	if i < 0 && len(publishers) > 0 {
+		i = 0
+	}
+	if i < 0 {
+		return ""
+	}
+	return collapseSpaces(publishers[i].PublisherName)
+}
+
+// Category returns the heading of the main subject of the product, or of
+// its first subject with a heading. Keywords are not a category, and
+// headings too long to be one are passed over.
+func (p *Product) Category() string {
+	var category string
+	for _, subject := range p.DescriptiveDetail.Subjects {
+		heading := collapseSpaces(subject.SubjectHeadingText)
+		if heading == "" || strings.TrimSpace(subject.SubjectSchemeIdentifier) == subjectKeywords || utf8.RuneCountInString(heading) > maxCategoryLength {
+			continue
+		}
+		if subject.MainSubject != nil {
+			return heading
+		}
+		if category == "" {
+			category = heading
+		}
+	}
+	return category
+}
+
+// PublishedYear returns the year of the publication date of the product,
+// or 0 when it has none
+func (p *Product) PublishedYear() int {
+	for _, date := range p.PublishingDetail.PublishingDates {
+		if strings.TrimSpace(date.PublishingDateRole) != dateRolePublication {
+			continue
+		}
+		value := strings.TrimSpace(date.Date)
+		if len(value) < 4 {
+			return 0
+		}
+		year, err := strconv.Atoi(value[:4])
+		if err != nil || year < 1000 {
+			return 0
+		}
+		return year
+	}
+	return 0
+}
+
+// Price returns the recommended retail price of the product, taking the
+// default currency and price type from header. When currency is set only
+// prices in that currency are considered. ok is false when the product
+// has no price in a supported currency.
+func (p *Product) Price(header Header, currency string) (price Price, ok bool) {
+	best := len(priceTypes) + 1
+	for _, supply := range p.ProductSupply {
+		for _, detail := range supply.SupplyDetails {
+			if detail.UnpricedItemType != "" {
+				continue
+			}
+			for _, candidate := range detail.Prices {
+				candidate = candidate.withDefaults(header)
+				if currency != "" && !strings.EqualFold(candidate.CurrencyCode, currency) {
+					continue
+				}
+				if _, err := candidate.Money(); err != nil {
+					continue
+				}
+				rank := slices.Index(priceTypes, candidate.PriceType)
+				if rank < 0 {
+					rank = len(priceTypes)
+				}
+				if rank < best {
+					price, best, ok = candidate, rank, true
+				}
+			}
+		}
+	}
+	return price, ok
+}
+
+// withDefaults fills in the currency and price type the price inherits
+// from the header
+func (p Price) withDefaults(header Header) Price {
+	p.PriceType = strings.TrimSpace(p.PriceType)
+	p.CurrencyCode = strings.TrimSpace(p.CurrencyCode)
+	if p.PriceType == "" {
+		p.PriceType = strings.TrimSpace(header.DefaultPriceType)
+	}
+	if p.CurrencyCode == "" {
+		p.CurrencyCode = strings.TrimSpace(header.DefaultCurrencyCode)
+	}
+	return p
+}
+
+// Money converts the price to Money
+func (p Price) Money() (models.Money, error) {
+	return models.ParseDecimal(p.PriceAmount, p.CurrencyCode)
+}
+
+// Book maps the product onto a new book; see Reader.Header for header and
+// Price for currency. Fields the product does not provide are left empty,
+// and the author links carry names but no author IDs. It fails with
+// validation errors when the product's ISBN is invalid.
+func (p *Product) Book(header Header, currency string) (*models.Book, error) {
+	book := &models.Book{
+		Title:         p.Title(),
+		Authors:       p.Credits(),
+		Publisher:     p.Publisher(),
+		Description:   p.Description(),
+		Category:      p.Category(),
+		PublishedYear: p.PublishedYear(),
+	}
+	book.Author = models.CreditLine(book.Authors)
+	if price, ok := p.Price(header, currency); ok {
+		book.Price, _ = price.Money()
+	}
+	if isbn := p.ISBN(); book.SetISBN(isbn) != nil {
+		return nil, validation.Errors{{Field: "isbn", Code: "isbn", Message: fmt.Sprintf("isbn %q is not a valid ISBN-10 or ISBN-13", isbn)}}
+	}
+	return book, nil
+}
+
+var (
+	// htmlBreaks match the HTML elements that start a new line
+	htmlBreaks = regexp.MustCompile(`(?i)<\s*(br|/?p|/?div|/?li|/?h[1-6])\b[^>]*>`)
+	htmlTags   = regexp.MustCompile(`<[^>]*>`)
+)
+
+// UnmarshalXML reads the text of the element and of any XHTML elements
+// within it, or unescapes it when it is HTML
+func (t *Text) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var (
+		b     strings.Builder
+		depth = 1
+	)
+	for depth > 0 {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch tok := token.(type) {
+		case xml.StartElement:
+			depth++
+			if htmlBreaks.MatchString("<" + tok.Name.Local + ">") {
+				b.WriteString("\n")
+			}
+		case xml.EndElement:
+			depth--
+			if depth > 0 && htmlBreaks.MatchString("</"+tok.Name.Local+">") {
+				b.WriteString("\n")
+			}
+		case xml.CharData:
+			b.Write(tok)
+		}
+	}
+
+	text := b.String()
+	format := ""
+	for _, attr := range start.Attr {
+		if attr.Name.Local == "textformat" {
+			format = attr.Value
+		}
+	}
+	if format == textFormatHTML || strings.ContainsRune(text, '<') {
+		text = htmlBreaks.ReplaceAllString(text, "\n")
+		text = html.UnescapeString(htmlTags.ReplaceAllString(text, ""))
+	}
+	*t = Text(plainParagraphs(text))
+	return nil
+}
+
+// plainParagraphs collapses the spaces within the lines of text and drops
+// its blank lines
+func plainParagraphs(text string) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = collapseSpaces(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// collapseSpaces trims s and reduces every run of white space within it to
+// one space
+func collapseSpaces(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
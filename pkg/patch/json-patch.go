@@ -0,0 +1,294 @@
+package patch
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Operation is one step of a JSON Patch document
+// @Description JSON Patch (RFC 6902) operation
+type Operation struct {
+	// @Description add, remove, replace, move, copy or test
+	// @Example "replace"
+	Op string `json:"op" example:"replace"`
+
+	// @Description JSON Pointer (RFC 6901) to the target location
+	// @Example "/title"
+	Path string `json:"path" example:"/title"`
+
+	// @Description JSON Pointer to the source location of move and copy
+	From string `json:"from,omitempty"`
+
+	// @Description Value to add, replace with or test against
+	Value json.RawMessage `json:"value,omitempty" swaggertype:"object"`
+}
+
+// JSONPatch applies the JSON Patch document p, an array of operations, to
+// the JSON value doc. Operations are applied in order and the patch fails as
+// a whole if any of them fails.
+func JSONPatch(doc, p []byte) ([]byte, error) {
+	target, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+	var ops []Operation
+	if err := json.Unmarshal(p, &ops); err != nil {
+		return nil, fmt.Errorf("%w: a JSON Patch must be an array of operations: %v", ErrInvalidPatch, err)
+	}
+	for i, op := range ops {
+		if target, err = op.apply(target); err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+	return json.Marshal(target)
+}
+
+// apply performs the operation on doc and returns the new document
+func (op Operation) apply(doc any) (any, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return nil, fmt.Errorf("%w: value is required", ErrInvalidPatch)
+		}
+		value, err := decode(op.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: value: %v", ErrInvalidPatch, err)
+		}
+		switch op.Op {
+		case "add":
+			return add(doc, path, value)
+		case "replace":
+			return update(doc, path, func(any) (any, error) { return value, nil })
+		}
+		current, err := get(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !equal(current, value) {
+			return nil, fmt.Errorf("%w: test failed, the value at %s differs", ErrConflict, op.Path)
+		}
+		return doc, nil
+	case "remove":
+		doc, _, err = remove(doc, path)
+		return doc, err
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "copy" {
+			value, err := get(doc, from)
+			if err != nil {
+				return nil, err
+			}
+			return add(doc, path, deepCopy(value))
+		}
+		if len(from) < len(path) && slices.Equal(from, path[:len(from)]) {
+			return nil, fmt.Errorf("%w: cannot move a value into itself", ErrInvalidPatch)
+		}
+		doc, value, err := remove(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, value)
+	}
+	return nil, fmt.Errorf("%w: unknown op %q", ErrInvalidPatch, op.Op)
+}
+
+// parsePointer splits a JSON Pointer into its unescaped reference tokens
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: path %q must be empty or start with /", ErrInvalidPatch, pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+	return tokens, nil
+}
+
+// get returns the value at path
+func get(doc any, path []string) (any, error) {
+	for _, token := range path {
+		switch node := doc.(type) {
+		case map[string]any:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("%w: member %q does not exist", ErrConflict, token)
+			}
+			doc = value
+		case []any:
+			i, err := arrayIndex(token, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+			doc = node[i]
+		default:
+			return nil, fmt.Errorf("%w: cannot index a scalar with %q", ErrConflict, token)
+		}
+	}
+	return doc, nil
+}
+
+// update replaces the existing value at path with the result of fn
+func update(doc any, path []string, fn func(any) (any, error)) (any, error) {
+	if len(path) == 0 {
+		return fn(doc)
+	}
+	token, rest := path[0], path[1:]
+	switch node := doc.(type) {
+	case map[string]any:
+		child, ok := node[token]
+		if !ok {
+			return nil, fmt.Errorf("%w: member %q does not exist", ErrConflict, token)
+		}
+		value, err := update(child, rest, fn)
+		if err != nil {
+			return nil, err
+		}
+		node[token] = value
+		return node, nil
+	case []any:
+		i, err := arrayIndex(token, len(node), false)
+		if err != nil {
+			return nil, err
+		}
+		value, err := update(node[i], rest, fn)
+		if err != nil {
+			return nil, err
+		}
+		node[i] = value
+		return node, nil
+	}
+	return nil, fmt.Errorf("%w: cannot index a scalar with %q", ErrConflict, token)
+}
+
+// add sets the member at path, or inserts into an array before the index at
+// path; "-" appends to an array
+func add(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	parent, token := path[:len(path)-1], path[len(path)-1]
+	return update(doc, parent, func(node any) (any, error) {
+		switch node := node.(type) {
+		case map[string]any:
+			node[token] = value
+			return node, nil
+		case []any:
+			i, err := arrayIndex(token, len(node), true)
+			if err != nil {
+				return nil, err
+			}
+			return slices.Insert(node, i, value), nil
+		}
+		return nil, fmt.Errorf("%w: cannot add %q to a scalar", ErrConflict, token)
+	})
+}
+
+// remove deletes the existing value at path and returns it
+func remove(doc any, path []string) (any, any, error) {
+	if len(path) == 0 {
+		return nil, nil, fmt.Errorf("%w: cannot remove the whole document", ErrInvalidPatch)
+	}
+	var removed any
+	parent, token := path[:len(path)-1], path[len(path)-1]
+	doc, err := update(doc, parent, func(node any) (any, error) {
+		switch node := node.(type) {
+		case map[string]any:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("%w: member %q does not exist", ErrConflict, token)
+			}
+			removed = value
+			delete(node, token)
+			return node, nil
+		case []any:
+			i, err := arrayIndex(token, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+			removed = node[i]
+			return slices.Delete(node, i, i+1), nil
+		}
+		return nil, fmt.Errorf("%w: cannot remove %q from a scalar", ErrConflict, token)
+	})
+	return doc, removed, err
+}
+
+// arrayIndex parses an array index token. Adding may also address the end
+// of the array, either by its length or by "-".
+func arrayIndex(token string, length int, adding bool) (int, error) {
+	if adding && token == "-" {
+		return length, nil
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("%w: %q is not an array index", ErrInvalidPatch, token)
+	}
+	if i > length || (i == length && !adding) {
+		return 0, fmt.Errorf("%w: index %d is out of bounds", ErrConflict, i)
+	}
+	return i, nil
+}
+
+// equal compares two decoded JSON values; numbers are equal when their
+// values are, however they are written
+func equal(a, b any) bool {
+	switch a := a.(type) {
+	case map[string]any:
+		b, ok := b.(map[string]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for key, value := range a {
+			other, ok := b[key]
+			if !ok || !equal(value, other) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		b, ok := b.([]any)
+		return ok && slices.EqualFunc(a, b, equal)
+	case json.Number:
+		b, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		x, okA := new(big.Rat).SetString(a.String())
+		y, okB := new(big.Rat).SetString(b.String())
+		return okA && okB && x.Cmp(y) == 0
+	}
+	return a == b
+}
+
+// deepCopy copies the maps and slices of a decoded JSON value
+func deepCopy(value any) any {
+	switch value := value.(type) {
+	case map[string]any:
+		copied := make(map[string]any, len(value))
+		for key, member := range value {
+			copied[key] = deepCopy(member)
+		}
+		return copied
+	case []any:
+		copied := make([]any, len(value))
+		for i, element := range value {
+			copied[i] = deepCopy(element)
+		}
+		return copied
+	}
+	return value
+}
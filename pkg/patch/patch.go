@@ -0,0 +1,76 @@
+// Package patch applies JSON Merge Patch (RFC 7396) and JSON Patch
+// (RFC 6902) documents to JSON values. Both work on the generic decoding
+// of the target, so the result must be decoded and validated again by the
+// caller before it is used.
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Media types of the supported patch formats
+const (
+	MergePatchType = "application/merge-patch+json"
+	JSONPatchType  = "application/json-patch+json"
+)
+
+var (
+	// ErrInvalidPatch is wrapped by errors caused by a malformed patch document
+	ErrInvalidPatch = errors.New("invalid patch")
+	// ErrConflict is wrapped by errors caused by a well formed patch that
+	// cannot be applied to the target, including failed test operations
+	ErrConflict = errors.New("patch cannot be applied")
+)
+
+// MergePatch applies the merge patch document p to the JSON value doc. Members
+// of p replace those of doc, objects are merged recursively and null removes
+// a member.
+func MergePatch(doc, p []byte) ([]byte, error) {
+	target, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+	patch, err := decode(p)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	return json.Marshal(merge(target, patch))
+}
+
+func merge(target, patch any) any {
+	members, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	object, ok := target.(map[string]any)
+	if !ok {
+		object = make(map[string]any)
+	}
+	for key, value := range members {
+		if value == nil {
+			delete(object, key)
+		} else {
+			object[key] = merge(object[key], value)
+		}
+	}
+	return object
+}
+
+// decode parses a single JSON value, keeping numbers exact
+func decode(data []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("malformed JSON: %v", err)
+	}
+	var extra any
+	if err := decoder.Decode(&extra); err != io.EOF {
+		return nil, errors.New("malformed JSON: trailing data after the value")
+	}
+	return value, nil
+}
@@ -0,0 +1,203 @@
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// sameJSON reports whether two JSON documents hold the same value
+func sameJSON(t *testing.T, a, b string) bool {
+	t.Helper()
+	var x, y any
+	if err := json.Unmarshal([]byte(a), &x); err != nil {
+		t.Fatalf("malformed JSON %s: %v", a, err)
+	}
+	if err := json.Unmarshal([]byte(b), &y); err != nil {
+		t.Fatalf("malformed JSON %s: %v", b, err)
+	}
+	return reflect.DeepEqual(x, y)
+}
+
+const book = `{"title": "Emma", "price": {"amount": 1599, "currency": "USD"}, "authors": [{"author_id": 1}, {"author_id": 2}], "isbn": "9780141439587"}`
+
+func TestJSONPatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		patch string
+		want  string
+		err   error
+	}{
+		{
+			name:  "replace after a passing test",
+			patch: `[{"op": "test", "path": "/title", "value": "Emma"}, {"op": "replace", "path": "/title", "value": "Persuasion"}]`,
+			want:  `{"title": "Persuasion", "price": {"amount": 1599, "currency": "USD"}, "authors": [{"author_id": 1}, {"author_id": 2}], "isbn": "9780141439587"}`,
+		},
+		{
+			name:  "failed test",
+			patch: `[{"op": "test", "path": "/title", "value": "Dune"}, {"op": "replace", "path": "/title", "value": "Persuasion"}]`,
+			err:   ErrConflict,
+		},
+		{
+			name:  "test compares numbers by value",
+			patch: `[{"op": "test", "path": "/price/amount", "value": 1599.0}]`,
+			want:  book,
+		},
+		{
+			name:  "test compares objects whatever their member order",
+			patch: `[{"op": "test", "path": "/price", "value": {"currency": "USD", "amount": 1599}}]`,
+			want:  book,
+		},
+		{
+			name:  "test of an array compares its order",
+			patch: `[{"op": "test", "path": "/authors", "value": [{"author_id": 2}, {"author_id": 1}]}]`,
+			err:   ErrConflict,
+		},
+		{
+			name:  "test of a missing path",
+			patch: `[{"op": "test", "path": "/publisher", "value": "Penguin"}]`,
+			err:   ErrConflict,
+		},
+		{
+			name:  "test without a value",
+			patch: `[{"op": "test", "path": "/title"}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "move within an array",
+			patch: `[{"op": "move", "from": "/authors/0", "path": "/authors/-"}]`,
+			want:  `{"title": "Emma", "price": {"amount": 1599, "currency": "USD"}, "authors": [{"author_id": 2}, {"author_id": 1}], "isbn": "9780141439587"}`,
+		},
+		{
+			name:  "move to another member",
+			patch: `[{"op": "move", "from": "/isbn", "path": "/publisher"}]`,
+			want:  `{"title": "Emma", "price": {"amount": 1599, "currency": "USD"}, "authors": [{"author_id": 1}, {"author_id": 2}], "publisher": "9780141439587"}`,
+		},
+		{
+			name:  "move into itself",
+			patch: `[{"op": "move", "from": "/price", "path": "/price/amount"}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "move from a missing path",
+			patch: `[{"op": "move", "from": "/publisher", "path": "/title"}]`,
+			err:   ErrConflict,
+		},
+		{
+			name:  "copy",
+			patch: `[{"op": "copy", "from": "/authors/1", "path": "/authors/0"}]`,
+			want:  `{"title": "Emma", "price": {"amount": 1599, "currency": "USD"}, "authors": [{"author_id": 2}, {"author_id": 1}, {"author_id": 2}], "isbn": "9780141439587"}`,
+		},
+		{
+			name:  "add and remove",
+			patch: `[{"op": "add", "path": "/publisher", "value": "Penguin"}, {"op": "remove", "path": "/isbn"}, {"op": "remove", "path": "/authors/1"}]`,
+			want:  `{"title": "Emma", "price": {"amount": 1599, "currency": "USD"}, "authors": [{"author_id": 1}], "publisher": "Penguin"}`,
+		},
+		{
+			name:  "escaped pointer",
+			patch: `[{"op": "add", "path": "/a~1b~0c", "value": 1}, {"op": "remove", "path": "/a~1b~0c"}]`,
+			want:  book,
+		},
+		{
+			name:  "replace of a missing member",
+			patch: `[{"op": "replace", "path": "/publisher", "value": "Penguin"}]`,
+			err:   ErrConflict,
+		},
+		{
+			name:  "array index out of bounds",
+			patch: `[{"op": "add", "path": "/authors/3", "value": {"author_id": 3}}]`,
+			err:   ErrConflict,
+		},
+		{
+			name:  "array index with a leading zero",
+			patch: `[{"op": "remove", "path": "/authors/01"}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "unknown op",
+			patch: `[{"op": "increment", "path": "/price/amount", "value": 1}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "path without a leading slash",
+			patch: `[{"op": "remove", "path": "title"}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "not an array",
+			patch: `{"op": "remove", "path": "/title"}`,
+			err:   ErrInvalidPatch,
+		},
+	}
+	for _, tt := range tests {
+		got, err := JSONPatch([]byte(book), []byte(tt.patch))
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("%s: JSONPatch = %s, %v, want %v", tt.name, got, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: JSONPatch: %v", tt.name, err)
+			continue
+		}
+		if !sameJSON(t, string(got), tt.want) {
+			t.Errorf("%s: JSONPatch = %s\nwant %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestJSONPatchIsAllOrNothing(t *testing.T) {
+	// The first operation applies, but the failing second one discards it
+	p := `[{"op": "replace", "path": "/title", "value": "Dune"}, {"op": "test", "path": "/title", "value": "Emma"}]`
+	if got, err := JSONPatch([]byte(book), []byte(p)); !errors.Is(err, ErrConflict) || got != nil {
+		t.Errorf("JSONPatch = %s, %v; want no document and ErrConflict", got, err)
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		patch string
+		want  string
+		err   error
+	}{
+		{
+			name:  "replace and remove members",
+			patch: `{"title": "Persuasion", "isbn": null}`,
+			want:  `{"title": "Persuasion", "price": {"amount": 1599, "currency": "USD"}, "authors": [{"author_id": 1}, {"author_id": 2}]}`,
+		},
+		{
+			name:  "objects merge",
+			patch: `{"price": {"amount": 999}}`,
+			want:  `{"title": "Emma", "price": {"amount": 999, "currency": "USD"}, "authors": [{"author_id": 1}, {"author_id": 2}], "isbn": "9780141439587"}`,
+		},
+		{
+			name:  "arrays are replaced",
+			patch: `{"authors": [{"author_id": 3}]}`,
+			want:  `{"title": "Emma", "price": {"amount": 1599, "currency": "USD"}, "authors": [{"author_id": 3}], "isbn": "9780141439587"}`,
+		},
+		{
+			name:  "malformed",
+			patch: `{"title": `,
+			err:   ErrInvalidPatch,
+		},
+	}
+	for _, tt := range tests {
+		got, err := MergePatch([]byte(book), []byte(tt.patch))
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("%s: MergePatch = %s, %v, want %v", tt.name, got, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: MergePatch: %v", tt.name, err)
+			continue
+		}
+		if !sameJSON(t, string(got), tt.want) {
+			t.Errorf("%s: MergePatch = %s\nwant %s", tt.name, got, tt.want)
+		}
+	}
+}
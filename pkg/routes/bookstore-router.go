@@ -1,16 +1,103 @@
 package routespckg
 
 import (
+	"go-bookstore-mysql-crud/pkg/apperrors"
+	"go-bookstore-mysql-crud/pkg/auth"
 	"go-bookstore-mysql-crud/pkg/controllers"
+	"go-bookstore-mysql-crud/pkg/models"
 
 	"github.com/gorilla/mux"
 )
 
-var RegisterBookstoreRoutes = func(router *mux.Router) {
-	// Book routes
-	router.HandleFunc("/books", controllers.GetBooks).Methods("GET")
-	router.HandleFunc("/books/{id}", controllers.GetBookById).Methods("GET")
-	router.HandleFunc("/books", controllers.CreateBook).Methods("POST")
-	router.HandleFunc("/books/{id}", controllers.UpdateBook).Methods("PUT")
-	router.HandleFunc("/books/{id}", controllers.DeleteBook).Methods("DELETE")
+// Controllers groups the handlers mounted by RegisterBookstoreRoutes
+type Controllers struct {
+	Books   *controllers.BookController
+	Authors *controllers.AuthorController
+	Stock   *controllers.StockController
+	APIKeys *controllers.APIKeyController
+	// Login issues user tokens; nil when user logins are disabled
+	Login *controllers.AuthController
+	// Health serves the /healthz and /readyz probes; nil to leave them out
+	Health *controllers.HealthController
+	// Auth guards every route with the scope it requires
+	Auth *auth.Authenticator
+}
+
+// NewControllers wires the controllers to the given repositories. User
+// logins are enabled when tokens is not nil; otherwise only API keys are
+// accepted.
+func NewControllers(repos models.Repositories, tokens *auth.Tokens) Controllers {
+	bookController := controllers.NewBookController(repos.Books, repos.Authors)
+	c := Controllers{
+		Books:   bookController,
+		Authors: controllers.NewAuthorController(repos.Authors, bookController),
+		Stock:   controllers.NewStockController(repos.Stock, repos.Books),
+		APIKeys: controllers.NewAPIKeyController(repos.APIKeys),
+		Auth:    auth.NewAuthenticator(repos.APIKeys, tokens),
+	}
+	if tokens != nil {
+		c.Login = controllers.NewAuthController(repos.Users, tokens)
+	}
+	return c
+}
+
+// RegisterBookstoreRoutes mounts the API on router. Reads require the read
+// scope (viewer role), creating, changing and restoring resources the write
+// scope (editor role), and deleting or purging resources or managing API keys
+// the admin scope (admin role).
+var RegisterBookstoreRoutes = func(router *mux.Router, c Controllers) {
+	read := c.Auth.Require(models.ScopeRead)
+	write := c.Auth.Require(models.ScopeWrite)
+	admin := c.Auth.Require(models.ScopeAdmin)
+
+	// Probes and login are the only routes open to anonymous callers
+	if c.Health != nil {
+		router.HandleFunc("/healthz", c.Health.Healthz).Methods("GET")
+		router.HandleFunc("/readyz", c.Health.Readyz).Methods("GET")
+	}
+	if c.Login != nil {
+		router.HandleFunc("/auth/login", c.Login.Login).Methods("POST")
+		router.HandleFunc("/auth/refresh", c.Login.Refresh).Methods("POST")
+	}
+
+	// Book routes; search, the trash and export are registered first so
+	// that "search", "trash" and "export" are not taken for a book ID
+	router.Handle("/books", read(c.Books.GetBooks)).Methods("GET")
+	router.Handle("/books/search", read(c.Books.SearchBooks)).Methods("GET")
+	router.Handle("/books/export", read(c.Books.ExportBooks)).Methods("GET")
+	router.Handle("/books/trash", read(c.Books.GetTrash)).Methods("GET")
+	router.Handle("/books/trash/{id}", admin(c.Books.PurgeBook)).Methods("DELETE")
+	router.Handle("/books/{id}", read(c.Books.GetBookById)).Methods("GET")
+	router.Handle("/books/isbn/{isbn}", read(c.Books.GetBookByISBN)).Methods("GET")
+	router.Handle("/books", write(c.Books.CreateBook)).Methods("POST")
+	router.Handle("/books:batch", write(c.Books.BatchBooks)).Methods("POST")
+	router.Handle("/books/import", write(c.Books.ImportBooks)).Methods("POST")
+	router.Handle("/books/import/onix", write(c.Books.ImportONIX)).Methods("POST")
+	router.Handle("/books/{id}", write(c.Books.UpdateBook)).Methods("PUT")
+	router.Handle("/books/{id}", write(c.Books.PatchBook)).Methods("PATCH")
+	router.Handle("/books/{id}", admin(c.Books.DeleteBook)).Methods("DELETE")
+	router.Handle("/books/{id}/restore", write(c.Books.RestoreBook)).Methods("POST")
+
+	// Stock routes
+	router.Handle("/books/{id}/stock", read(c.Stock.GetStock)).Methods("GET")
+	router.Handle("/books/{id}/stock", write(c.Stock.UpdateStockSettings)).Methods("PUT")
+	router.Handle("/books/{id}/stock/movements", read(c.Stock.GetStockMovements)).Methods("GET")
+	router.Handle("/books/{id}/stock/movements", write(c.Stock.CreateStockMovement)).Methods("POST")
+
+	// Author routes
+	router.Handle("/authors", read(c.Authors.GetAuthors)).Methods("GET")
+	router.Handle("/authors/{id}", read(c.Authors.GetAuthorById)).Methods("GET")
+	router.Handle("/authors/{id}/books", read(c.Authors.GetAuthorBooks)).Methods("GET")
+	router.Handle("/authors", write(c.Authors.CreateAuthor)).Methods("POST")
+	router.Handle("/authors/{id}", write(c.Authors.UpdateAuthor)).Methods("PUT")
+	router.Handle("/authors/{id}", admin(c.Authors.DeleteAuthor)).Methods("DELETE")
+
+	// API key administration
+	router.Handle("/api-keys", admin(c.APIKeys.GetAPIKeys)).Methods("GET")
+	router.Handle("/api-keys", admin(c.APIKeys.CreateAPIKey)).Methods("POST")
+	router.Handle("/api-keys/{id}", admin(c.APIKeys.RevokeAPIKey)).Methods("DELETE")
+
+	// Unmatched requests get problem+json responses like handler errors do
+	router.NotFoundHandler = apperrors.NotFoundHandler()
+	router.MethodNotAllowedHandler = apperrors.MethodNotAllowedHandler()
 }
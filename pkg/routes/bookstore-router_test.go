@@ -0,0 +1,82 @@
+package routespckg
+
+import (
+	"context"
+	"go-bookstore-mysql-crud/pkg/auth"
+	"go-bookstore-mysql-crud/pkg/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+// apiKey stores a key with the given scopes and returns it
+func apiKey(t *testing.T, repos models.Repositories, scopes ...string) string {
+	t.Helper()
+	key, prefix, hash, err := auth.GenerateAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repos.APIKeys.Create(context.Background(), &models.APIKey{Name: "test", Prefix: prefix, Hash: hash, Scopes: scopes}); err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestRouteScopes(t *testing.T) {
+	repos := models.NewMemoryRepositories()
+	router := mux.NewRouter()
+	controllers := NewControllers(repos, nil)
+	controllers.Books.RequireIfMatch = false
+	RegisterBookstoreRoutes(router, controllers)
+
+	reader := apiKey(t, repos, models.ScopeRead)
+	writer := apiKey(t, repos, models.ScopeRead, models.ScopeWrite)
+	admin := apiKey(t, repos, models.ScopeRead, models.ScopeWrite, models.ScopeAdmin)
+	book := `{"title": "Emma", "author": "Jane Austen", "price": "7.99"}`
+
+	// The steps run in order against the same store
+	steps := []struct {
+		name   string
+		key    string
+		method string
+		target string
+		body   string
+		status int
+	}{
+		{"anonymous read", "", "GET", "/books", "", http.StatusUnauthorized},
+		{"unknown key", "bks_nope", "GET", "/books", "", http.StatusUnauthorized},
+		{"read", reader, "GET", "/books", "", http.StatusOK},
+		{"create without write", reader, "POST", "/books", book, http.StatusForbidden},
+		{"create", writer, "POST", "/books", book, http.StatusOK},
+		{"search is not a book ID", reader, "GET", "/books/search?q=emma", "", http.StatusOK},
+		{"export is not a book ID", reader, "GET", "/books/export", "", http.StatusOK},
+		{"stock", reader, "GET", "/books/1/stock", "", http.StatusOK},
+		{"stock movement without write", reader, "POST", "/books/1/stock/movements", `{"type": "receipt", "quantity": 3}`, http.StatusForbidden},
+		{"delete without admin", writer, "DELETE", "/books/1", "", http.StatusForbidden},
+		{"batch delete without admin", writer, "POST", "/books:batch", `{"operations": [{"op": "delete", "id": 1}]}`, http.StatusForbidden},
+		{"trash is not a book ID", reader, "GET", "/books/trash", "", http.StatusOK},
+		{"delete", admin, "DELETE", "/books/1", "", http.StatusOK},
+		{"restore without write", reader, "POST", "/books/1/restore", "", http.StatusForbidden},
+		{"restore", writer, "POST", "/books/1/restore", "", http.StatusOK},
+		{"purge of a live book", admin, "DELETE", "/books/trash/1", "", http.StatusConflict},
+		{"API keys need admin", writer, "GET", "/api-keys", "", http.StatusForbidden},
+		{"API keys", admin, "GET", "/api-keys", "", http.StatusOK},
+	}
+	for _, step := range steps {
+		r := httptest.NewRequest(step.method, step.target, strings.NewReader(step.body))
+		if step.key != "" {
+			r.Header.Set("Authorization", "Bearer "+step.key)
+		}
+		if step.body != "" {
+			r.Header.Set("Content-Type", "application/json")
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		if w.Code != step.status {
+			t.Errorf("%s: %s %s = %d, want %d: %s", step.name, step.method, step.target, w.Code, step.status, w.Body)
+		}
+	}
+}
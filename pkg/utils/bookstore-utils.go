@@ -1,16 +1,85 @@
 package utils
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
 
 	"net/http"
 )
 
+// MaxBodyBytes caps the size of request bodies accepted by ParseBody
+const MaxBodyBytes = 1 << 20
+
+var (
+	// ErrInvalidBody is wrapped by every ParseBody error caused by the client
+	ErrInvalidBody = errors.New("invalid request body")
+	// ErrBodyTooLarge is returned when the body exceeds MaxBodyBytes
+	ErrBodyTooLarge = fmt.Errorf("%w: body exceeds %d bytes", ErrInvalidBody, MaxBodyBytes)
+)
+
+// ParseBody strictly decodes a single JSON value from the request body into
+// dst. Unknown fields, trailing data and bodies over MaxBodyBytes are rejected.
 func ParseBody(r *http.Request, dst interface{}) error {
 	defer r.Body.Close()
-	decoder := json.NewDecoder(r.Body)
+	return decodeStrict(http.MaxBytesReader(nil, r.Body, MaxBodyBytes), dst)
+}
+
+// DecodeJSON strictly decodes data into dst like ParseBody does with a body
+func DecodeJSON(data []byte, dst interface{}) error {
+	return decodeStrict(bytes.NewReader(data), dst)
+}
+
+// ReadBody reads the whole request body, rejecting bodies over MaxBodyBytes
+func ReadBody(r *http.Request) ([]byte, error) {
+	defer r.Body.Close()
+	data, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, MaxBodyBytes))
+	if err != nil {
+		return nil, decodeError(err)
+	}
+	return data, nil
+}
+
+func decodeStrict(reader io.Reader, dst interface{}) error {
+	decoder := json.NewDecoder(reader)
+	decoder.DisallowUnknownFields()
 	if err := decoder.Decode(dst); err != nil {
-		return err
+		return decodeError(err)
+	}
+	if err := decoder.Decode(&struct{}{}); err != io.EOF {
+		if err != nil {
+			return decodeError(err)
+		}
+		return fmt.Errorf("%w: body must contain a single JSON value", ErrInvalidBody)
+	}
+	return nil
+}
+
+// decodeError turns a json.Decoder error into a client facing message
+func decodeError(err error) error {
+	var (
+		syntaxErr   *json.SyntaxError
+		typeErr     *json.UnmarshalTypeError
+		maxBytesErr *http.MaxBytesError
+	)
+	switch {
+	case errors.Is(err, io.EOF):
+		return fmt.Errorf("%w: body must not be empty", ErrInvalidBody)
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return fmt.Errorf("%w: body contains malformed JSON", ErrInvalidBody)
+	case errors.As(err, &syntaxErr):
+		return fmt.Errorf("%w: malformed JSON at offset %d", ErrInvalidBody, syntaxErr.Offset)
+	case errors.As(err, &typeErr):
+		return fmt.Errorf("%w: field %q must be of type %s", ErrInvalidBody, typeErr.Field, typeErr.Type)
+	case errors.As(err, &maxBytesErr):
+		return ErrBodyTooLarge
+	}
+	// json reports unknown fields only as a formatted string
+	if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		return fmt.Errorf("%w: unknown field %s", ErrInvalidBody, field)
 	}
-	return nil // Yes, in the success case, we return the parsed body (dst)
+	return fmt.Errorf("%w: %w", ErrInvalidBody, err)
 }
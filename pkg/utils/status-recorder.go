@@ -0,0 +1,34 @@
+package utils
+
+import "net/http"
+
+// StatusRecorder is an http.ResponseWriter that remembers the status code
+// written through it, for middleware that reports on responses
+type StatusRecorder struct {
+	http.ResponseWriter
+	// Status is the status code sent, http.StatusOK until one is written
+	Status      int
+	wroteHeader bool
+}
+
+// NewStatusRecorder wraps w
+func NewStatusRecorder(w http.ResponseWriter) *StatusRecorder {
+	return &StatusRecorder{ResponseWriter: w, Status: http.StatusOK}
+}
+
+func (r *StatusRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.Status, r.wroteHeader = status, true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *StatusRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	return r.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (r *StatusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,139 @@
+package validation
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// required rejects zero values and strings that are only whitespace
+func required(value reflect.Value, _ string) *FieldError {
+	if value.IsZero() || (value.Kind() == reflect.String && strings.TrimSpace(value.String()) == "") {
+		return &FieldError{Code: "required", Message: "is required"}
+	}
+	return nil
+}
+
+// minLength checks the length of strings, slices and maps, or the value of numbers
+func minLength(value reflect.Value, param string) *FieldError {
+	limit := mustInt(param)
+	if n, ok := measure(value); ok && n < limit {
+		return &FieldError{Code: "min", Message: fmt.Sprintf("must be at least %d%s", limit, unit(value))}
+	}
+	return nil
+}
+
+// maxLength checks the length of strings, slices and maps, or the value of numbers
+func maxLength(value reflect.Value, param string) *FieldError {
+	limit := mustInt(param)
+	if n, ok := measure(value); ok && n > limit {
+		return &FieldError{Code: "max", Message: fmt.Sprintf("must be at most %d%s", limit, unit(value))}
+	}
+	return nil
+}
+
+// selfValid defers to the value's own Validate method
+func selfValid(value reflect.Value, _ string) *FieldError {
+	v, ok := value.Interface().(interface{ Validate() error })
+	if !ok && value.CanAddr() {
+		v, ok = value.Addr().Interface().(interface{ Validate() error })
+	}
+	if !ok {
+		return nil
+	}
+	if err := v.Validate(); err != nil {
+		return &FieldError{Code: "invalid", Message: "is invalid: " + err.Error()}
+	}
+	return nil
+}
+
+// oneOf accepts only the space separated values listed in param
+func oneOf(value reflect.Value, param string) *FieldError {
+	allowed := strings.Fields(param)
+	if !slices.Contains(allowed, fmt.Sprint(value.Interface())) {
+		return &FieldError{Code: "oneof", Message: "must be one of " + strings.Join(allowed, ", ")}
+	}
+	return nil
+}
+
+// isbn checks the checksum of an ISBN-10 or ISBN-13, ignoring hyphens and spaces
+func isbn(value reflect.Value, _ string) *FieldError {
+	if value.Kind() != reflect.String || !ValidISBN(value.String()) {
+		return &FieldError{Code: "isbn", Message: "is not a valid ISBN-10 or ISBN-13"}
+	}
+	return nil
+}
+
+// ValidISBN reports whether s is an ISBN-10 or ISBN-13 with a correct check
+// digit. Hyphens and spaces are ignored.
+func ValidISBN(s string) bool {
+	digits := strings.NewReplacer("-", "", " ", "").Replace(s)
+	switch len(digits) {
+	case 10:
+		sum := 0
+		for i := 0; i < 10; i++ {
+			c := digits[i]
+			var d int
+			switch {
+			case c >= '0' && c <= '9':
+				d = int(c - '0')
+			case (c == 'X' || c == 'x') && i == 9:
+				d = 10
+			default:
+				return false
+			}
+			sum += d * (10 - i)
+		}
+		return sum%11 == 0
+	case 13:
+		sum := 0
+		for i := 0; i < 13; i++ {
+			c := digits[i]
+			if c < '0' || c > '9' {
+				return false
+			}
+			weight := 1
+			if i%2 == 1 {
+				weight = 3
+			}
+			sum += int(c-'0') * weight
+		}
+		return sum%10 == 0
+	}
+	return false
+}
+
+func measure(value reflect.Value) (int, bool) {
+	switch value.Kind() {
+	case reflect.String:
+		return utf8.RuneCountInString(value.String()), true
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return value.Len(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return int(value.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int(value.Uint()), true
+	}
+	return 0, false
+}
+
+func unit(value reflect.Value) string {
+	switch value.Kind() {
+	case reflect.String:
+		return " characters"
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return " items"
+	}
+	return ""
+}
+
+func mustInt(param string) int {
+	n, err := strconv.Atoi(param)
+	if err != nil {
+		panic(fmt.Sprintf("validation: rule parameter %q is not an integer", param))
+	}
+	return n
+}
@@ -0,0 +1,156 @@
+// Package validation checks decoded request structs against the rules
+// declared in their `binding` struct tags, e.g.
+//
+//	Title string `json:"title" binding:"required,max=255"`
+//
+// Rules are comma separated; a rule may take a parameter after "=". The
+// special rule "dive" validates each struct element of a slice, reporting
+// failures as e.g. "authors[1].role". Failures are collected per field
+// rather than stopping at the first one.
+package validation
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// FieldError describes why a single field failed validation
+// @Description Validation failure of a single request field
+type FieldError struct {
+	// @Description JSON name of the offending field
+	// @Example "title"
+	Field string `json:"field" example:"title"`
+
+	// @Description Machine readable rule that failed
+	// @Example "required"
+	Code string `json:"code" example:"required"`
+
+	// @Description Human readable explanation
+	// @Example "title is required"
+	Message string `json:"message" example:"title is required"`
+}
+
+// Errors is the list of field failures returned by Struct
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, fe := range e {
+		messages[i] = fe.Message
+	}
+	return "validation failed: " + strings.Join(messages, "; ")
+}
+
+// Rule checks one field value against an optional parameter and returns nil
+// when the value is valid. The Field of the returned error is filled in by
+// the caller.
+type Rule func(value reflect.Value, param string) *FieldError
+
+var (
+	rulesMu sync.RWMutex
+	rules   = map[string]Rule{
+		"required": required,
+		"min":      minLength,
+		"max":      maxLength,
+		"valid":    selfValid,
+		"isbn":     isbn,
+		"oneof":    oneOf,
+	}
+)
+
+// RegisterRule makes a custom rule available to `binding` tags under name,
+// replacing any rule already registered with that name
+func RegisterRule(name string, rule Rule) {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	rules[name] = rule
+}
+
+// Struct validates the exported fields of the struct v points to and returns
+// Errors listing every failure, or nil if v is valid
+func Struct(v interface{}) error {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("validation: Struct called with %T", v))
+	}
+
+	var errs Errors
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		tag := field.Tag.Get("binding")
+		if !field.IsExported() || tag == "" || tag == "-" {
+			continue
+		}
+		name := jsonName(field)
+		value := rv.Field(i)
+
+		for _, term := range strings.Split(tag, ",") {
+			ruleName, param, _ := strings.Cut(strings.TrimSpace(term), "=")
+			if ruleName == "" {
+				continue
+			}
+			if ruleName == "dive" {
+				errs = append(errs, dive(name, value)...)
+				continue
+			}
+			rulesMu.RLock()
+			rule, ok := rules[ruleName]
+			rulesMu.RUnlock()
+			if !ok {
+				panic(fmt.Sprintf("validation: unknown rule %q on %s.%s", ruleName, rt.Name(), field.Name))
+			}
+			if ruleName != "required" && value.IsZero() {
+				// Optional fields are only checked when present
+				continue
+			}
+			if fe := rule(value, param); fe != nil {
+				fe.Field = name
+				fe.Message = name + " " + fe.Message
+				errs = append(errs, *fe)
+				// Later rules usually add nothing once one has failed
+				break
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// dive validates every struct element of a slice field
+func dive(name string, value reflect.Value) Errors {
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		return nil
+	}
+	var errs Errors
+	for i := 0; i < value.Len(); i++ {
+		elem := reflect.Indirect(value.Index(i))
+		if elem.Kind() != reflect.Struct {
+			continue
+		}
+		var elemErrs Errors
+		if err := Struct(elem.Addr().Interface()); err != nil {
+			elemErrs = err.(Errors)
+		}
+		prefix := fmt.Sprintf("%s[%d].", name, i)
+		for _, fe := range elemErrs {
+			fe.Message = prefix + fe.Message
+			fe.Field = prefix + fe.Field
+			errs = append(errs, fe)
+		}
+	}
+	return errs
+}
+
+// jsonName returns the name a field is known by in request bodies
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
+}
@@ -0,0 +1,130 @@
+package validation
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type price int
+
+func (p price) Validate() error {
+	if p < 0 {
+		return errors.New("negative")
+	}
+	return nil
+}
+
+type credit struct {
+	ID   uint   `json:"id" binding:"required"`
+	Role string `json:"role,omitempty" binding:"oneof=author editor"`
+}
+
+type request struct {
+	Title   string   `json:"title" binding:"required,min=2,max=5"`
+	Tags    []string `json:"tags" binding:"max=2"`
+	Pages   int      `json:"pages" binding:"min=1"`
+	ISBN    string   `json:"isbn,omitempty" binding:"isbn"`
+	Price   price    `json:"price" binding:"valid"`
+	Note    string   `binding:"max=3"`
+	Credits []credit `json:"credits" binding:"max=2,dive"`
+	// hidden is unexported, so its rule is never checked
+	hidden string `binding:"required"`
+}
+
+func TestStruct(t *testing.T) {
+	valid := request{Title: "Emma", Pages: 400, ISBN: "978-0-14-143958-7", Price: 799}
+	tests := []struct {
+		name   string
+		change func(r *request)
+		want   []FieldError
+	}{
+		{"valid", func(r *request) {}, nil},
+		{"optional fields left out", func(r *request) { r.Pages, r.ISBN, r.Price = 0, "", 0 }, nil},
+		{"missing", func(r *request) { r.Title = "" }, []FieldError{{"title", "required", "title is required"}}},
+		{"blank", func(r *request) { r.Title = "  " }, []FieldError{{"title", "required", "title is required"}}},
+		{"too short", func(r *request) { r.Title = "E" }, []FieldError{{"title", "min", "title must be at least 2 characters"}}},
+		// Runes are counted, not bytes
+		{"long in bytes", func(r *request) { r.Title = "Émilé" }, nil},
+		{"too long", func(r *request) { r.Title = "Emma 2" }, []FieldError{{"title", "max", "title must be at most 5 characters"}}},
+		{"too many items", func(r *request) { r.Tags = []string{"a", "b", "c"} }, []FieldError{{"tags", "max", "tags must be at most 2 items"}}},
+		{"number too small", func(r *request) { r.Pages = -1 }, []FieldError{{"pages", "min", "pages must be at least 1"}}},
+		{"bad checksum", func(r *request) { r.ISBN = "9780141439588" }, []FieldError{{"isbn", "isbn", "isbn is not a valid ISBN-10 or ISBN-13"}}},
+		{"invalid value", func(r *request) { r.Price = -1 }, []FieldError{{"price", "invalid", "price is invalid: negative"}}},
+		{"field without a JSON name", func(r *request) { r.Note = "long" }, []FieldError{{"Note", "max", "Note must be at most 3 characters"}}},
+		{"one of", func(r *request) { r.Credits = []credit{{ID: 1, Role: "editor"}} }, nil},
+		{"not one of", func(r *request) { r.Credits = []credit{{ID: 1, Role: "illustrator"}} }, []FieldError{{"credits[0].role", "oneof", "credits[0].role must be one of author, editor"}}},
+		{"dive", func(r *request) { r.Credits = []credit{{ID: 1}, {Role: "author"}} }, []FieldError{{"credits[1].id", "required", "credits[1].id is required"}}},
+		{"too many to dive", func(r *request) { r.Credits = make([]credit, 3) }, []FieldError{{"credits", "max", "credits must be at most 2 items"}}},
+		{"every failure", func(r *request) { r.Title, r.Pages = "", -1 }, []FieldError{
+			{"title", "required", "title is required"},
+			{"pages", "min", "pages must be at least 1"},
+		}},
+	}
+	for _, tt := range tests {
+		r := valid
+		tt.change(&r)
+		err := Struct(&r)
+		if tt.want == nil {
+			if err != nil {
+				t.Errorf("%s: Struct = %v, want nil", tt.name, err)
+			}
+			continue
+		}
+		var errs Errors
+		if !errors.As(err, &errs) || !reflect.DeepEqual([]FieldError(errs), tt.want) {
+			t.Errorf("%s: Struct = %#v, want %#v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestValidISBN(t *testing.T) {
+	tests := []struct {
+		isbn string
+		want bool
+	}{
+		{"0-7432-7356-7", true},
+		{"080442957X", true},
+		{"080442957x", true},
+		{"9780743273565", true},
+		{"978 0 7432 7356 5", true},
+		{"0743273568", false},
+		{"9780743273566", false},
+		{"X804429570", false},
+		{"97807432735", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := ValidISBN(tt.isbn); got != tt.want {
+			t.Errorf("ValidISBN(%q) = %v, want %v", tt.isbn, got, tt.want)
+		}
+	}
+}
+
+func TestRegisterRule(t *testing.T) {
+	RegisterRule("even", func(value reflect.Value, _ string) *FieldError {
+		if value.Int()%2 != 0 {
+			return &FieldError{Code: "even", Message: "must be even"}
+		}
+		return nil
+	})
+	var v struct {
+		N int `json:"n" binding:"even"`
+	}
+	v.N = 3
+	if err := Struct(&v); err == nil || err.Error() != "validation failed: n must be even" {
+		t.Errorf("Struct = %v, want n must be even", err)
+	}
+}
+
+func TestStructPanicsOnUnknownRule(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Struct did not panic on an unknown rule")
+		}
+	}()
+	var v struct {
+		N int `binding:"prime"`
+	}
+	Struct(&v)
+}